@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/backup"
+)
+
+// BackupHandler 处理快照相关的请求
+type BackupHandler struct {
+	backups *backup.Manager
+}
+
+// NewBackupHandler 创建一个新的快照处理程序
+func NewBackupHandler(backups *backup.Manager) *BackupHandler {
+	return &BackupHandler{backups: backups}
+}
+
+// RegisterBackupRoutes 注册快照接口
+func RegisterBackupRoutes(apiGroup *gin.RouterGroup, backups *backup.Manager) {
+	handler := NewBackupHandler(backups)
+
+	group := apiGroup.Group("/backups")
+	{
+		group.GET("", handler.ListBackups)
+		group.POST("", handler.CreateBackup)
+		group.GET("/:name", handler.DownloadBackup)
+	}
+}
+
+// snapshotStatus 带校验结果的快照
+type snapshotStatus struct {
+	*backup.Snapshot
+	Verified *bool  `json:"verified,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ListBackups 列出快照，最新的在前。verify=true 时逐个校验快照的完整性
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	snapshots, err := h.backups.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	verify := c.Query("verify") == "true"
+	result := make([]snapshotStatus, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		status := snapshotStatus{Snapshot: snapshot}
+		if verify {
+			err := h.backups.Verify(snapshot.Name)
+			ok := err == nil
+			status.Verified = &ok
+			if err != nil {
+				status.Error = err.Error()
+			}
+		}
+		result = append(result, status)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateBackup 立即创建一个快照，并按轮换策略清理旧快照
+func (h *BackupHandler) CreateBackup(c *gin.Context) {
+	snapshot, err := h.backups.Create()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	removed, err := h.backups.Prune()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"snapshot": snapshot, "removed": removed})
+}
+
+// DownloadBackup 下载快照文件
+func (h *BackupHandler) DownloadBackup(c *gin.Context) {
+	name := c.Param("name")
+	path, err := h.backups.Path(name)
+	if errors.Is(err, backup.ErrSnapshotNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.FileAttachment(path, name)
+}
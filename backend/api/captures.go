@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// CaptureHandler 处理收集入口的管理，以及兼容 flomo 和 usememos 格式的写入请求
+type CaptureHandler struct {
+	store    *store.MemoStore
+	captures *store.CaptureStore
+}
+
+// NewCaptureHandler 创建一个新的收集入口处理程序
+func NewCaptureHandler(memoStore *store.MemoStore, captures *store.CaptureStore) *CaptureHandler {
+	return &CaptureHandler{store: memoStore, captures: captures}
+}
+
+// RegisterCaptureRoutes 注册收集入口接口。
+// flomo 格式的地址为 /api/iwh/<密钥>/；usememos 格式以服务器地址作为 memos 地址、密钥作为访问令牌
+func RegisterCaptureRoutes(apiGroup *gin.RouterGroup, memoStore *store.MemoStore, captures *store.CaptureStore) {
+	handler := NewCaptureHandler(memoStore, captures)
+
+	group := apiGroup.Group("/captures")
+	{
+		group.GET("", handler.ListCaptures)
+		group.POST("", handler.CreateCapture)
+		group.GET("/:id", handler.GetCapture)
+		group.PUT("/:id", handler.UpdateCapture)
+		group.DELETE("/:id", handler.DeleteCapture)
+		group.POST("/:id/rotate", handler.RotateCaptureSecret)
+	}
+
+	// flomo 的地址以 / 结尾，两种写法都接受，避免 POST 请求被重定向
+	apiGroup.POST("/iwh/:secret", handler.CaptureFlomo)
+	apiGroup.POST("/iwh/:secret/", handler.CaptureFlomo)
+
+	// usememos 新版（/api/v1/memos）和旧版（/api/v1/memo）的创建接口
+	apiGroup.POST("/v1/memos", handler.CaptureMemos)
+	apiGroup.POST("/v1/memo", handler.CaptureMemos)
+}
+
+// 创建或更新收集入口的请求格式
+type captureRequest struct {
+	Name   string   `json:"name"`
+	Format string   `json:"format"`
+	Tags   []string `json:"tags"`
+}
+
+// ListCaptures 列出全部收集入口，不包含密钥
+func (h *CaptureHandler) ListCaptures(c *gin.Context) {
+	endpoints := h.captures.List()
+	for _, endpoint := range endpoints {
+		redactCapture(endpoint)
+	}
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// 密钥只在创建和重新生成时返回，其余响应中去掉
+func redactCapture(endpoint *store.CaptureEndpoint) *store.CaptureEndpoint {
+	endpoint.Secret = ""
+	return endpoint
+}
+
+// CreateCapture 创建收集入口，密钥在响应中返回
+func (h *CaptureHandler) CreateCapture(c *gin.Context) {
+	var req captureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.captures.Create(&store.CaptureEndpoint{Name: req.Name, Format: req.Format, Tags: req.Tags})
+	if err != nil {
+		c.JSON(captureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// GetCapture 获取收集入口，不包含密钥
+func (h *CaptureHandler) GetCapture(c *gin.Context) {
+	endpoint, err := h.captures.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(captureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, redactCapture(endpoint))
+}
+
+// UpdateCapture 修改收集入口的名称和标签
+func (h *CaptureHandler) UpdateCapture(c *gin.Context) {
+	var req captureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.captures.Update(c.Param("id"), &store.CaptureEndpoint{Name: req.Name, Tags: req.Tags})
+	if err != nil {
+		c.JSON(captureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, redactCapture(endpoint))
+}
+
+// DeleteCapture 删除收集入口
+func (h *CaptureHandler) DeleteCapture(c *gin.Context) {
+	if err := h.captures.Delete(c.Param("id")); err != nil {
+		c.JSON(captureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RotateCaptureSecret 重新生成密钥，旧的密钥立即失效
+func (h *CaptureHandler) RotateCaptureSecret(c *gin.Context) {
+	endpoint, err := h.captures.RotateSecret(c.Param("id"))
+	if err != nil {
+		c.JSON(captureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// 用收集入口的标签创建备忘录并记录一次收集
+func (h *CaptureHandler) capture(endpoint *store.CaptureEndpoint, content, visibility string) (*store.Memo, error) {
+	memo := &store.Memo{
+		Content:    content,
+		Tags:       endpoint.Tags,
+		Visibility: visibility,
+	}
+	if err := h.store.CreateMemo(memo); err != nil {
+		return nil, err
+	}
+	// 统计写入失败不影响已经创建的备忘录
+	h.captures.RecordUse(endpoint.ID)
+	return memo, nil
+}
+
+// flomo 的请求格式，也接受表单
+type flomoRequest struct {
+	Content string `json:"content" form:"content"`
+}
+
+// CaptureFlomo 接受 flomo API 记录格式的请求，响应格式与 flomo 相同，code 为 0 表示成功
+func (h *CaptureHandler) CaptureFlomo(c *gin.Context) {
+	endpoint, err := h.captures.Authenticate(store.CaptureFlomo, c.Param("secret"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": -1, "message": err.Error()})
+		return
+	}
+
+	var req flomoRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": -1, "message": err.Error()})
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": -1, "message": "内容不能为空"})
+		return
+	}
+
+	memo, err := h.capture(endpoint, content, store.VisibilityPrivate)
+	if err != nil {
+		c.JSON(memoErrorStatus(err), gin.H{"code": -1, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已记录",
+		"memo": gin.H{
+			"slug":       memo.ID,
+			"content":    memo.Content,
+			"tags":       memo.Tags,
+			"source":     "incoming_webhook",
+			"created_at": memo.CreatedAt.Format(time.DateTime),
+			"updated_at": memo.UpdatedAt.Format(time.DateTime),
+		},
+	})
+}
+
+// usememos 的备忘录字段
+type memosMemo struct {
+	Content    string `json:"content"`
+	Visibility string `json:"visibility"` // PRIVATE、PROTECTED 或 PUBLIC
+}
+
+// usememos 的请求格式，新版把备忘录放在 memo 字段中
+type memosRequest struct {
+	memosMemo
+	Memo *memosMemo `json:"memo"`
+}
+
+// CaptureMemos 接受 usememos 创建备忘录格式的请求。
+// 密钥通过 Authorization: Bearer 请求头或旧版的 openId 参数传递，响应同时包含新旧版本的主要字段
+func (h *CaptureHandler) CaptureMemos(c *gin.Context) {
+	secret := c.Query("openId")
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		secret = strings.TrimSpace(token)
+	}
+	endpoint, err := h.captures.Authenticate(store.CaptureMemos, secret)
+	if err != nil {
+		// 16 为 gRPC 的 Unauthenticated
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 16, "message": err.Error(), "error": err.Error()})
+		return
+	}
+
+	var req memosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 3, "message": err.Error(), "error": err.Error()})
+		return
+	}
+	fields := req.memosMemo
+	if req.Memo != nil {
+		fields = *req.Memo
+	}
+	content := strings.TrimSpace(fields.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 3, "message": "内容不能为空", "error": "内容不能为空"})
+		return
+	}
+	visibility := store.VisibilityPrivate
+	if strings.EqualFold(fields.Visibility, "PUBLIC") {
+		visibility = store.VisibilityPublic
+	}
+
+	memo, err := h.capture(endpoint, content, visibility)
+	if err != nil {
+		c.JSON(memoErrorStatus(err), gin.H{"code": 13, "message": err.Error(), "error": err.Error()})
+		return
+	}
+	memosVisibility := "PRIVATE"
+	if memo.IsPublic() {
+		memosVisibility = "PUBLIC"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"name":        "memos/" + memo.ID,
+		"uid":         memo.ID,
+		"id":          memo.ID,
+		"content":     memo.Content,
+		"visibility":  memosVisibility,
+		"tags":        memo.Tags,
+		"pinned":      false,
+		"createTime":  memo.CreatedAt.UTC().Format(time.RFC3339),
+		"updateTime":  memo.UpdatedAt.UTC().Format(time.RFC3339),
+		"displayTime": memo.CreatedAt.UTC().Format(time.RFC3339),
+		"createdTs":   memo.CreatedAt.Unix(),
+		"updatedTs":   memo.UpdatedAt.Unix(),
+	})
+}
+
+func captureErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrCaptureNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrInvalidCapture):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+func TestCaptureSecretRedacted(t *testing.T) {
+	dir, err := os.MkdirTemp("", "memo-capture-api-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	captures, err := store.NewCaptureStore(dir)
+	if err != nil {
+		t.Fatalf("创建CaptureStore失败: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterCaptureRoutes(r.Group("/api"), memoStore, captures)
+
+	request := func(method, path, body string) (int, map[string]interface{}, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serve(r, req)
+		var endpoint map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &endpoint)
+		return w.Code, endpoint, w.Body.String()
+	}
+
+	code, created, _ := request(http.MethodPost, "/api/captures", `{"name": "快捷指令", "format": "flomo"}`)
+	secret, _ := created["secret"].(string)
+	if code != http.StatusCreated || secret == "" {
+		t.Fatalf("创建时应返回密钥, 实际 %d %v", code, created)
+	}
+	id := created["id"].(string)
+
+	for _, tt := range []struct{ method, path, body string }{
+		{http.MethodGet, "/api/captures", ""},
+		{http.MethodGet, "/api/captures/" + id, ""},
+		{http.MethodPut, "/api/captures/" + id, `{"name": "改名"}`},
+	} {
+		code, _, body := request(tt.method, tt.path, tt.body)
+		if code != http.StatusOK || strings.Contains(body, "secret") || strings.Contains(body, secret) {
+			t.Errorf("%s %s 不应返回密钥, 实际 %d: %s", tt.method, tt.path, code, body)
+		}
+	}
+
+	code, rotated, _ := request(http.MethodPost, "/api/captures/"+id+"/rotate", "")
+	if newSecret, _ := rotated["secret"].(string); code != http.StatusOK || newSecret == "" || newSecret == secret {
+		t.Errorf("重新生成时应返回新的密钥, 实际 %d %v", code, rotated)
+	}
+}
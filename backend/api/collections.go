@@ -0,0 +1,327 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/export"
+	"ramblog-app/backend/feed"
+	"ramblog-app/backend/search"
+	"ramblog-app/backend/store"
+)
+
+// CollectionHandler 处理智能合集相关的请求
+type CollectionHandler struct {
+	store       *store.MemoStore
+	collections *store.CollectionStore
+	feeds       *FeedHandler
+}
+
+// NewCollectionHandler 创建一个新的合集处理程序
+func NewCollectionHandler(store *store.MemoStore, collections *store.CollectionStore, title, baseURL string) *CollectionHandler {
+	return &CollectionHandler{
+		store:       store,
+		collections: collections,
+		feeds:       NewFeedHandler(store, title, baseURL),
+	}
+}
+
+// RegisterCollectionRoutes 注册智能合集接口
+func RegisterCollectionRoutes(apiGroup *gin.RouterGroup, store *store.MemoStore, collections *store.CollectionStore, title, baseURL string) {
+	handler := NewCollectionHandler(store, collections, title, baseURL)
+
+	group := apiGroup.Group("/collections")
+	{
+		group.GET("", handler.ListCollections)
+		group.POST("", handler.CreateCollection)
+		group.GET("/:id", handler.GetCollection)
+		group.PUT("/:id", handler.UpdateCollection)
+		group.DELETE("/:id", handler.DeleteCollection)
+		group.GET("/:id/memos", handler.CollectionMemos)
+		group.GET("/:id/export", handler.ExportCollection)
+	}
+}
+
+// 合集的接口响应格式
+type collectionResponse struct {
+	*store.Collection
+	Count int `json:"count"` // 当前满足查询的备忘录数
+}
+
+// 创建或更新合集的请求格式
+type collectionRequest struct {
+	Name        string `json:"name"`
+	Query       string `json:"query"`
+	Description string `json:"description"`
+}
+
+// ListCollections 列出全部合集及各自的备忘录数
+func (h *CollectionHandler) ListCollections(c *gin.Context) {
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	collections := h.collections.List()
+	response := make([]collectionResponse, 0, len(collections))
+	for _, collection := range collections {
+		// 已保存的查询在保存时校验过，直接修改 collections.yaml 导致的错误按 0 条处理
+		count := 0
+		if matched, err := evaluate(collection, memos); err == nil {
+			count = len(matched)
+		}
+		response = append(response, collectionResponse{Collection: collection, Count: count})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateCollection 创建合集
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validCollectionQuery(c, req.Query) {
+		return
+	}
+
+	collection, err := h.collections.Create(&store.Collection{
+		Name:        req.Name,
+		Query:       req.Query,
+		Description: req.Description,
+	})
+	if err != nil {
+		c.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusCreated, collection)
+}
+
+// GetCollection 获取合集
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	collection, err := h.collections.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, collection)
+}
+
+// UpdateCollection 更新合集的名称、查询和描述
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validCollectionQuery(c, req.Query) {
+		return
+	}
+
+	collection, err := h.collections.Update(c.Param("id"), &store.Collection{
+		Name:        req.Name,
+		Query:       req.Query,
+		Description: req.Description,
+	})
+	if err != nil {
+		c.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, http.StatusOK, collection)
+}
+
+// DeleteCollection 删除合集
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	if err := h.collections.Delete(c.Param("id")); err != nil {
+		c.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CollectionMemos 实时执行合集的查询，返回满足条件的备忘录
+func (h *CollectionHandler) CollectionMemos(c *gin.Context) {
+	_, memos, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, memos)
+}
+
+// ExportCollection 导出合集。format 为 atom、rss、json 时生成订阅源，
+// 与站点订阅源一样只包含公开备忘录；format 为 zip 时打包全部备忘录文件和引用的附件
+func (h *CollectionHandler) ExportCollection(c *gin.Context) {
+	var format feedFormat
+	switch c.DefaultQuery("format", "atom") {
+	case "atom":
+		format = atomFormat
+	case "rss":
+		format = rssFormat
+	case "json":
+		format = jsonFormat
+	case "zip":
+		h.exportBundle(c)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只能是 atom、rss、json 或 zip"})
+		return
+	}
+
+	collection, memos, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	baseURL := h.feeds.requestBaseURL(c)
+	title := h.feeds.title
+	if title == "" {
+		title = "Ramblog"
+	}
+	opts := feed.Options{
+		Title:   title + " - " + collection.Name,
+		BaseURL: baseURL,
+		FeedURL: baseURL + c.Request.URL.String(),
+	}
+	memos = feed.Select(memos, opts)
+
+	body, err := format.build(memos, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	serveConditional(c, format.contentType, body, feed.LastModified(memos))
+}
+
+// 合集打包中的 collection.json
+type bundleInfo struct {
+	*store.Collection
+	ExportedAt         time.Time `json:"exportedAt"`
+	Memos              []string  `json:"memos"`
+	MissingAttachments []string  `json:"missingAttachments,omitempty"`
+}
+
+// 把合集打包为 ZIP，先在内存中生成完整的文件，出错时仍能返回 JSON 错误
+func (h *CollectionHandler) exportBundle(c *gin.Context) {
+	collection, memos, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	bundle := export.NewBundle(&buf, h.store)
+	info := bundleInfo{Collection: collection, ExportedAt: time.Now().Truncate(time.Second), Memos: []string{}}
+	for _, memo := range memos {
+		if err := bundle.AddMemo(memo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		info.Memos = append(info.Memos, memo.ID)
+	}
+	info.MissingAttachments = bundle.Missing
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err == nil {
+		err = bundle.AddFile("collection.json", data, info.ExportedAt)
+	}
+	if err == nil {
+		err = bundle.Close()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="collection-%s.zip"`, collection.ID))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// 查找合集并执行查询，出错时直接输出错误响应
+func (h *CollectionHandler) lookup(c *gin.Context) (*store.Collection, []*store.Memo, bool) {
+	collection, err := h.collections.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	memos, err = evaluate(collection, memos)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return collection, memos, true
+}
+
+// 输出合集及其当前的备忘录数
+func (h *CollectionHandler) respond(c *gin.Context, status int, collection *store.Collection) {
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	count := 0
+	if matched, err := evaluate(collection, memos); err == nil {
+		count = len(matched)
+	}
+	c.JSON(status, collectionResponse{Collection: collection, Count: count})
+}
+
+// 对备忘录执行合集的查询
+func evaluate(collection *store.Collection, memos []*store.Memo) ([]*store.Memo, error) {
+	query, err := search.ParseQuery(collection.Query)
+	if err != nil {
+		return nil, fmt.Errorf("合集 %s 的查询无效: %w", collection.ID, err)
+	}
+	return search.Filter(memos, query), nil
+}
+
+// 校验合集的查询语法，错误时输出带出错位置的 400 响应
+func validCollectionQuery(c *gin.Context, query string) bool {
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "查询不能为空"})
+		return false
+	}
+	if _, err := search.ParseQuery(query); err != nil {
+		var parseErr *search.ParseError
+		if errors.As(err, &parseErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error(), "position": parseErr.Pos})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// 根据合集存储返回的错误选择状态码
+func collectionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrCollectionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrInvalidCollection):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// 没有事件时定期发送注释，避免代理断开空闲的连接
+const eventHeartbeat = 30 * time.Second
+
+// EventHandler 处理事件流
+type EventHandler struct {
+	events *store.EventHub
+}
+
+// NewEventHandler 创建一个新的事件处理程序
+func NewEventHandler(events *store.EventHub) *EventHandler {
+	return &EventHandler{events: events}
+}
+
+// RegisterEventRoutes 注册事件流接口
+func RegisterEventRoutes(apiGroup *gin.RouterGroup, memoStore *store.MemoStore) {
+	handler := NewEventHandler(memoStore.Events())
+	apiGroup.GET("/events", handler.Stream)
+}
+
+// Stream 以 Server-Sent Events 输出备忘录和标签的变更。
+// 重连时根据 Last-Event-ID（或 ?lastEventId=）补发错过的事件，无法补发时先发送 reset 事件
+func (h *EventHandler) Stream(c *gin.Context) {
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	sub, missed, complete := h.events.Subscribe(lastID)
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !complete {
+		// reset 的ID是当前最新的事件，客户端重新加载数据后从这里继续
+		fmt.Fprintf(c.Writer, "id: %s\nevent: reset\ndata: {}\n\n", h.events.LastID())
+	}
+	for _, event := range missed {
+		writeEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// 订阅因处理过慢被断开，客户端会带上 Last-Event-ID 重连
+				return
+			}
+			writeEvent(c.Writer, event)
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, event store.Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/export"
+	"ramblog-app/backend/store"
+)
+
+// Export 导出备忘录，format 指定格式：
+//   - zip（默认）：包含备忘录文件、附件和 manifest.json 的压缩包，可通过 /api/import/archive 恢复
+//   - jsonl：每行一条完整的备忘录 JSON
+//   - csv：备忘录的元数据
+//   - html：内联了图片的单个 HTML 文件
+//   - epub：按月份分章的电子书
+//
+// 支持与备忘录列表相同的查询参数 q，只导出符合条件的备忘录；
+// zip 格式带 q 时只包含符合条件的备忘录及其引用的附件
+func (h *MemoHandler) Export(c *gin.Context) {
+	format, ok := export.LookupFormat(c.DefaultQuery("format", export.Formats[0].Name))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": export.ErrUnknownFormat.Error()})
+		return
+	}
+
+	opts := export.Options{Title: c.Query("title"), Author: c.Query("author"), Query: c.Query("q")}
+	var memos []*store.Memo
+	if format.Name != "zip" || opts.Query != "" {
+		if memos, ok = h.queryMemos(c); !ok {
+			return
+		}
+	}
+
+	name := "ramblog-" + time.Now().Format("20060102-150405") + format.Ext
+	c.Header("Content-Type", format.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	c.Status(http.StatusOK)
+
+	// 响应头已经发出，出错时只能中断连接
+	if err := export.Write(c.Writer, h.store, format.Name, memos, opts); err != nil {
+		log.Printf("导出 %s 失败: %v", format.Name, err)
+		c.Abort()
+	}
+}
+
+// ImportArchive 恢复 GET /api/export?format=zip 导出的压缩包。表单字段 file 为压缩包，
+// conflict 指定与现有文件冲突时的处理方式：skip（默认）、overwrite 或 rename
+func (h *MemoHandler) ImportArchive(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未收到文件"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	conflict := c.PostForm("conflict")
+	if conflict == "" {
+		conflict = c.Query("conflict")
+	}
+	report, err := export.RestoreArchive(file, header.Size, h.store, conflict)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, export.ErrInvalidArchive) || errors.Is(err, export.ErrInvalidConflict) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
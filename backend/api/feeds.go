@@ -0,0 +1,182 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/feed"
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// FeedHandler 处理订阅源相关的请求
+type FeedHandler struct {
+	store   *store.MemoStore
+	title   string
+	baseURL string
+}
+
+// NewFeedHandler 创建一个新的订阅源处理程序，baseURL 为空时根据请求推断
+func NewFeedHandler(store *store.MemoStore, title, baseURL string) *FeedHandler {
+	return &FeedHandler{
+		store:   store,
+		title:   title,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// 订阅源格式定义
+type feedFormat struct {
+	contentType string
+	build       func([]*store.Memo, feed.Options) ([]byte, error)
+}
+
+var (
+	atomFormat = feedFormat{"application/atom+xml; charset=utf-8", feed.Atom}
+	rssFormat  = feedFormat{"application/rss+xml; charset=utf-8", feed.RSS}
+	jsonFormat = feedFormat{"application/feed+json; charset=utf-8", feed.JSON}
+)
+
+// 标签订阅源的文件名
+var tagFeedFormats = map[string]feedFormat{
+	"feed.xml":  atomFormat,
+	"rss.xml":   rssFormat,
+	"feed.json": jsonFormat,
+}
+
+// RegisterFeedRoutes 注册订阅源路由和公开备忘录页面（位于站点根路径而非 /api 下）
+func RegisterFeedRoutes(r gin.IRouter, store *store.MemoStore, title, baseURL string) {
+	handler := NewFeedHandler(store, title, baseURL)
+
+	r.GET("/feed.xml", handler.serve(atomFormat))
+	r.GET("/rss.xml", handler.serve(rssFormat))
+	r.GET("/feed.json", handler.serve(jsonFormat))
+
+	// 按标签的订阅源，标签可以包含 /，如 /tags/读书/小说/feed.xml
+	r.GET("/tags/*path", handler.serveTag)
+
+	// 订阅源条目链接到的页面
+	r.GET("/memos/:id", handler.ViewMemo)
+}
+
+// 生成指定格式订阅源的处理函数
+func (h *FeedHandler) serve(format feedFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.serveFeed(c, format, "")
+	}
+}
+
+// 标签订阅源，路径的最后一段为订阅源文件名，之前的部分为标签
+func (h *FeedHandler) serveTag(c *gin.Context) {
+	path := strings.TrimPrefix(c.Param("path"), "/")
+	i := strings.LastIndex(path, "/")
+	format, ok := tagFeedFormats[path[i+1:]]
+	if i <= 0 || !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订阅源不存在"})
+		return
+	}
+	// 别名订阅源与标签名的订阅源内容相同
+	h.serveFeed(c, format, h.store.TagRegistry().Canonical(path[:i]))
+}
+
+func (h *FeedHandler) serveFeed(c *gin.Context, format feedFormat, tag string) {
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseURL := h.requestBaseURL(c)
+	opts := feed.Options{
+		Title:   h.title,
+		BaseURL: baseURL,
+		FeedURL: baseURL + c.Request.URL.EscapedPath(),
+		Tag:     tag,
+	}
+	memos = feed.Select(memos, opts)
+
+	body, err := format.build(memos, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	serveConditional(c, format.contentType, body, feed.LastModified(memos))
+}
+
+// ViewMemo 渲染公开备忘录的页面，私有备忘录与不存在的备忘录一样返回 404
+func (h *FeedHandler) ViewMemo(c *gin.Context) {
+	memo, err := h.store.GetMemo(c.Param("id"))
+	if err != nil || !memo.IsPublic() {
+		renderSharePage(c, http.StatusNotFound, sharePageData{Message: "备忘录不存在"})
+		return
+	}
+
+	html, err := render.New(render.Options{}).Render(memo.Content)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	renderSharePage(c, http.StatusOK, sharePageData{Memo: memo, HTML: template.HTML(html)})
+}
+
+// 返回站点根地址，未配置时根据请求头推断
+func (h *FeedHandler) requestBaseURL(c *gin.Context) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+	return requestBaseURL(c)
+}
+
+// requestBaseURL 根据请求（包括反向代理头）推断站点根地址
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := c.Request.Host
+	if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
+
+// serveConditional 输出响应内容，并处理 If-None-Match 条件请求。
+// Last-Modified 是订阅源中最新条目的修改时间，删除条目或改为私有时不会变化，
+// 因此只作为参考，不处理 If-Modified-Since，是否变化由内容的 ETag 判断
+func serveConditional(c *gin.Context, contentType string, body []byte, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// 判断 If-None-Match 头是否包含指定 ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+func newFeedTestServer(t *testing.T) (*gin.Engine, *store.MemoStore) {
+	dir, err := os.MkdirTemp("", "memo-feed-api-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	memoStore.SetClock(func() time.Time { return now })
+	if err := memoStore.TagRegistry().Set(&store.TagMeta{Name: "读书", Aliases: []string{"reading"}}); err != nil {
+		t.Fatalf("设置标签别名失败: %v", err)
+	}
+	for _, memo := range []*store.Memo{
+		{Content: "公开 #读书/小说", Visibility: store.VisibilityPublic},
+		{Content: "私有 #读书"},
+		{Content: "其他 #生活", Visibility: store.VisibilityPublic},
+	} {
+		if err := memoStore.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterFeedRoutes(r, memoStore, "测试", "https://example.com")
+	return r, memoStore
+}
+
+func TestTagFeed(t *testing.T) {
+	r, _ := newFeedTestServer(t)
+
+	for _, path := range []string{"/tags/读书/feed.xml", "/tags/读书/小说/rss.xml", "/tags/reading/feed.json"} {
+		w := serve(r, httptest.NewRequest(http.MethodGet, (&url.URL{Path: path}).String(), nil))
+		body := w.Body.String()
+		if w.Code != http.StatusOK || !strings.Contains(body, "公开") || strings.Contains(body, "私有") || strings.Contains(body, "其他") {
+			t.Errorf("%s 应只包含该标签及子标签的公开备忘录, 实际 %d:\n%s", path, w.Code, body)
+		}
+	}
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/tags/reading/feed.json", nil))
+	if !strings.Contains(w.Body.String(), `"title": "测试 #读书"`) {
+		t.Errorf("别名订阅源应使用标签名:\n%s", w.Body)
+	}
+
+	for _, path := range []string{"/tags/读书/", "/tags/feed.xml", "/tags/读书/index.html"} {
+		if w := serve(r, httptest.NewRequest(http.MethodGet, (&url.URL{Path: path}).String(), nil)); w.Code != http.StatusNotFound {
+			t.Errorf("%s 应返回 404, 实际 %d", path, w.Code)
+		}
+	}
+}
+
+func TestFeedConditionalRequest(t *testing.T) {
+	r, memoStore := newFeedTestServer(t)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || etag == "" || lastModified != "Sat, 02 Mar 2024 11:00:00 GMT" {
+		t.Fatalf("响应应包含 ETag 和 Last-Modified, 实际 %d %q %q", w.Code, etag, lastModified)
+	}
+
+	request := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		req.Header.Set(header, value)
+		return serve(r, req)
+	}
+	tests := []struct {
+		header, value string
+		want          int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		// 删除条目时 Last-Modified 不变，只按 ETag 判断
+		{"If-Modified-Since", lastModified, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := request(tt.header, tt.value)
+		if w.Code != tt.want {
+			t.Errorf("%s: %s 应返回 %d, 实际 %d", tt.header, tt.value, tt.want, w.Code)
+		}
+		if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("304 响应不应包含内容")
+		}
+	}
+
+	// 修改公开备忘录后旧的 ETag 失效
+	memos, _ := memoStore.ListMemos()
+	for _, memo := range memos {
+		if memo.IsPublic() {
+			if err := memoStore.UpdateMemo(memo.ID, &store.Memo{Content: "修改后", Visibility: store.VisibilityPublic}); err != nil {
+				t.Fatalf("更新备忘录失败: %v", err)
+			}
+			break
+		}
+	}
+	if w := request("If-None-Match", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("内容变化后应返回新的订阅源, 实际 %d", w.Code)
+	}
+
+	// 公开备忘录改为私有后，即使最新条目的时间不变也返回新的订阅源
+	w = serve(r, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	etag, lastModified = w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	for _, memo := range memos {
+		if memo.IsPublic() && strings.Contains(memo.Content, "公开") {
+			if err := memoStore.UpdateMemo(memo.ID, &store.Memo{Visibility: store.VisibilityPrivate}); err != nil {
+				t.Fatalf("更新备忘录失败: %v", err)
+			}
+		}
+	}
+	for _, header := range []string{"If-None-Match", "If-Modified-Since"} {
+		value := etag
+		if header == "If-Modified-Since" {
+			value = lastModified
+		}
+		if w := request(header, value); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "公开") {
+			t.Errorf("%s: 条目改为私有后应返回新的订阅源, 实际 %d", header, w.Code)
+		}
+	}
+}
+
+func TestPublicMemoPage(t *testing.T) {
+	r, memoStore := newFeedTestServer(t)
+	memos, _ := memoStore.ListMemos()
+	for _, memo := range memos {
+		w := serve(r, httptest.NewRequest(http.MethodGet, "/memos/"+memo.ID, nil))
+		if memo.IsPublic() {
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), strings.Fields(memo.Content)[0]) {
+				t.Errorf("公开备忘录的页面应返回 200, 实际 %d", w.Code)
+			}
+		} else if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "私有") {
+			t.Errorf("私有备忘录的页面应返回 404, 实际 %d", w.Code)
+		}
+	}
+
+	// 订阅源中的条目地址可以访问
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/feed.json", nil))
+	if !strings.Contains(w.Body.String(), `"url": "https://example.com/memos/`) {
+		t.Errorf("条目地址应为 /memos/<id>:\n%s", w.Body)
+	}
+}
@@ -1,24 +1,31 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/search"
 	"ramblog-app/backend/store"
 )
 
 // MemoHandler 处理与备忘录相关的API请求
 type MemoHandler struct {
-	store *store.MemoStore
+	store   *store.MemoStore
+	related *search.RelatedIndex
 }
 
 // NewMemoHandler 创建一个新的备忘录处理程序
 func NewMemoHandler(store *store.MemoStore) *MemoHandler {
 	return &MemoHandler{
-		store: store,
+		store:   store,
+		related: search.NewRelatedIndex(),
 	}
 }
 
@@ -31,27 +38,167 @@ func RegisterRoutes(r *gin.RouterGroup, store *store.MemoStore) {
 	{
 		memos.GET("", handler.ListMemos)
 		memos.POST("", handler.CreateMemo)
+		memos.GET("/on-this-day", handler.OnThisDay)
+		memos.GET("/random", handler.RandomMemo)
 		memos.GET("/:id", handler.GetMemo)
+		memos.GET("/:id/html", handler.GetMemoHTML)
+		memos.GET("/:id/backlinks", handler.GetBacklinks)
+		memos.GET("/:id/related", handler.GetRelated)
+		memos.GET("/:id/thread", handler.GetThread)
+		memos.POST("/:id/replies", handler.CreateReply)
+		memos.POST("/:id/review", handler.ReviewMemo)
+		memos.POST("/:id/trash", handler.TrashMemo)
 		memos.PUT("/:id", handler.UpdateMemo)
 		memos.DELETE("/:id", handler.DeleteMemo)
 	}
 
 	// 标签路由
-	r.GET("/tags", handler.ListTags)
+	tags := r.Group("/tags")
+	{
+		tags.GET("", handler.ListTags)
+		tags.POST("/rename", handler.RenameTag)
+		tags.POST("/merge", handler.MergeTags)
+		// 标签名可能包含 /，使用通配参数
+		tags.GET("/*name", handler.GetTag)
+		tags.PUT("/*name", handler.UpdateTag)
+	}
+
+	// 回收站
+	trash := r.Group("/trash")
+	{
+		trash.GET("", handler.ListTrash)
+		trash.POST("/:id/restore", handler.RestoreMemo)
+		trash.DELETE("/:id", handler.PurgeMemo)
+	}
+
+	// 维护
+	maintenance := r.Group("/maintenance")
+	{
+		maintenance.GET("/duplicates", handler.ListDuplicates)
+		maintenance.POST("/duplicates/merge", handler.MergeDuplicates)
+	}
+
+	// 间隔回顾
+	r.GET("/review", handler.ReviewQueue)
+
+	// 任务路由
+	r.GET("/tasks", handler.ListTasks)
+	r.POST("/tasks/toggle", handler.ToggleTask)
+
+	// 链接图
+	r.GET("/graph", handler.GetGraph)
 
 	// 文件上传路由
 	r.POST("/upload", handler.UploadFile)
+
+	// 从其他工具导入
+	r.POST("/import", handler.Import)
+
+	// 导出与恢复
+	r.GET("/export", handler.Export)
+	r.POST("/import/archive", handler.ImportArchive)
 }
 
-// ListMemos 列出所有备忘录
+// memoWithHTML 附带渲染后HTML的备忘录
+type memoWithHTML struct {
+	*store.Memo
+	HTML string `json:"html"`
+}
+
+// threadWithHTML 附带渲染后HTML的对话树节点，回复同样附带HTML
+type threadWithHTML struct {
+	*store.ThreadNode
+	HTML    string            `json:"html"`
+	Replies []*threadWithHTML `json:"replies"`
+}
+
+// 为对话树中的每条备忘录渲染HTML
+func threadsWithHTML(nodes []*store.ThreadNode) ([]*threadWithHTML, error) {
+	result := make([]*threadWithHTML, 0, len(nodes))
+	for _, node := range nodes {
+		html, err := render.HTML(node.Content)
+		if err != nil {
+			return nil, err
+		}
+		replies, err := threadsWithHTML(node.Replies)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &threadWithHTML{ThreadNode: node, HTML: html, Replies: replies})
+	}
+	return result, nil
+}
+
+// 解析 fields 参数，如 ?fields=html
+func requestedFields(c *gin.Context) map[string]bool {
+	fields := make(map[string]bool)
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields[field] = true
+		}
+	}
+	return fields
+}
+
+// ListMemos 列出所有备忘录，?q= 按查询语言筛选，?fields=html 时附带渲染后的HTML，
+// ?collapse=threads 时只列出对话的根备忘录，回复折叠在 replies 中
 func (h *MemoHandler) ListMemos(c *gin.Context) {
+	memos, ok := h.queryMemos(c)
+	if !ok {
+		return
+	}
+
+	withHTML := requestedFields(c)["html"]
+	if c.Query("collapse") == "threads" {
+		threads := store.CollapseThreads(memos)
+		if !withHTML {
+			c.JSON(http.StatusOK, threads)
+			return
+		}
+		result, err := threadsWithHTML(threads)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if !withHTML {
+		c.JSON(http.StatusOK, memos)
+		return
+	}
+
+	result := make([]memoWithHTML, 0, len(memos))
+	for _, memo := range memos {
+		html, err := render.HTML(memo.Content)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result = append(result, memoWithHTML{Memo: memo, HTML: html})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// queryMemos 列出备忘录并按查询参数 q 筛选，出错时已写入响应并返回 false
+func (h *MemoHandler) queryMemos(c *gin.Context) ([]*store.Memo, bool) {
 	memos, err := h.store.ListMemos()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, memos)
+	if q := c.Query("q"); q != "" {
+		query, err := search.ParseQuery(q)
+		var parseErr *search.ParseError
+		if errors.As(err, &parseErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error(), "position": parseErr.Pos})
+			return nil, false
+		}
+		memos = search.Filter(memos, query)
+	}
+	return memos, true
 }
 
 // CreateMemo 创建一个新的备忘录
@@ -63,7 +210,7 @@ func (h *MemoHandler) CreateMemo(c *gin.Context) {
 	}
 
 	if err := h.store.CreateMemo(&memo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(memoErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -82,6 +229,24 @@ func (h *MemoHandler) GetMemo(c *gin.Context) {
 	c.JSON(http.StatusOK, memo)
 }
 
+// GetMemoHTML 获取备忘录渲染并清理后的HTML片段
+func (h *MemoHandler) GetMemoHTML(c *gin.Context) {
+	id := c.Param("id")
+	memo, err := h.store.GetMemo(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	html, err := render.HTML(memo.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
 // UpdateMemo 更新备忘录
 func (h *MemoHandler) UpdateMemo(c *gin.Context) {
 	id := c.Param("id")
@@ -93,7 +258,7 @@ func (h *MemoHandler) UpdateMemo(c *gin.Context) {
 	}
 
 	if err := h.store.UpdateMemo(id, &updates); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(memoErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -107,18 +272,134 @@ func (h *MemoHandler) UpdateMemo(c *gin.Context) {
 	c.JSON(http.StatusOK, memo)
 }
 
-// DeleteMemo 删除备忘录
+// DeleteMemo 删除备忘录，返回仍然链接到它的备忘录
 func (h *MemoHandler) DeleteMemo(c *gin.Context) {
 	id := c.Param("id")
+	graph, err := h.store.LinkGraph()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := h.store.DeleteMemo(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, gin.H{"id": id, "backlinks": graph.Backlinks(id)})
+}
+
+// 回复目标不合法返回400，其他错误返回500
+func memoErrorStatus(err error) int {
+	if errors.Is(err, store.ErrInvalidParent) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// CreateReply 创建一条回复指定备忘录的备忘录
+func (h *MemoHandler) CreateReply(c *gin.Context) {
+	parent := c.Param("id")
+	if _, err := h.store.GetMemo(parent); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var memo store.Memo
+	if err := c.ShouldBindJSON(&memo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.CreateReply(parent, &memo); err != nil {
+		c.JSON(memoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, memo)
+}
+
+// GetThread 返回包含指定备忘录的整个对话树
+func (h *MemoHandler) GetThread(c *gin.Context) {
+	thread, err := h.store.Thread(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
 }
 
-// ListTags 列出所有唯一标签
+// GetBacklinks 列出链接到指定备忘录的其他备忘录
+func (h *MemoHandler) GetBacklinks(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.store.GetMemo(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	graph, err := h.store.LinkGraph()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph.Backlinks(id))
+}
+
+// 相关备忘录的默认数量
+const defaultRelatedLimit = 10
+
+// GetRelated 列出与指定备忘录内容相近的其他备忘录，?limit= 默认10条
+func (h *MemoHandler) GetRelated(c *gin.Context) {
+	id := c.Param("id")
+	limit := defaultRelatedLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的数量: " + s})
+			return
+		}
+		limit = n
+	}
+
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.related.Sync(memos)
+
+	related := h.related.Related(id, limit)
+	if related == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "备忘录不存在: " + id})
+		return
+	}
+	c.JSON(http.StatusOK, related)
+}
+
+// GetGraph 返回备忘录链接图的节点和边，以及指向不存在备忘录的链接
+func (h *MemoHandler) GetGraph(c *gin.Context) {
+	graph, err := h.store.LinkGraph()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// tagInfo 扁平列表中的标签
+type tagInfo struct {
+	ID      string   `json:"id"` // 与名称相同，兼容前端的 Tag 接口
+	Name    string   `json:"name"`
+	Count   int      `json:"count"`
+	Sources []string `json:"sources"` // frontmatter 和/或 content
+	Color   string   `json:"color,omitempty"`
+	Icon    string   `json:"icon,omitempty"`
+}
+
+// ListTags 列出所有被直接使用的标签，按名称排序；?tree=true 时返回层级树
 func (h *MemoHandler) ListTags(c *gin.Context) {
 	memos, err := h.store.ListMemos()
 	if err != nil {
@@ -126,23 +407,179 @@ func (h *MemoHandler) ListTags(c *gin.Context) {
 		return
 	}
 
-	// 使用map来确保标签唯一性
-	tagsMap := make(map[string]bool)
-	for _, memo := range memos {
-		for _, tag := range memo.Tags {
-			tagsMap[tag] = true
-		}
+	tree := store.BuildTagTree(memos)
+	h.store.TagRegistry().Annotate(tree)
+	if c.Query("tree") == "true" {
+		c.JSON(http.StatusOK, tree)
+		return
 	}
 
-	// 将map转换为切片
-	tags := make([]string, 0, len(tagsMap))
-	for tag := range tagsMap {
-		tags = append(tags, tag)
+	// 只保留被直接使用的标签，树的遍历顺序即按名称排序
+	tags := []tagInfo{}
+	var walk func(nodes []*store.TagNode)
+	walk = func(nodes []*store.TagNode) {
+		for _, node := range nodes {
+			if node.Count > 0 {
+				tags = append(tags, tagInfo{
+					ID:      node.Path,
+					Name:    node.Path,
+					Count:   node.Count,
+					Sources: node.Sources,
+					Color:   node.Color,
+					Icon:    node.Icon,
+				})
+			}
+			walk(node.Children)
+		}
 	}
+	walk(tree)
 
 	c.JSON(http.StatusOK, tags)
 }
 
+// 标签参数错误返回400，其他错误返回500
+func tagErrorStatus(err error) int {
+	if errors.Is(err, store.ErrInvalidTag) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// 标签改名的请求格式
+type renameTagRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// RenameTag 改名标签及其子标签，改写所有相关备忘录
+func (h *MemoHandler) RenameTag(c *gin.Context) {
+	var req renameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.store.RenameTag(req.From, req.To)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// 标签合并的请求格式
+type mergeTagsRequest struct {
+	Sources []string `json:"sources" binding:"required"`
+	Target  string   `json:"target" binding:"required"`
+}
+
+// MergeTags 将多个标签合并到目标标签，改写所有相关备忘录
+func (h *MemoHandler) MergeTags(c *gin.Context) {
+	var req mergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.store.MergeTags(req.Sources, req.Target)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// tagDetail 单个标签的元数据和使用情况
+type tagDetail struct {
+	*store.TagMeta
+	Count      int        `json:"count"`
+	TotalCount int        `json:"totalCount"`
+	LastUsed   *time.Time `json:"lastUsed"`
+	Sources    []string   `json:"sources"`
+}
+
+// 在标签树中查找完整路径为 path 的节点
+func findTagNode(nodes []*store.TagNode, path string) *store.TagNode {
+	for _, node := range nodes {
+		if node.Path == path {
+			return node
+		}
+		if strings.HasPrefix(path, node.Path+"/") {
+			return findTagNode(node.Children, path)
+		}
+	}
+	return nil
+}
+
+// 通配参数带有前导 /
+func tagParam(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("name"), "/")
+}
+
+// GetTag 获取单个标签的元数据和使用情况，未登记也未被使用的标签返回404
+func (h *MemoHandler) GetTag(c *gin.Context) {
+	name := tagParam(c)
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := h.store.TagRegistry().Get(name)
+	node := findTagNode(store.BuildTagTree(memos), name)
+	if meta == nil && node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "标签不存在"})
+		return
+	}
+	if meta == nil {
+		meta = &store.TagMeta{Name: name}
+	}
+
+	detail := tagDetail{TagMeta: meta, Sources: []string{}}
+	if node != nil {
+		detail.Count = node.Count
+		detail.TotalCount = node.TotalCount
+		detail.LastUsed = node.LastUsed
+		detail.Sources = node.Sources
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// 更新标签的请求格式
+type updateTagRequest struct {
+	Color       string   `json:"color"`
+	Description string   `json:"description"`
+	Icon        string   `json:"icon"`
+	Aliases     []string `json:"aliases"`
+}
+
+// UpdateTag 设置标签的颜色、描述、图标和别名
+func (h *MemoHandler) UpdateTag(c *gin.Context) {
+	var req updateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	registry := h.store.TagRegistry()
+	name := tagParam(c)
+	meta := &store.TagMeta{
+		Name:        name,
+		Color:       req.Color,
+		Description: req.Description,
+		Icon:        req.Icon,
+		Aliases:     req.Aliases,
+	}
+	if err := registry.Set(meta); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, registry.Get(name))
+}
+
 // UploadFile 处理文件上传
 func (h *MemoHandler) UploadFile(c *gin.Context) {
 	file, err := c.FormFile("file")
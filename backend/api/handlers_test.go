@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+func newMemoTestServer(t *testing.T) (*gin.Engine, *store.MemoStore) {
+	dir, err := os.MkdirTemp("", "memo-api-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r.Group("/api"), memoStore)
+	return r, memoStore
+}
+
+func TestListTags(t *testing.T) {
+	r, memoStore := newMemoTestServer(t)
+	for _, content := range []string{"#读书/小说", "#读书 #生活"} {
+		if err := memoStore.CreateMemo(&store.Memo{Content: content}); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+	}
+
+	// 默认返回扁平列表，每个标签都有 id，与前端保存的配置兼容
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	var flat []tagInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &flat); err != nil {
+		t.Fatalf("默认应返回扁平列表: %v\n%s", err, w.Body)
+	}
+	var names []string
+	for _, tag := range flat {
+		if tag.ID != tag.Name {
+			t.Errorf("标签 id 应为标签名: %+v", tag)
+		}
+		names = append(names, tag.Name)
+	}
+	if want := []string{"生活", "读书", "读书/小说"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("标签列表不正确: %v", names)
+	}
+
+	w = serve(r, httptest.NewRequest(http.MethodGet, "/api/tags?tree=true", nil))
+	var tree []*store.TagNode
+	if err := json.Unmarshal(w.Body.Bytes(), &tree); err != nil {
+		t.Fatalf("?tree=true 应返回层级树: %v\n%s", err, w.Body)
+	}
+	for _, node := range tree {
+		if node.Path == "读书" && (node.TotalCount != 2 || len(node.Children) != 1) {
+			t.Errorf("标签树节点不正确: %+v", node)
+		}
+	}
+	if len(tree) != 2 {
+		t.Errorf("标签树应有 2 个根节点, 实际 %d", len(tree))
+	}
+}
+
+func TestMergeDuplicatesStatus(t *testing.T) {
+	r, memoStore := newMemoTestServer(t)
+	var ids []string
+	for _, content := range []string{"重复的内容", "重复的内容 "} {
+		memo := &store.Memo{Content: content}
+		if err := memoStore.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+		ids = append(ids, memo.ID)
+	}
+
+	merge := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/maintenance/duplicates/merge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serve(r, req).Code
+	}
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"ids": ["` + ids[0] + `", "` + ids[0] + `"]}`, http.StatusBadRequest},
+		{`{"ids": ["` + ids[0] + `", "` + ids[1] + `"], "target": "不存在"}`, http.StatusBadRequest},
+		{`{"ids": ["` + ids[0] + `", "不存在"]}`, http.StatusNotFound},
+		{`{"ids": ["` + ids[0] + `", "` + ids[1] + `"], "target": "` + ids[1] + `"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := merge(tt.body); got != tt.want {
+			t.Errorf("%s: 期望 %d, 实际 %d", tt.body, tt.want, got)
+		}
+	}
+	if _, err := memoStore.GetMemo(ids[0]); err == nil {
+		t.Error("合并后其余备忘录应移入回收站")
+	}
+}
+
+func TestDetachReply(t *testing.T) {
+	r, memoStore := newMemoTestServer(t)
+	root := &store.Memo{Content: "根"}
+	if err := memoStore.CreateMemo(root); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	reply := &store.Memo{Content: "回复"}
+	if err := memoStore.CreateReply(root.ID, reply); err != nil {
+		t.Fatalf("创建回复失败: %v", err)
+	}
+
+	update := func(body string) *store.Memo {
+		req := httptest.NewRequest(http.MethodPut, "/api/memos/"+reply.ID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serve(r, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("更新备忘录失败: %d %s", w.Code, w.Body)
+		}
+		memo, _ := memoStore.GetMemo(reply.ID)
+		return memo
+	}
+
+	// 没有给出 parent 时保持原来的父备忘录
+	if memo := update(`{"content": "改过的回复"}`); memo.ParentID() != root.ID {
+		t.Errorf("未给出 parent 时不应修改, 实际 %q", memo.ParentID())
+	}
+	if memo := update(`{"parent": ""}`); memo.Parent != nil || memo.Content != "改过的回复" {
+		t.Errorf("parent 为空字符串时应不再作为回复, 实际 %q", memo.ParentID())
+	}
+	if memo := update(`{"parent": "` + root.ID + `"}`); memo.ParentID() != root.ID {
+		t.Errorf("应重新回复根备忘录, 实际 %q", memo.ParentID())
+	}
+}
+
+func TestCollapseThreadsWithHTML(t *testing.T) {
+	r, memoStore := newMemoTestServer(t)
+	root := &store.Memo{Content: "**根**"}
+	if err := memoStore.CreateMemo(root); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if err := memoStore.CreateReply(root.ID, &store.Memo{Content: "*回复*"}); err != nil {
+		t.Fatalf("创建回复失败: %v", err)
+	}
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/api/memos?collapse=threads&fields=html", nil))
+	var threads []struct {
+		ID         string `json:"id"`
+		HTML       string `json:"html"`
+		ReplyCount int    `json:"replyCount"`
+		Replies    []struct {
+			HTML    string            `json:"html"`
+			Replies []json.RawMessage `json:"replies"`
+		} `json:"replies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &threads); err != nil || len(threads) != 1 {
+		t.Fatalf("应返回 1 个对话: %v\n%s", err, w.Body)
+	}
+	thread := threads[0]
+	if thread.ID != root.ID || thread.ReplyCount != 1 || !strings.Contains(thread.HTML, "<strong>根</strong>") {
+		t.Errorf("根备忘录应附带 HTML: %+v", thread)
+	}
+	if len(thread.Replies) != 1 || !strings.Contains(thread.Replies[0].HTML, "<em>回复</em>") || thread.Replies[0].Replies == nil {
+		t.Errorf("回复应附带 HTML: %+v", thread.Replies)
+	}
+}
+
+func TestTrashMemo(t *testing.T) {
+	r, memoStore := newMemoTestServer(t)
+	memo := &store.Memo{Content: "要移入回收站的"}
+	if err := memoStore.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+
+	if w := serve(r, httptest.NewRequest(http.MethodPost, "/api/memos/"+memo.ID+"/trash", nil)); w.Code != http.StatusNoContent {
+		t.Fatalf("移入回收站应返回 204, 实际 %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/api/memos/"+memo.ID, nil)); w.Code != http.StatusNotFound {
+		t.Errorf("移入回收站后备忘录应不存在, 实际 %d", w.Code)
+	}
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/api/trash", nil))
+	if !strings.Contains(w.Body.String(), memo.ID) {
+		t.Errorf("回收站中应有该备忘录: %s", w.Body)
+	}
+	if w := serve(r, httptest.NewRequest(http.MethodPost, "/api/memos/"+memo.ID+"/trash", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("重复移入回收站应返回 404, 实际 %d", w.Code)
+	}
+
+	if w := serve(r, httptest.NewRequest(http.MethodPost, "/api/trash/"+memo.ID+"/restore", nil)); w.Code != http.StatusOK {
+		t.Errorf("恢复应返回 200, 实际 %d", w.Code)
+	}
+	if got, err := memoStore.GetMemo(memo.ID); err != nil || got.Content != memo.Content {
+		t.Errorf("恢复后备忘录不正确: %v %v", got, err)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/importer"
+)
+
+// Import 导入上传的导出文件。表单字段 file 为导出文件（目录形式的导出需打包为 ZIP），
+// format 指定格式（默认自动识别），dryRun=true 时只返回报告不写入
+func (h *MemoHandler) Import(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未收到文件"})
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "ramblog-upload")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// 保留原文件名，格式识别依赖扩展名
+	path := filepath.Join(dir, filepath.Base(file.Filename))
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存上传的文件失败: " + err.Error()})
+		return
+	}
+
+	opts := importer.Options{DryRun: c.PostForm("dryRun") == "true" || c.Query("dryRun") == "true"}
+	report, err := importer.Import(h.store, c.PostForm("format"), path, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if report.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/search"
+	"ramblog-app/backend/store"
+)
+
+// ListDuplicates 列出重复和近似重复的备忘录簇，?threshold= 为0到1之间的相似度，默认0.7
+func (h *MemoHandler) ListDuplicates(c *gin.Context) {
+	threshold := search.DefaultDuplicateThreshold
+	if s := c.Query("threshold"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 || v > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的相似度: " + s})
+			return
+		}
+		threshold = v
+	}
+
+	memos, err := h.store.ListMemos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, search.FindDuplicates(memos, threshold))
+}
+
+// 合并备忘录的请求格式
+type mergeMemosRequest struct {
+	IDs    []string `json:"ids" binding:"required,min=2"`
+	Target string   `json:"target"` // 保留的备忘录，默认为最早创建的一条
+}
+
+// MergeDuplicates 把选中备忘录的正文和标签合并到一条中，其余移入回收站
+func (h *MemoHandler) MergeDuplicates(c *gin.Context) {
+	var req mergeMemosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "重复的备忘录ID: " + id})
+			return
+		}
+		seen[id] = true
+	}
+	if req.Target != "" && !seen[req.Target] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "保留的备忘录不在 ids 中: " + req.Target})
+		return
+	}
+
+	var oldest *store.Memo
+	for _, id := range req.IDs {
+		memo, err := h.store.GetMemo(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if oldest == nil || memo.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = memo
+		}
+	}
+	target := req.Target
+	if target == "" {
+		target = oldest.ID
+	}
+
+	others := make([]string, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		if id != target {
+			others = append(others, id)
+		}
+	}
+	merged, err := h.store.MergeMemos(target, others)
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"memo": merged, "trashed": others})
+}
+
+// 没有可合并的备忘录返回400，其他错误返回500
+func mergeErrorStatus(err error) int {
+	if errors.Is(err, store.ErrNothingToMerge) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// 回顾队列的默认长度
+const defaultReviewLimit = 20
+
+// 时长中按天计算的单位，一个月按30天、一年按365天计算。
+// 月份写作 mo，m 按 Go 的时长格式表示分钟
+var ageUnits = []struct {
+	suffix string
+	days   int
+}{{"mo", 30}, {"d", 1}, {"w", 7}, {"y", 365}}
+
+// 解析时长，除 Go 的时长格式（如 90m、12h）外支持 30d、2w、6mo、1y
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for _, unit := range ageUnits {
+		if !strings.HasSuffix(s, unit.suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)))
+		if err == nil && n >= 0 {
+			return time.Duration(n*unit.days) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时长: %s", s)
+	}
+	return d, nil
+}
+
+// OnThisDay 列出往年同月同日的备忘录，?date=2024-05-01 默认为今天
+func (h *MemoHandler) OnThisDay(c *gin.Context) {
+	var date time.Time
+	if s := c.Query("date"); s != "" {
+		var err error
+		date, err = time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期: " + s})
+			return
+		}
+	}
+
+	memos, err := h.store.OnThisDay(date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memos)
+}
+
+// RandomMemo 随机返回一条备忘录，支持 ?tag=、?older_than=30d 和 ?newer_than=1y。
+// 时长的单位为 d（天）、w（周）、mo（月，30天）、y（年，365天），
+// 也接受 Go 的时长格式，如 90m 表示90分钟、12h 表示12小时
+func (h *MemoHandler) RandomMemo(c *gin.Context) {
+	filter := store.ResurfaceFilter{Tag: c.Query("tag")}
+	var err error
+	if filter.OlderThan, err = parseAge(c.Query("older_than")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.NewerThan, err = parseAge(c.Query("newer_than")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	memo, err := h.store.RandomMemo(filter)
+	if errors.Is(err, store.ErrNoMemo) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memo)
+}
+
+// ReviewQueue 列出待回顾的备忘录，?limit= 默认20条
+func (h *MemoHandler) ReviewQueue(c *gin.Context) {
+	limit := defaultReviewLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的数量: " + s})
+			return
+		}
+		limit = n
+	}
+
+	memos, err := h.store.ReviewQueue(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memos)
+}
+
+// 回顾的请求格式
+type reviewRequest struct {
+	Result string `json:"result"` // again、good（默认）或 easy
+}
+
+// ReviewMemo 记录一次回顾，返回安排好下次回顾时间的备忘录
+func (h *MemoHandler) ReviewMemo(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.store.GetMemo(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req reviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	memo, err := h.store.ReviewMemo(id, req.Result)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrInvalidReview) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memo)
+}
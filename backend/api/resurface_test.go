@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"30d", 30 * day},
+		{"2w", 14 * day},
+		{"6mo", 180 * day},
+		{"1y", 365 * day},
+		{"90m", 90 * time.Minute}, // m 是分钟，不是月
+		{"12h", 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v, 期望 %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"mo", "-1d", "3x", "1.5mo"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("parseAge(%q) 应返回错误", in)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/feed"
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// ShareHandler 处理分享链接相关的请求
+type ShareHandler struct {
+	store  *store.MemoStore
+	shares *store.ShareStore
+}
+
+// NewShareHandler 创建一个新的分享处理程序
+func NewShareHandler(store *store.MemoStore, shares *store.ShareStore) *ShareHandler {
+	return &ShareHandler{
+		store:  store,
+		shares: shares,
+	}
+}
+
+// RegisterShareRoutes 注册分享管理接口（/api 下）和分享页面（/s 下）
+func RegisterShareRoutes(apiGroup *gin.RouterGroup, r gin.IRouter, store *store.MemoStore, shares *store.ShareStore) {
+	handler := NewShareHandler(store, shares)
+
+	apiGroup.POST("/memos/:id/shares", handler.CreateShare)
+	apiGroup.GET("/memos/:id/shares", handler.ListShares)
+	apiGroup.GET("/shares", handler.ListShares)
+	apiGroup.DELETE("/shares/:token", handler.RevokeShare)
+
+	page := r.Group("/s/:token")
+	{
+		page.GET("", handler.ViewShare)
+		page.POST("", handler.ViewShare)
+	}
+}
+
+// 分享的接口响应格式
+type shareResponse struct {
+	*store.Share
+	HasPassword bool   `json:"hasPassword"`
+	Expired     bool   `json:"expired"`
+	URL         string `json:"url"`
+}
+
+func newShareResponse(c *gin.Context, share *store.Share) shareResponse {
+	return shareResponse{
+		Share:       share,
+		HasPassword: share.HasPassword(),
+		Expired:     share.Expired(time.Now()),
+		URL:         requestBaseURL(c) + "/s/" + share.Token,
+	}
+}
+
+// 创建分享的请求格式
+type createShareRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"` // 过期时间
+	ExpiresIn string     `json:"expiresIn"` // 有效期，如 "72h"，与 expiresAt 二选一
+	Password  string     `json:"password"`  // 访问密码，可选
+}
+
+// CreateShare 为备忘录创建分享链接
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.store.GetMemo(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 请求体可以为空，表示不设有效期和密码
+	var req createShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的有效期: " + req.ExpiresIn})
+			return
+		}
+		t := time.Now().Add(d).Truncate(time.Second)
+		expiresAt = &t
+	}
+
+	share, err := h.shares.CreateShare(id, expiresAt, req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newShareResponse(c, share))
+}
+
+// ListShares 列出分享，带 :id 时仅列出该备忘录的分享
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	shares := h.shares.ListShares(c.Param("id"))
+
+	resp := make([]shareResponse, 0, len(shares))
+	for _, share := range shares {
+		resp = append(resp, newShareResponse(c, share))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeShare 撤销分享
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	if err := h.shares.RevokeShare(c.Param("token")); err != nil {
+		if errors.Is(err, store.ErrShareNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ViewShare 渲染分享页面，设置了密码时先要求输入密码
+func (h *ShareHandler) ViewShare(c *gin.Context) {
+	share, ok := h.lookupShare(c)
+	if !ok {
+		return
+	}
+
+	if share.HasPassword() && !h.unlocked(c, share) {
+		password := c.PostForm("password")
+		if c.Request.Method != http.MethodPost || !share.CheckPassword(password) {
+			renderSharePage(c, http.StatusUnauthorized, sharePageData{Locked: true, Failed: c.Request.Method == http.MethodPost})
+			return
+		}
+		// 密码正确后写入 Cookie，刷新页面时无需重复输入密码
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(shareCookieName(share), shareCookieValue(share), 0, "/s/"+share.Token, "", false, true)
+	}
+
+	memo, err := h.store.GetMemo(share.MemoID)
+	if err != nil {
+		renderSharePage(c, http.StatusNotFound, sharePageData{Message: "分享的备忘录已不存在"})
+		return
+	}
+
+	// 附件仍使用 /static/ 下的地址，与其他附件一样公开访问，分享只控制正文的访问
+	html, err := render.New(render.Options{}).Render(memo.Content)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.shares.RecordView(share.Token); err != nil {
+		c.Error(err)
+	}
+
+	renderSharePage(c, http.StatusOK, sharePageData{
+		Memo: memo,
+		HTML: template.HTML(html),
+	})
+}
+
+// 查找分享，不存在或已过期时直接输出错误页面
+func (h *ShareHandler) lookupShare(c *gin.Context) (*store.Share, bool) {
+	share, err := h.shares.GetShare(c.Param("token"))
+	switch {
+	case errors.Is(err, store.ErrShareExpired):
+		renderSharePage(c, http.StatusGone, sharePageData{Message: "分享已过期"})
+		return nil, false
+	case err != nil:
+		renderSharePage(c, http.StatusNotFound, sharePageData{Message: "分享不存在或已被撤销"})
+		return nil, false
+	}
+	return share, true
+}
+
+// 判断请求是否已通过密码验证
+func (h *ShareHandler) unlocked(c *gin.Context, share *store.Share) bool {
+	value, err := c.Cookie(shareCookieName(share))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(value), []byte(shareCookieValue(share))) == 1
+}
+
+func shareCookieName(share *store.Share) string {
+	return "ramblog_share_" + share.Token[:8]
+}
+
+// Cookie 值由令牌和密码哈希派生，修改密码或撤销分享后自动失效
+func shareCookieValue(share *store.Share) string {
+	sum := sha256.Sum256([]byte(share.Token + ":" + share.PasswordHash))
+	return hex.EncodeToString(sum[:])
+}
+
+type sharePageData struct {
+	Memo    *store.Memo
+	HTML    template.HTML
+	Locked  bool
+	Failed  bool
+	Message string
+}
+
+func renderSharePage(c *gin.Context, status int, data sharePageData) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("X-Robots-Tag", "noindex")
+	if err := sharePageTemplate.Execute(c.Writer, data); err != nil {
+		c.Error(err)
+	}
+}
+
+var sharePageTemplate = template.Must(template.New("share").Funcs(template.FuncMap{
+	"title":        feed.EntryTitle,
+	"highlightCSS": func() template.CSS { return template.CSS(render.StyleSheet()) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <meta name="robots" content="noindex">
+  <title>{{if .Memo}}{{title .Memo}}{{else}}分享{{end}}</title>
+  <style>
+    body { max-width: 720px; margin: 0 auto; padding: 2rem 1rem; font-family: -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; line-height: 1.7; color: #222; }
+    img { max-width: 100%; }
+    .meta { font-size: .85rem; color: #888; }
+    pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+    .heading-anchor { margin-left: .3rem; color: #ccc; }
+    {{highlightCSS}}
+  </style>
+</head>
+<body>
+{{if .Locked}}
+  <form method="post">
+    <p>此分享需要密码访问</p>
+    {{if .Failed}}<p style="color:#dc2626">密码错误</p>{{end}}
+    <input type="password" name="password" autofocus>
+    <button type="submit">查看</button>
+  </form>
+{{else if .Memo}}
+  <article>
+    <div class="meta">{{.Memo.CreatedAt.Format "2006-01-02 15:04"}}{{range .Memo.Tags}} #{{.}}{{end}}</div>
+    {{if .Memo.Title}}<h2>{{.Memo.Title}}</h2>{{end}}
+    {{.HTML}}
+  </article>
+{{else}}
+  <p>{{.Message}}</p>
+{{end}}
+</body>
+</html>
+`))
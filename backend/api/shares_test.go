@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+func newShareTestServer(t *testing.T) (*gin.Engine, *store.MemoStore, *store.ShareStore) {
+	dir, err := os.MkdirTemp("", "memo-share-api-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	shares, err := store.NewShareStore(dir)
+	if err != nil {
+		t.Fatalf("创建ShareStore失败: %v", err)
+	}
+	memoStore.SetShareStore(shares)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterShareRoutes(r.Group("/api"), r, memoStore, shares)
+	return r, memoStore, shares
+}
+
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createTestShare(t *testing.T, r http.Handler, memoID, body string) shareResponse {
+	req := httptest.NewRequest(http.MethodPost, "/api/memos/"+memoID+"/shares", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := serve(r, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建分享应返回 201, 实际 %d: %s", w.Code, w.Body)
+	}
+	var resp shareResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析分享失败: %v", err)
+	}
+	return resp
+}
+
+func TestSharePage(t *testing.T) {
+	r, memoStore, shares := newShareTestServer(t)
+	memo := &store.Memo{Content: "私密内容 ![](/static/a.png)"}
+	if err := memoStore.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+
+	if w := serve(r, httptest.NewRequest(http.MethodPost, "/api/memos/不存在/shares", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的备忘录应返回 404, 实际 %d", w.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/memos/"+memo.ID+"/shares", strings.NewReader(`{"expiresIn":"-1h"}`))
+	if w := serve(r, req); w.Code != http.StatusBadRequest {
+		t.Errorf("无效的有效期应返回 400, 实际 %d", w.Code)
+	}
+
+	share := createTestShare(t, r, memo.ID, `{"expiresIn":"72h","password":"口令"}`)
+	if !share.HasPassword || share.Expired || share.ExpiresAt == nil || !strings.HasSuffix(share.URL, "/s/"+share.Token) {
+		t.Errorf("分享响应不正确: %+v", share)
+	}
+	if strings.Contains(serve(r, httptest.NewRequest(http.MethodGet, "/api/shares", nil)).Body.String(), "$2a$") {
+		t.Errorf("分享列表不应包含密码哈希")
+	}
+	page := "/s/" + share.Token
+
+	// 未输入密码时只显示密码表单
+	w := serve(r, httptest.NewRequest(http.MethodGet, page, nil))
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "私密内容") {
+		t.Errorf("未解锁的分享应返回 401 且不含正文, 实际 %d", w.Code)
+	}
+
+	postPassword := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, page, strings.NewReader(url.Values{"password": {password}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(r, req)
+	}
+	if w := postPassword("错误"); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "密码错误") {
+		t.Errorf("错误的密码应返回 401, 实际 %d", w.Code)
+	}
+	w = postPassword("口令")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "私密内容") {
+		t.Fatalf("正确的密码应显示正文, 实际 %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `src="/static/a.png"`) {
+		t.Errorf("附件应使用 /static/ 下的地址: %s", w.Body)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Path != page || !cookies[0].HttpOnly {
+		t.Fatalf("解锁后应设置限定在分享路径下的 Cookie: %+v", cookies)
+	}
+
+	// 带 Cookie 访问页面无需再输入密码
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(cookies[0])
+		return serve(r, req)
+	}
+	if w := get(page); w.Code != http.StatusOK {
+		t.Errorf("带 Cookie 的访问应返回 200, 实际 %d", w.Code)
+	}
+
+	// 其他分享的 Cookie 不能解锁
+	other := createTestShare(t, r, memo.ID, `{"password":"口令"}`)
+	req = httptest.NewRequest(http.MethodGet, "/s/"+other.Token, nil)
+	req.AddCookie(&http.Cookie{Name: "ramblog_share_" + other.Token[:8], Value: cookies[0].Value})
+	if w := serve(r, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("其他分享的 Cookie 不应解锁, 实际 %d", w.Code)
+	}
+
+	// 解锁和之后的两次访问各计一次
+	if err := shares.Flush(); err != nil {
+		t.Fatalf("写入访问次数失败: %v", err)
+	}
+	if got, _ := shares.GetShare(share.Token); got.Views != 2 {
+		t.Errorf("访问次数应为 2, 实际 %d", got.Views)
+	}
+
+	// 撤销或删除备忘录后分享不可访问
+	if w := serve(r, httptest.NewRequest(http.MethodDelete, "/api/shares/"+share.Token, nil)); w.Code != http.StatusNoContent {
+		t.Errorf("撤销分享应返回 204, 实际 %d", w.Code)
+	}
+	if w := get(page); w.Code != http.StatusNotFound {
+		t.Errorf("撤销后的分享应返回 404, 实际 %d", w.Code)
+	}
+	if err := memoStore.DeleteMemo(memo.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/s/"+other.Token, nil)); w.Code != http.StatusNotFound {
+		t.Errorf("删除备忘录后分享应返回 404, 实际 %d", w.Code)
+	}
+}
+
+func TestExpiredSharePage(t *testing.T) {
+	r, memoStore, _ := newShareTestServer(t)
+	memo := &store.Memo{Content: "过期的分享"}
+	if err := memoStore.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	share := createTestShare(t, r, memo.ID, `{"expiresAt":"2000-01-01T00:00:00Z"}`)
+	if !share.Expired {
+		t.Errorf("分享应已过期: %+v", share)
+	}
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/s/"+share.Token, nil))
+	if w.Code != http.StatusGone || strings.Contains(w.Body.String(), "过期的分享") {
+		t.Errorf("过期的分享应返回 410, 实际 %d", w.Code)
+	}
+}
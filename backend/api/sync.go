@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// 拉取时默认每次返回的变更数量
+const defaultSyncLimit = 500
+
+// 每次推送最多包含的修改数量
+const maxSyncBatch = 1000
+
+// SyncHandler 处理增量同步
+type SyncHandler struct {
+	store *store.MemoStore
+}
+
+// NewSyncHandler 创建一个新的同步处理程序
+func NewSyncHandler(memoStore *store.MemoStore) *SyncHandler {
+	return &SyncHandler{store: memoStore}
+}
+
+// RegisterSyncRoutes 注册同步接口
+func RegisterSyncRoutes(apiGroup *gin.RouterGroup, memoStore *store.MemoStore) {
+	handler := NewSyncHandler(memoStore)
+
+	group := apiGroup.Group("/sync")
+	{
+		group.GET("/changes", handler.Changes)
+		group.POST("/push", handler.Push)
+	}
+}
+
+// Changes 返回 cursor 之后的变更，cursor 为空或 0 时返回全部备忘录
+func (h *SyncHandler) Changes(c *gin.Context) {
+	var cursor uint64
+	if value := c.Query("cursor"); value != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 cursor"})
+			return
+		}
+	}
+	limit := defaultSyncLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 limit"})
+			return
+		}
+		limit = n
+	}
+
+	changes, err := h.store.ChangesSince(cursor, limit)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// pushRequest 推送的请求体
+type pushRequest struct {
+	Changes []*store.SyncChange `json:"changes"`
+}
+
+// Push 应用客户端推送的一批修改，冲突的修改不会覆盖服务器上的内容，在结果中返回
+func (h *SyncHandler) Push(c *gin.Context) {
+	var req pushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Changes) > maxSyncBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "一次最多推送 " + strconv.Itoa(maxSyncBatch) + " 项修改"})
+		return
+	}
+	c.JSON(http.StatusOK, h.store.PushChanges(req.Changes))
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// ListTasks 列出所有备忘录中的任务，支持 ?status=open|done 和 ?due_before=2024-05-01
+func (h *MemoHandler) ListTasks(c *gin.Context) {
+	filter := store.TaskFilter{
+		Status:    c.Query("status"),
+		DueBefore: c.Query("due_before"),
+	}
+	switch filter.Status {
+	case "", "all":
+		filter.Status = ""
+	case store.TaskStatusOpen, store.TaskStatusDone:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务状态: " + filter.Status})
+		return
+	}
+	if filter.DueBefore != "" {
+		if _, err := time.Parse("2006-01-02", filter.DueBefore); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期: " + filter.DueBefore})
+			return
+		}
+	}
+
+	tasks, err := h.store.Tasks(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// 切换任务状态的请求格式
+type toggleTaskRequest struct {
+	MemoID string `json:"memoId" binding:"required"`
+	Line   int    `json:"line" binding:"required,min=1"`
+	Done   *bool  `json:"done"` // 为空时取反
+	Text   string `json:"text"` // 可选，与当前任务文字不一致时返回409
+}
+
+// ToggleTask 勾选或取消勾选任务，直接修改源文件中的复选框
+func (h *MemoHandler) ToggleTask(c *gin.Context) {
+	var req toggleTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.store.GetMemo(req.MemoID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.store.ToggleTask(req.MemoID, req.Line, req.Done, req.Text)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, store.ErrTaskNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, store.ErrTaskChanged):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+)
+
+// TrashMemo 将备忘录移入回收站，之后可以恢复
+func (h *MemoHandler) TrashMemo(c *gin.Context) {
+	if err := h.store.TrashMemo(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListTrash 列出回收站中的备忘录
+func (h *MemoHandler) ListTrash(c *gin.Context) {
+	memos, err := h.store.ListTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memos)
+}
+
+// RestoreMemo 从回收站恢复备忘录
+func (h *MemoHandler) RestoreMemo(c *gin.Context) {
+	memo, err := h.store.RestoreMemo(c.Param("id"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, store.ErrMemoExists) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memo)
+}
+
+// PurgeMemo 从回收站中彻底删除备忘录
+func (h *MemoHandler) PurgeMemo(c *gin.Context) {
+	if err := h.store.PurgeMemo(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
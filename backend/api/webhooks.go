@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/webhook"
+)
+
+// 发送记录默认返回的条数
+const defaultDeliveryLimit = 100
+
+// WebhookHandler 处理 webhook 相关的请求
+type WebhookHandler struct {
+	webhooks *webhook.Dispatcher
+}
+
+// NewWebhookHandler 创建一个新的 webhook 处理程序
+func NewWebhookHandler(webhooks *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// RegisterWebhookRoutes 注册 webhook 接口
+func RegisterWebhookRoutes(apiGroup *gin.RouterGroup, webhooks *webhook.Dispatcher) {
+	handler := NewWebhookHandler(webhooks)
+
+	group := apiGroup.Group("/webhooks")
+	{
+		group.GET("", handler.ListWebhooks)
+		group.POST("", handler.CreateWebhook)
+		group.GET("/deliveries", handler.Deliveries)
+		group.GET("/:id", handler.GetWebhook)
+		group.PUT("/:id", handler.UpdateWebhook)
+		group.DELETE("/:id", handler.DeleteWebhook)
+		group.GET("/:id/deliveries", handler.Deliveries)
+	}
+}
+
+// webhook 的接口响应格式
+type webhookResponse struct {
+	*webhook.Hook
+	Pending int `json:"pending"` // 队列中等待发送的请求数
+}
+
+func (h *WebhookHandler) respond(c *gin.Context, status int, hook *webhook.Hook) {
+	c.JSON(status, webhookResponse{Hook: hook, Pending: h.webhooks.Pending(hook.ID)})
+}
+
+// 密钥只在创建时返回，其余响应中去掉
+func redactHook(hook *webhook.Hook) *webhook.Hook {
+	hook.Secret = ""
+	return hook
+}
+
+// ListWebhooks 列出全部 webhook，不包含密钥
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	hooks := h.webhooks.List()
+	response := make([]webhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		response = append(response, webhookResponse{Hook: redactHook(hook), Pending: h.webhooks.Pending(hook.ID)})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateWebhook 添加 webhook，未指定密钥时自动生成，在响应中返回
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req webhook.Hook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, err := h.webhooks.Create(&req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.respond(c, http.StatusCreated, hook)
+}
+
+// GetWebhook 获取 webhook，不包含密钥
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	hook, err := h.webhooks.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.respond(c, http.StatusOK, redactHook(hook))
+}
+
+// UpdateWebhook 修改 webhook，secret 为空时保留原来的密钥。响应中不包含密钥
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req webhook.Hook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook, err := h.webhooks.Update(c.Param("id"), &req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.respond(c, http.StatusOK, redactHook(hook))
+}
+
+// DeleteWebhook 删除 webhook，尚未发送的请求一并丢弃
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhooks.Delete(c.Param("id")); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries 返回最近的发送记录，从新到旧。带 :id 时只返回该 webhook 的记录
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id := c.Param("id")
+	if id != "" {
+		if _, err := h.webhooks.Get(id); err != nil {
+			c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	limit := defaultDeliveryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 limit"})
+			return
+		}
+		limit = n
+	}
+	c.JSON(http.StatusOK, h.webhooks.Deliveries(id, limit))
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, webhook.ErrHookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, webhook.ErrInvalidHook):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"ramblog-app/backend/store"
+	"ramblog-app/backend/webhook"
+)
+
+func TestWebhookSecretRedacted(t *testing.T) {
+	dir, err := os.MkdirTemp("", "memo-webhook-api-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	webhooks, err := webhook.NewDispatcher(memoStore)
+	if err != nil {
+		t.Fatalf("创建Dispatcher失败: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterWebhookRoutes(r.Group("/api"), webhooks)
+
+	request := func(method, path, body string) (int, map[string]interface{}, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serve(r, req)
+		var hook map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &hook)
+		return w.Code, hook, w.Body.String()
+	}
+
+	code, created, _ := request(http.MethodPost, "/api/webhooks", `{"url": "https://example.com/hook"}`)
+	secret, _ := created["secret"].(string)
+	if code != http.StatusCreated || secret == "" {
+		t.Fatalf("创建时应返回密钥, 实际 %d %v", code, created)
+	}
+	id := created["id"].(string)
+
+	for _, tt := range []struct{ method, path, body string }{
+		{http.MethodGet, "/api/webhooks", ""},
+		{http.MethodGet, "/api/webhooks/" + id, ""},
+		{http.MethodPut, "/api/webhooks/" + id, `{"url": "https://example.com/other"}`},
+	} {
+		code, _, body := request(tt.method, tt.path, tt.body)
+		if code != http.StatusOK || strings.Contains(body, "secret") || strings.Contains(body, secret) {
+			t.Errorf("%s %s 不应返回密钥, 实际 %d: %s", tt.method, tt.path, code, body)
+		}
+	}
+
+	// 修改时没有指定密钥，原来的密钥仍然有效
+	hook, err := webhooks.Get(id)
+	if err != nil || hook.Secret != secret {
+		t.Errorf("修改后应保留原来的密钥, 实际 %v %v", hook, err)
+	}
+}
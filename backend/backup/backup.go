@@ -0,0 +1,523 @@
+// Package backup 定时把数据目录打包为快照，按小时、天、周轮换保留，并校验快照的完整性
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ramblog-app/backend/export"
+	"ramblog-app/backend/store"
+)
+
+// ManifestFormat 快照清单中标识格式的名称
+const ManifestFormat = "ramblog-snapshot"
+
+// 快照文件名的前缀、时间格式和扩展名
+const (
+	snapshotPrefix = "ramblog-"
+	snapshotLayout = "20060102-150405"
+	snapshotExt    = ".zip"
+)
+
+// 快照中数据目录的文件位于 data/ 下，清单位于根目录
+const (
+	dataPrefix   = "data/"
+	manifestName = "manifest.json"
+)
+
+// 保留原因
+const (
+	KeepLatest = "latest"
+	KeepHourly = "hourly"
+	KeepDaily  = "daily"
+	KeepWeekly = "weekly"
+)
+
+// ErrSnapshotNotFound 快照不存在
+var ErrSnapshotNotFound = errors.New("快照不存在")
+
+// ErrCorruptSnapshot 快照损坏
+var ErrCorruptSnapshot = errors.New("快照校验失败")
+
+// Manifest 快照中的 manifest.json，记录数据目录中每个文件的大小和 SHA-256
+type Manifest struct {
+	Format    string                `json:"format"`
+	Version   int                   `json:"version"`
+	CreatedAt time.Time             `json:"createdAt"`
+	Files     []export.ManifestFile `json:"files"`
+}
+
+// Policy 快照的轮换策略：每小时、每天、每周各保留最近若干个快照，0 表示不按该周期保留。
+// 最新的快照总是保留
+type Policy struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// DefaultPolicy 默认保留最近 24 小时、7 天和 4 周的快照
+var DefaultPolicy = Policy{Hourly: 24, Daily: 7, Weekly: 4}
+
+// Snapshot 一个快照文件
+type Snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+	Keep      []string  `json:"keep,omitempty"` // 按轮换策略保留的原因，为空时下次清理会被删除
+}
+
+// Manager 管理备份目录中的快照
+type Manager struct {
+	store  *store.MemoStore
+	dir    string
+	policy Policy
+	mutex  sync.Mutex
+	now    func() time.Time
+
+	afterFreeze func() // 测试用：解除写入禁止后、打包附件前调用
+}
+
+// DefaultDir 默认的备份目录：与数据目录同级的 <数据目录>-backups
+func DefaultDir(dataDir string) string {
+	return filepath.Clean(dataDir) + "-backups"
+}
+
+// NewManager 创建快照管理器，dir 不存在时自动创建。
+// memoStore 为 nil 时只能列出和校验快照，不能创建
+func NewManager(memoStore *store.MemoStore, dir string, policy Policy) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("无法创建备份目录: %w", err)
+	}
+	return &Manager{store: memoStore, dir: dir, policy: policy, now: time.Now}, nil
+}
+
+// Dir 返回备份目录
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Create 创建一个快照。打包期间禁止写入数据目录，保证快照的一致性；
+// 附件只会新增，不会原地修改，在解除写入禁止后再打包，避免长时间阻塞写入。
+// 写入完成后重新校验，校验失败的快照会被删除
+func (m *Manager) Create() (*Snapshot, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	createdAt := m.now().Truncate(time.Second)
+	name := snapshotPrefix + createdAt.Format(snapshotLayout) + snapshotExt
+	for i := 2; fileExists(filepath.Join(m.dir, name)); i++ {
+		name = fmt.Sprintf("%s%s-%d%s", snapshotPrefix, createdAt.Format(snapshotLayout), i, snapshotExt)
+	}
+	target := filepath.Join(m.dir, name)
+	tmp := target + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("创建快照文件失败: %w", err)
+	}
+	err = m.writeSnapshot(f, createdAt, true)
+	if errors.Is(err, errAttachmentChanged) {
+		// 打包期间附件被替换（如恢复或重新加密），在禁止写入的情况下重新打包全部文件
+		if err = f.Truncate(0); err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err == nil {
+			err = m.writeSnapshot(f, createdAt, false)
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = verifyFile(tmp)
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("创建快照失败: %w", err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Name: name, CreatedAt: createdAt, Size: info.Size()}, nil
+}
+
+// errAttachmentChanged 解除写入禁止后打包的附件在打包期间发生了变化
+var errAttachmentChanged = errors.New("附件在打包期间发生了变化")
+
+// 把数据目录写入快照。deferAttachments 为 true 时附件在解除写入禁止后再打包
+func (m *Manager) writeSnapshot(w io.Writer, createdAt time.Time, deferAttachments bool) error {
+	sw := &snapshotWriter{
+		zw:       zip.NewWriter(w),
+		manifest: Manifest{Format: ManifestFormat, Version: 1, CreatedAt: createdAt, Files: []export.ManifestFile{}},
+	}
+	err := m.store.Freeze(func(dataDir string) error {
+		return sw.addDataDir(dataDir, m.dir, deferAttachments)
+	})
+	if err != nil {
+		return err
+	}
+	if m.afterFreeze != nil {
+		m.afterFreeze()
+	}
+	if err := sw.addDeferred(); err != nil {
+		return err
+	}
+	return sw.close(createdAt)
+}
+
+// 快照的 zip 写入器和已写入文件的清单
+type snapshotWriter struct {
+	zw       *zip.Writer
+	manifest Manifest
+	deferred []deferredFile
+}
+
+// 延后打包的附件，info 为禁止写入期间读取的文件信息
+type deferredFile struct {
+	src, name string
+	info      os.FileInfo
+}
+
+// 遍历数据目录，跳过备份目录本身、临时文件和以 . 开头的目录（如 .git）
+func (sw *snapshotWriter) addDataDir(dataDir, backupDir string, deferAttachments bool) error {
+	backupAbs, _ := filepath.Abs(backupDir)
+	return filepath.WalkDir(dataDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, _ := filepath.Abs(p); abs == backupAbs {
+				return filepath.SkipDir
+			}
+			if p != dataDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if deferAttachments && strings.HasPrefix(rel, "static/") {
+			info, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			sw.deferred = append(sw.deferred, deferredFile{src: p, name: dataPrefix + rel, info: info})
+			return nil
+		}
+		return sw.add(p, dataPrefix+rel)
+	})
+}
+
+// 打包延后的附件，打包前后文件不一致时返回 errAttachmentChanged
+func (sw *snapshotWriter) addDeferred() error {
+	for _, file := range sw.deferred {
+		if err := sw.add(file.src, file.name); err != nil {
+			if os.IsNotExist(err) {
+				return errAttachmentChanged
+			}
+			return err
+		}
+		info, err := os.Stat(file.src)
+		if err != nil || !os.SameFile(info, file.info) || info.Size() != file.info.Size() || !info.ModTime().Equal(file.info.ModTime()) {
+			return errAttachmentChanged
+		}
+	}
+	return nil
+}
+
+func (sw *snapshotWriter) add(src, name string) error {
+	file, err := addFile(sw.zw, src, name)
+	if err != nil {
+		return err
+	}
+	sw.manifest.Files = append(sw.manifest.Files, file)
+	return nil
+}
+
+// 最后写入清单
+func (sw *snapshotWriter) close(createdAt time.Time) error {
+	data, err := json.MarshalIndent(sw.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	mw, err := sw.zw.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: createdAt})
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(data); err != nil {
+		return err
+	}
+	return sw.zw.Close()
+}
+
+func addFile(zw *zip.Writer, src, name string) (export.ManifestFile, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return export.ManifestFile{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return export.ManifestFile{}, err
+	}
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return export.ManifestFile{}, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(w, hash), f)
+	if err != nil {
+		return export.ManifestFile{}, fmt.Errorf("读取 %s 失败: %w", src, err)
+	}
+	return export.ManifestFile{Path: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// List 列出备份目录中的快照，最新的在前，并标注按轮换策略保留的原因
+func (m *Manager) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份目录失败: %w", err)
+	}
+	snapshots := []*Snapshot{}
+	for _, entry := range entries {
+		createdAt, ok := parseSnapshotName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, &Snapshot{Name: entry.Name(), CreatedAt: createdAt, Size: info.Size()})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+		}
+		return snapshots[i].Name > snapshots[j].Name
+	})
+	applyPolicy(snapshots, m.policy)
+	return snapshots, nil
+}
+
+// 从文件名解析快照时间，如 ramblog-20240102-150405.zip 或 ramblog-20240102-150405-2.zip
+func parseSnapshotName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotExt) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotExt)
+	if len(stamp) < len(snapshotLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(snapshotLayout, stamp[:len(snapshotLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// 按轮换策略标注需要保留的快照，snapshots 按时间从新到旧排列。
+// 每个周期内保留最新的一个快照，按周期从新到旧保留指定的数量
+func applyPolicy(snapshots []*Snapshot, policy Policy) {
+	if len(snapshots) == 0 {
+		return
+	}
+	snapshots[0].Keep = append(snapshots[0].Keep, KeepLatest)
+
+	buckets := []struct {
+		reason string
+		count  int
+		key    func(time.Time) string
+	}{
+		{KeepHourly, policy.Hourly, func(t time.Time) string { return t.Format("2006010215") }},
+		{KeepDaily, policy.Daily, func(t time.Time) string { return t.Format("20060102") }},
+		{KeepWeekly, policy.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}},
+	}
+	for _, bucket := range buckets {
+		last, kept := "", 0
+		for _, snapshot := range snapshots {
+			if kept >= bucket.count {
+				break
+			}
+			if key := bucket.key(snapshot.CreatedAt); key != last {
+				snapshot.Keep = append(snapshot.Keep, bucket.reason)
+				last = key
+				kept++
+			}
+		}
+	}
+}
+
+// Prune 删除轮换策略不再保留的快照，返回被删除的快照
+func (m *Manager) Prune() ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	snapshots, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, snapshot := range snapshots {
+		if len(snapshot.Keep) > 0 {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.dir, snapshot.Name)); err != nil {
+			return removed, fmt.Errorf("删除快照 %s 失败: %w", snapshot.Name, err)
+		}
+		removed = append(removed, snapshot.Name)
+	}
+	return removed, nil
+}
+
+// Path 返回快照文件的路径
+func (m *Manager) Path(name string) (string, error) {
+	if _, ok := parseSnapshotName(name); !ok || name != filepath.Base(name) {
+		return "", fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	p := filepath.Join(m.dir, name)
+	if !fileExists(p) {
+		return "", fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	return p, nil
+}
+
+// Verify 校验快照中每个文件的大小和 SHA-256
+func (m *Manager) Verify(name string) error {
+	p, err := m.Path(name)
+	if err != nil {
+		return err
+	}
+	return verifyFile(p)
+}
+
+// 校验快照文件
+func verifyFile(p string) error {
+	_, err := readManifest(p, true)
+	return err
+}
+
+// 读取快照的清单，verify 为 true 时同时校验所有文件
+func readManifest(p string, verify bool) (*Manifest, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	mf, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: 缺少 %s", ErrCorruptSnapshot, manifestName)
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+	}
+	var manifest Manifest
+	err = json.NewDecoder(rc).Decode(&manifest)
+	rc.Close()
+	if err != nil || manifest.Format != ManifestFormat {
+		return nil, fmt.Errorf("%w: 清单无效", ErrCorruptSnapshot)
+	}
+	if !verify {
+		return &manifest, nil
+	}
+
+	if len(manifest.Files)+1 != len(files) {
+		return nil, fmt.Errorf("%w: 文件数与清单不一致", ErrCorruptSnapshot)
+	}
+	for _, entry := range manifest.Files {
+		f, ok := files[entry.Path]
+		if !ok || !strings.HasPrefix(entry.Path, dataPrefix) || !validPath(entry.Path) {
+			return nil, fmt.Errorf("%w: 缺少或无效的文件 %s", ErrCorruptSnapshot, entry.Path)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+		}
+		hash := sha256.New()
+		size, err := io.Copy(hash, rc)
+		rc.Close()
+		if err != nil || size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrCorruptSnapshot, entry.Path)
+		}
+	}
+	return &manifest, nil
+}
+
+// 拒绝跳出数据目录的路径
+func validPath(name string) bool {
+	clean := path.Clean(name)
+	return clean == name && !strings.HasPrefix(clean, "/") && !strings.Contains(clean, "..")
+}
+
+// Run 每隔 interval 创建一个快照并按轮换策略清理旧快照，直到 ctx 结束。
+// 启动时如果最新的快照早于 interval 之前，立即创建一个
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	snapshots, err := m.List()
+	if err != nil || len(snapshots) == 0 || m.now().Sub(snapshots[0].CreatedAt) >= interval {
+		m.runOnce()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runOnce()
+		}
+	}
+}
+
+func (m *Manager) runOnce() {
+	snapshot, err := m.Create()
+	if err != nil {
+		log.Printf("自动备份失败: %v", err)
+		return
+	}
+	removed, err := m.Prune()
+	if err != nil {
+		log.Printf("清理旧快照失败: %v", err)
+	}
+	log.Printf("已创建快照 %s（%d 字节），清理 %d 个旧快照", snapshot.Name, snapshot.Size, len(removed))
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,276 @@
+package backup
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+func newTestManager(t *testing.T) (*Manager, *store.MemoStore) {
+	dir, err := os.MkdirTemp("", "memo-backup-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	memoStore, err := store.NewMemoStore(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	manager, err := NewManager(memoStore, DefaultDir(memoStore.DataDir()), DefaultPolicy)
+	if err != nil {
+		t.Fatalf("创建Manager失败: %v", err)
+	}
+	return manager, memoStore
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	manager, memoStore := newTestManager(t)
+	memo := &store.Memo{Content: "备份前 #备份"}
+	if err := memoStore.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(memoStore.GetStaticDir(), "a.png"), []byte("PNG"), 0644); err != nil {
+		t.Fatalf("写入附件失败: %v", err)
+	}
+
+	snapshot, err := manager.Create()
+	if err != nil {
+		t.Fatalf("创建快照失败: %v", err)
+	}
+	if err := manager.Verify(snapshot.Name); err != nil {
+		t.Errorf("新快照应通过校验: %v", err)
+	}
+	snapshots, _ := manager.List()
+	if len(snapshots) != 1 || snapshots[0].Name != snapshot.Name || len(snapshots[0].Keep) == 0 {
+		t.Errorf("快照列表不正确: %+v", snapshots)
+	}
+
+	// 快照之后的修改在恢复后消失
+	if err := memoStore.DeleteMemo(memo.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	path, _ := manager.Path(snapshot.Name)
+	previous, err := Restore(path, memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if previous == "" {
+		t.Errorf("应保留恢复前的数据目录")
+	}
+	restored, err := store.NewMemoStore(memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("打开恢复后的数据目录失败: %v", err)
+	}
+	if got, err := restored.GetMemo(memo.ID); err != nil || got.Content != memo.Content {
+		t.Errorf("恢复后的备忘录不正确: %v %+v", err, got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored.GetStaticDir(), "a.png")); string(data) != "PNG" {
+		t.Errorf("恢复后的附件不正确: %q", data)
+	}
+}
+
+func TestVerifyDetectsCorruption(t *testing.T) {
+	manager, memoStore := newTestManager(t)
+	if err := memoStore.CreateMemo(&store.Memo{Content: "内容"}); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	snapshot, err := manager.Create()
+	if err != nil {
+		t.Fatalf("创建快照失败: %v", err)
+	}
+
+	// 替换快照中的备忘录内容，保留原清单
+	path, _ := manager.Path(snapshot.Name)
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("读取快照失败: %v", err)
+	}
+	tampered := path + ".new"
+	out, _ := os.Create(tampered)
+	zw := zip.NewWriter(out)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if f.Name != manifestName {
+			data = append(data, '!')
+		}
+		w, _ := zw.Create(f.Name)
+		w.Write(data)
+	}
+	zw.Close()
+	out.Close()
+	zr.Close()
+	os.Rename(tampered, path)
+
+	if err := manager.Verify(snapshot.Name); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("损坏的快照应校验失败, 实际 %v", err)
+	}
+	if _, err := Restore(path, memoStore.DataDir()); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("不应恢复损坏的快照, 实际 %v", err)
+	}
+	if _, err := manager.Path("../data/x.zip"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("应拒绝备份目录之外的路径, 实际 %v", err)
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	base := time.Date(2024, 3, 20, 12, 30, 0, 0, time.Local) // 星期三
+	var snapshots []*Snapshot
+	// 每 30 分钟一个快照，共 10 天
+	for i := 0; i < 10*48; i++ {
+		snapshots = append(snapshots, &Snapshot{CreatedAt: base.Add(-time.Duration(i) * 30 * time.Minute)})
+	}
+	applyPolicy(snapshots, Policy{Hourly: 3, Daily: 2, Weekly: 2})
+
+	var kept []time.Time
+	for _, s := range snapshots {
+		if len(s.Keep) > 0 {
+			kept = append(kept, s.CreatedAt)
+		}
+	}
+	want := []time.Time{
+		base,                     // 最新、每小时、每天、每周
+		base.Add(-time.Hour),     // 11:30
+		base.Add(-2 * time.Hour), // 10:30
+		time.Date(2024, 3, 19, 23, 30, 0, 0, time.Local), // 前一天的最后一个
+		time.Date(2024, 3, 17, 23, 30, 0, 0, time.Local), // 上一周的最后一个
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("保留的快照不正确:\n%v\n期望\n%v", kept, want)
+	}
+	if !reflect.DeepEqual(snapshots[0].Keep, []string{KeepLatest, KeepHourly, KeepDaily, KeepWeekly}) {
+		t.Errorf("最新快照的保留原因不正确: %v", snapshots[0].Keep)
+	}
+}
+
+// 读取快照中的文件内容
+func readSnapshot(t *testing.T, manager *Manager, name string) map[string]string {
+	path, _ := manager.Path(name)
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("读取快照失败: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+// 在 timeout 内等待 fn 返回，超时返回 false
+func finishesWithin(timeout time.Duration, fn func() error) (bool, error) {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return true, err
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+func TestCreateAllowsWritesWhilePackagingAttachments(t *testing.T) {
+	manager, memoStore := newTestManager(t)
+	collections, err := store.NewCollectionStore(memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("创建合集存储失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(memoStore.GetStaticDir(), "a.png"), []byte("PNG"), 0644); err != nil {
+		t.Fatalf("写入附件失败: %v", err)
+	}
+
+	written := &store.Memo{Content: "打包附件时写入"}
+	manager.afterFreeze = func() {
+		ok, err := finishesWithin(5*time.Second, func() error {
+			if err := memoStore.CreateMemo(written); err != nil {
+				return err
+			}
+			_, err := collections.Create(&store.Collection{Name: "打包期间", Query: "#备份"})
+			return err
+		})
+		if !ok {
+			t.Errorf("打包附件时写入被阻塞")
+		} else if err != nil {
+			t.Errorf("打包附件时写入失败: %v", err)
+		}
+	}
+	snapshot, err := manager.Create()
+	if err != nil {
+		t.Fatalf("创建快照失败: %v", err)
+	}
+
+	files := readSnapshot(t, manager, snapshot.Name)
+	if files["data/static/a.png"] != "PNG" {
+		t.Errorf("快照中的附件不正确: %q", files["data/static/a.png"])
+	}
+	for name := range files {
+		if strings.Contains(name, written.ID) || name == "data/collections.yaml" {
+			t.Errorf("快照不应包含禁止写入之后的修改: %s", name)
+		}
+	}
+}
+
+func TestCreateRepackagesReplacedAttachment(t *testing.T) {
+	manager, memoStore := newTestManager(t)
+	attachment := filepath.Join(memoStore.GetStaticDir(), "a.png")
+	if err := os.WriteFile(attachment, []byte("PNG"), 0644); err != nil {
+		t.Fatalf("写入附件失败: %v", err)
+	}
+
+	calls := 0
+	manager.afterFreeze = func() {
+		calls++
+		if calls == 1 {
+			os.WriteFile(attachment+".new", []byte("PNG v2"), 0644)
+			os.Rename(attachment+".new", attachment)
+		}
+	}
+	snapshot, err := manager.Create()
+	if err != nil {
+		t.Fatalf("创建快照失败: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("附件被替换后应重新打包, 实际打包 %d 次", calls)
+	}
+	if files := readSnapshot(t, manager, snapshot.Name); files["data/static/a.png"] != "PNG v2" {
+		t.Errorf("快照中的附件不正确: %q", files["data/static/a.png"])
+	}
+}
+
+func TestFreezeBlocksSideStores(t *testing.T) {
+	_, memoStore := newTestManager(t)
+	collections, err := store.NewCollectionStore(memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("创建合集存储失败: %v", err)
+	}
+
+	done := make(chan error, 1)
+	memoStore.Freeze(func(dataDir string) error {
+		go func() {
+			_, err := collections.Create(&store.Collection{Name: "冻结期间", Query: "#备份"})
+			done <- err
+		}()
+		select {
+		case <-done:
+			t.Errorf("禁止写入期间合集不应被写入")
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
+	})
+	if err := <-done; err != nil {
+		t.Errorf("解除禁止后写入失败: %v", err)
+	}
+}
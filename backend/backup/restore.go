@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Restore 把快照恢复为数据目录。先校验快照并解压到与数据目录同级的临时目录，
+// 再把现有的数据目录改名为 <数据目录>.before-restore-<时间> 保留，最后把临时目录改名为数据目录。
+// 返回保留的旧数据目录，数据目录原本不存在或为空时返回空字符串。恢复时服务器应处于停止状态
+func Restore(snapshotPath, dataDir string) (string, error) {
+	manifest, err := readManifest(snapshotPath, true)
+	if err != nil {
+		return "", err
+	}
+
+	dataDir = filepath.Clean(dataDir)
+	stamp := time.Now().Format(snapshotLayout)
+	tmp := dataDir + ".restore-" + stamp
+	if err := extract(snapshotPath, manifest, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+
+	previous := ""
+	if entries, err := os.ReadDir(dataDir); err == nil {
+		if len(entries) > 0 {
+			previous = dataDir + ".before-restore-" + stamp
+			if err := os.Rename(dataDir, previous); err != nil {
+				os.RemoveAll(tmp)
+				return "", fmt.Errorf("保留现有数据目录失败: %w", err)
+			}
+		} else if err := os.Remove(dataDir); err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("读取数据目录失败: %w", err)
+	}
+
+	if err := os.Rename(tmp, dataDir); err != nil {
+		return previous, fmt.Errorf("替换数据目录失败，快照已解压到 %s: %w", tmp, err)
+	}
+	return previous, nil
+}
+
+// 把清单中的文件解压到 dir，保留修改时间
+func extract(snapshotPath string, manifest *Manifest, dir string) error {
+	zr, err := zip.OpenReader(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, entry := range manifest.Files {
+		f := files[entry.Path]
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(entry.Path, dataPrefix)))
+		if err := extractFile(f, target); err != nil {
+			return fmt.Errorf("解压 %s 失败: %w", entry.Path, err)
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, f.Modified, f.Modified)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/backup"
+	"ramblog-app/backend/export"
+	"ramblog-app/backend/importer"
+	"ramblog-app/backend/search"
+	"ramblog-app/backend/site"
+	"ramblog-app/backend/store"
+)
+
+// command 表示一个子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands 所有可用的子命令
+var commands = map[string]command{}
+
+func init() {
+	for _, cmd := range []command{
+		{name: "build-site", usage: "将公开备忘录生成为静态站点", run: runBuildSite},
+		{name: "export", usage: "导出备忘录为 zip、jsonl、csv、html 或 epub 文件，可用 --q 筛选", run: runExport},
+		{name: "restore", usage: "从快照恢复数据目录，参数为快照文件名（位于备份目录）或路径，不指定时列出所有快照。恢复前需先停止服务器，现有的数据目录会被改名保留", run: runRestore},
+		{name: "import", usage: "从 flomo、memos、Obsidian 或前端 localStorage 的导出中导入备忘录，参数为导出的文件、目录或 ZIP 压缩包", run: runImport},
+		{name: "encrypt", usage: "启用加密存储并加密已有的文件，或修改加密模式、轮换数据密钥、更换口令。需先停止服务器", run: runEncrypt},
+		{name: "decrypt", usage: "解密全部文件并关闭加密存储。需先停止服务器", run: runDecrypt},
+	} {
+		commands[cmd.name] = cmd
+	}
+}
+
+// 子命令共用的数据目录参数
+type storeFlags struct {
+	dataDir string
+	keyFile string
+}
+
+// 打开数据目录，启用了加密存储时先解锁
+func (f *storeFlags) open() (*store.MemoStore, error) {
+	memoStore, err := store.NewMemoStore(f.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("无法初始化存储: %w", err)
+	}
+	if err := unlockStore(memoStore, f.keyFile); err != nil {
+		return nil, fmt.Errorf("无法解锁加密存储: %w", err)
+	}
+	return memoStore, nil
+}
+
+// 为子命令创建参数解析器，统一提供数据目录和密钥文件参数
+func newCommandFlagSet(name string) (*flag.FlagSet, *storeFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flags := &storeFlags{}
+	fs.StringVar(&flags.dataDir, "data", "./data", "数据存储目录")
+	fs.StringVar(&flags.dataDir, "d", "./data", "数据存储目录 (--data 的简写)")
+	fs.StringVar(&flags.keyFile, "keyfile", "", "加密存储的密钥文件，未指定时从 RAMBLOG_PASSPHRASE 或终端读取口令")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "使用方法: %s %s [选项]\n\n%s\n\n选项:\n", os.Args[0], name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs, flags
+}
+
+// runBuildSite 生成静态站点
+func runBuildSite(args []string) error {
+	fs, flags := newCommandFlagSet("build-site")
+	var opts site.Options
+	fs.StringVar(&opts.OutDir, "out", "./public", "输出目录")
+	fs.StringVar(&opts.OutDir, "o", "./public", "输出目录 (--out 的简写)")
+	fs.StringVar(&opts.BaseURL, "base-url", "", "站点发布后的访问地址，如 https://example.com/notes（必填）")
+	fs.StringVar(&opts.Title, "title", "Ramblog", "站点标题")
+	fs.StringVar(&opts.TemplateDir, "templates", "", "自定义模板目录，覆盖同名的内置模板")
+	fs.IntVar(&opts.PageSize, "page-size", site.DefaultPageSize, "首页每页条数")
+	fs.Parse(args)
+
+	memoStore, err := flags.open()
+	if err != nil {
+		return err
+	}
+
+	builder, err := site.NewBuilder(memoStore, opts)
+	if err != nil {
+		return err
+	}
+	report, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("站点已生成到 %s: %d 条备忘录, %d 个页面, %d 个附件, 删除 %d 个旧文件\n",
+		opts.OutDir, report.Memos, report.Pages, report.Attachments, report.Removed)
+	for _, tag := range report.SkippedTags {
+		log.Printf("标签 #%s 无法作为目录名，未生成标签页\n", tag)
+	}
+	return nil
+}
+
+// runExport 导出备忘录，筛选条件与 /api/memos?q= 相同
+func runExport(args []string) error {
+	fs, flags := newCommandFlagSet("export")
+	formatName := fs.String("format", export.Formats[0].Name, "导出格式: "+strings.Join(export.FormatNames(), "、"))
+	fs.StringVar(formatName, "f", *formatName, "导出格式 (--format 的简写)")
+	out := fs.String("out", "", "输出文件，默认为当前目录下的 ramblog-<时间>.<格式>，- 表示标准输出")
+	fs.StringVar(out, "o", "", "输出文件 (--out 的简写)")
+	var opts export.Options
+	fs.StringVar(&opts.Query, "q", "", "筛选条件，如 \"tag:旅行 created:2024\"")
+	fs.StringVar(&opts.Title, "title", "", "html 和 epub 的标题")
+	fs.StringVar(&opts.Author, "author", "", "epub 的作者")
+	fs.Parse(args)
+
+	format, ok := export.LookupFormat(*formatName)
+	if !ok {
+		return export.ErrUnknownFormat
+	}
+
+	memoStore, err := flags.open()
+	if err != nil {
+		return err
+	}
+
+	var memos []*store.Memo
+	if format.Name != "zip" || opts.Query != "" {
+		if memos, err = memoStore.ListMemos(); err != nil {
+			return err
+		}
+		if opts.Query != "" {
+			query, err := search.ParseQuery(opts.Query)
+			if err != nil {
+				return err
+			}
+			memos = search.Filter(memos, query)
+		}
+	}
+
+	if *out == "-" {
+		return export.Write(os.Stdout, memoStore, format.Name, memos, opts)
+	}
+	if *out == "" {
+		*out = "ramblog-" + time.Now().Format("20060102-150405") + format.Ext
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %w", err)
+	}
+	if err := export.Write(f, memoStore, format.Name, memos, opts); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if memos == nil {
+		log.Printf("已导出整个数据目录到 %s\n", *out)
+	} else {
+		log.Printf("已导出 %d 条备忘录到 %s\n", len(memos), *out)
+	}
+	return nil
+}
+
+// runRestore 从快照恢复数据目录
+func runRestore(args []string) error {
+	fs, flags := newCommandFlagSet("restore")
+	backupDir := fs.String("backup-dir", "", "快照目录 (默认: 与数据目录同级的 <数据目录>-backups)")
+	fs.Parse(args)
+	if *backupDir == "" {
+		*backupDir = backup.DefaultDir(flags.dataDir)
+	}
+
+	manager, err := backup.NewManager(nil, *backupDir, backup.DefaultPolicy)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		snapshots, err := manager.List()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Printf("%s 中没有快照\n", *backupDir)
+		}
+		for _, snapshot := range snapshots {
+			fmt.Printf("%s  %s  %d 字节\n", snapshot.Name, snapshot.CreatedAt.Format("2006-01-02 15:04:05"), snapshot.Size)
+		}
+		return nil
+	}
+
+	// 参数可以是备份目录中的文件名，也可以是快照文件的路径
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err != nil {
+		if path, err = manager.Path(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	previous, err := backup.Restore(path, flags.dataDir)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		log.Printf("原数据目录已保留为 %s\n", previous)
+	}
+	log.Printf("已从 %s 恢复数据目录 %s\n", path, flags.dataDir)
+	return nil
+}
+
+// runImport 从其他工具的导出中导入备忘录
+func runImport(args []string) error {
+	fs, flags := newCommandFlagSet("import")
+	from := fs.String("from", "", "导出格式: "+strings.Join(importer.Names(), "、")+"，默认自动识别")
+	dryRun := fs.Bool("dry-run", false, "只显示将要导入的内容，不写入数据目录")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("需要指定一个导出文件或目录")
+	}
+
+	memoStore, err := flags.open()
+	if err != nil {
+		return err
+	}
+
+	report, err := importer.Import(memoStore, *from, fs.Arg(0), importer.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	for _, memo := range report.Memos {
+		switch memo.Action {
+		case importer.ActionCreate:
+			fmt.Printf("创建 %s  %s  %s\n", memo.ID, memo.CreatedAt.Format("2006-01-02 15:04"), memo.Source)
+		case importer.ActionSkip:
+			fmt.Printf("跳过 %s（%s）\n", memo.Source, memo.Reason)
+		}
+	}
+	for _, missing := range report.MissingAttachments {
+		fmt.Printf("缺少附件 %s\n", missing)
+	}
+
+	verb := "已导入"
+	if report.DryRun {
+		verb = "将导入"
+	}
+	log.Printf("%s %d 条备忘录（%s），跳过 %d 条，新增 %d 个附件，缺少 %d 个附件\n",
+		verb, report.Created, report.Format, report.Skipped, report.Attachments, len(report.MissingAttachments))
+	return nil
+}
+
+// runEncrypt 启用或调整加密存储，并按加密模式重新处理已有的文件
+func runEncrypt(args []string) error {
+	fs, flags := newCommandFlagSet("encrypt")
+	mode := fs.String("mode", "", "加密模式: all 加密全部备忘录和附件，private 只加密带 private 标签的备忘录 (首次启用时默认 all)")
+	rotate := fs.Bool("rotate", false, "生成新的数据密钥，用它重新加密全部文件后删除旧密钥")
+	changeSecret := fs.Bool("change-passphrase", false, "更换口令，新口令在终端中输入")
+	newKeyFile := fs.String("new-keyfile", "", "更换为该密钥文件中的口令")
+	fs.Parse(args)
+
+	memoStore, err := store.NewMemoStore(flags.dataDir)
+	if err != nil {
+		return fmt.Errorf("无法初始化存储: %w", err)
+	}
+
+	var keyring *store.Keyring
+	if store.EncryptionConfigured(flags.dataDir) {
+		secret, err := readSecret(flags.keyFile, "口令: ")
+		if err != nil {
+			return err
+		}
+		if keyring, err = store.UnlockKeyring(flags.dataDir, secret); err != nil {
+			return err
+		}
+		if *mode != "" && *mode != keyring.Mode() {
+			if *mode == store.EncryptionNone {
+				return fmt.Errorf("关闭加密请使用 decrypt 命令")
+			}
+			if err := keyring.SetMode(*mode); err != nil {
+				return err
+			}
+		}
+	} else {
+		if *mode == "" {
+			*mode = store.EncryptionAll
+		}
+		secret, err := readNewSecret(flags.keyFile, true)
+		if err != nil {
+			return err
+		}
+		if keyring, err = store.CreateKeyring(flags.dataDir, secret, *mode); err != nil {
+			return err
+		}
+		log.Printf("已为 %s 启用加密存储（模式: %s）\n", flags.dataDir, *mode)
+	}
+	memoStore.SetKeyring(keyring)
+
+	if *rotate {
+		id, err := keyring.Rotate()
+		if err != nil {
+			return err
+		}
+		log.Printf("已生成新的数据密钥 %s\n", id)
+	}
+
+	report, err := memoStore.ApplyEncryption()
+	if err != nil {
+		return err
+	}
+	log.Printf("加密 %d 个文件，解密 %d 个，更换密钥 %d 个，%d 个无需处理\n",
+		report.Encrypted, report.Decrypted, report.Rekeyed, report.Unchanged)
+
+	if *rotate {
+		removed, err := keyring.RetireKeys()
+		if err != nil {
+			return err
+		}
+		log.Printf("已删除 %d 个旧的数据密钥\n", removed)
+	}
+
+	if *changeSecret || *newKeyFile != "" {
+		secret, err := readNewSecret(*newKeyFile, false)
+		if err != nil {
+			return err
+		}
+		if err := keyring.ChangeSecret(secret); err != nil {
+			return err
+		}
+		log.Println("已更换口令")
+	}
+	return nil
+}
+
+// runDecrypt 解密全部文件并删除密钥
+func runDecrypt(args []string) error {
+	fs, flags := newCommandFlagSet("decrypt")
+	fs.Parse(args)
+
+	if !store.EncryptionConfigured(flags.dataDir) {
+		return fmt.Errorf("数据目录 %s 未启用加密存储", flags.dataDir)
+	}
+	memoStore, err := flags.open()
+	if err != nil {
+		return err
+	}
+
+	keyring := memoStore.Keyring()
+	if err := keyring.SetMode(store.EncryptionNone); err != nil {
+		return err
+	}
+	report, err := memoStore.ApplyEncryption()
+	if err != nil {
+		return err
+	}
+	if err := keyring.Remove(); err != nil {
+		return fmt.Errorf("删除密钥文件失败: %w", err)
+	}
+	log.Printf("已解密 %d 个文件，关闭加密存储\n", report.Decrypted)
+	return nil
+}
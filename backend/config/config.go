@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 )
 
 // Config 存储应用配置
@@ -11,6 +12,18 @@ type Config struct {
 	ServerAddr string // 服务器地址
 	DataDir    string // 数据目录
 	Debug      bool   // 是否启用调试模式
+	SiteTitle  string // 站点标题，用于订阅源
+	BaseURL    string // 站点对外访问地址，为空时根据请求推断
+	Hashtags   string // 正文 #标签 的合并策略: merge、content 或 frontmatter
+	KeyFile    string // 加密存储的密钥文件，为空时从 RAMBLOG_PASSPHRASE 或终端读取口令
+
+	WatchInterval time.Duration // 扫描数据目录中外部修改的间隔，0 表示不扫描
+
+	BackupDir      string        // 快照目录，为空时使用与数据目录同级的 <数据目录>-backups
+	BackupInterval time.Duration // 自动快照的间隔，0 表示不自动备份
+	BackupHourly   int           // 保留最近多少小时的快照
+	BackupDaily    int           // 保留最近多少天的快照
+	BackupWeekly   int           // 保留最近多少周的快照
 }
 
 // LoadConfig 从命令行参数加载配置
@@ -20,6 +33,18 @@ func LoadConfig() *Config {
 		serverAddr = flag.String("addr", "127.0.0.1:3000", "服务器监听地址")
 		dataDir    = flag.String("data", "./data", "数据存储目录")
 		debug      = flag.Bool("debug", false, "是否启用调试模式")
+		siteTitle  = flag.String("title", "Ramblog", "站点标题")
+		baseURL    = flag.String("base-url", "", "站点对外访问地址，用于生成订阅源中的绝对链接")
+		hashtags   = flag.String("hashtags", "merge", "正文 #标签 的合并策略: merge、content 或 frontmatter")
+		keyFile    = flag.String("keyfile", "", "加密存储的密钥文件，未指定时从 RAMBLOG_PASSPHRASE 或终端读取口令")
+
+		watchInterval = flag.Duration("watch-interval", 2*time.Second, "扫描数据目录中外部修改的间隔，0 表示不扫描")
+
+		backupDir      = flag.String("backup-dir", "", "快照目录 (默认: 与数据目录同级的 <数据目录>-backups)")
+		backupInterval = flag.Duration("backup-interval", time.Hour, "自动快照的间隔，0 表示不自动备份")
+		backupHourly   = flag.Int("backup-hourly", 24, "保留最近多少小时的快照")
+		backupDaily    = flag.Int("backup-daily", 7, "保留最近多少天的快照")
+		backupWeekly   = flag.Int("backup-weekly", 4, "保留最近多少周的快照")
 	)
 
 	// 定义短参数别名
@@ -34,7 +59,22 @@ func LoadConfig() *Config {
 		fmt.Fprintf(os.Stderr, "  -a, --addr string    服务器监听地址 (默认: \"127.0.0.1:3000\")\n")
 		fmt.Fprintf(os.Stderr, "  -d, --data string    数据存储目录 (默认: \"./data\")\n")
 		fmt.Fprintf(os.Stderr, "  -D, --debug          是否启用调试模式 (默认: false)\n")
+		fmt.Fprintf(os.Stderr, "      --title string   站点标题 (默认: \"Ramblog\")\n")
+		fmt.Fprintf(os.Stderr, "      --base-url string 站点对外访问地址 (默认根据请求推断)\n")
+		fmt.Fprintf(os.Stderr, "      --hashtags string 正文 #标签 的合并策略: merge、content 或 frontmatter (默认: \"merge\")\n")
+		fmt.Fprintf(os.Stderr, "      --keyfile string 加密存储的密钥文件 (默认从 RAMBLOG_PASSPHRASE 或终端读取口令)\n")
+		fmt.Fprintf(os.Stderr, "      --watch-interval duration 扫描数据目录中外部修改的间隔，0 表示不扫描 (默认: 2s)\n")
+		fmt.Fprintf(os.Stderr, "      --backup-dir string 快照目录 (默认: \"<数据目录>-backups\")\n")
+		fmt.Fprintf(os.Stderr, "      --backup-interval duration 自动快照的间隔，0 表示不自动备份 (默认: 1h)\n")
+		fmt.Fprintf(os.Stderr, "      --backup-hourly int  保留最近多少小时的快照 (默认: 24)\n")
+		fmt.Fprintf(os.Stderr, "      --backup-daily int   保留最近多少天的快照 (默认: 7)\n")
+		fmt.Fprintf(os.Stderr, "      --backup-weekly int  保留最近多少周的快照 (默认: 4)\n")
 		fmt.Fprintf(os.Stderr, "  -h, --help           显示帮助信息\n")
+		fmt.Fprintf(os.Stderr, "\n子命令:\n")
+		fmt.Fprintf(os.Stderr, "  build-site           将公开备忘录生成为静态站点\n")
+		fmt.Fprintf(os.Stderr, "  restore <快照>       从快照恢复数据目录（需先停止服务器）\n")
+		fmt.Fprintf(os.Stderr, "  encrypt              启用加密存储、轮换密钥或更换口令（需先停止服务器）\n")
+		fmt.Fprintf(os.Stderr, "  decrypt              解密全部文件并关闭加密存储（需先停止服务器）\n")
 		os.Exit(0)
 	}
 
@@ -44,5 +84,17 @@ func LoadConfig() *Config {
 		ServerAddr: *serverAddr,
 		DataDir:    *dataDir,
 		Debug:      *debug,
+		SiteTitle:  *siteTitle,
+		BaseURL:    *baseURL,
+		Hashtags:   *hashtags,
+		KeyFile:    *keyFile,
+
+		WatchInterval: *watchInterval,
+
+		BackupDir:      *backupDir,
+		BackupInterval: *backupInterval,
+		BackupHourly:   *backupHourly,
+		BackupDaily:    *backupDaily,
+		BackupWeekly:   *backupWeekly,
 	}
 }
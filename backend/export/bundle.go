@@ -0,0 +1,223 @@
+// Package export 把备忘录导出为可下载的文件
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// ManifestName 压缩包中清单文件的名称
+const ManifestName = "manifest.json"
+
+// ManifestFormat 清单中标识压缩包格式的名称
+const ManifestFormat = "ramblog-archive"
+
+// Manifest 压缩包中的 manifest.json，记录其余每个文件的大小和 SHA-256
+type Manifest struct {
+	Format      string         `json:"format"`
+	Version     int            `json:"version"`
+	ExportedAt  time.Time      `json:"exportedAt"`
+	Memos       int            `json:"memos"`
+	Attachments int            `json:"attachments"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile 清单中的一个文件
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle 以流的方式把备忘录的原始文件和附件写入 ZIP：
+// 备忘录位于 memos/<id>.md，附件位于 static/<名称>，最后写入 manifest.json
+type Bundle struct {
+	zw       *zip.Writer
+	store    *store.MemoStore
+	added    map[string]bool
+	manifest Manifest
+
+	Memos       int      // 已写入的备忘录数
+	Attachments int      // 已写入的附件数
+	Missing     []string // 引用了但不存在的附件
+}
+
+// NewBundle 创建写入 w 的打包器
+func NewBundle(w io.Writer, memoStore *store.MemoStore) *Bundle {
+	return &Bundle{
+		zw:       zip.NewWriter(w),
+		store:    memoStore,
+		added:    make(map[string]bool),
+		manifest: Manifest{Format: ManifestFormat, Version: 1, Files: []ManifestFile{}},
+	}
+}
+
+// AddFile 写入一个文件，同名文件只写入一次
+func (b *Bundle) AddFile(name string, data []byte, modTime time.Time) error {
+	return b.addReader(name, bytes.NewReader(data), modTime)
+}
+
+// 从 r 复制文件内容，同时计算校验和
+func (b *Bundle) addReader(name string, r io.Reader, modTime time.Time) error {
+	if b.added[name] {
+		return nil
+	}
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(w, hash), r)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	b.added[name] = true
+	b.manifest.Files = append(b.manifest.Files, ManifestFile{
+		Path:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+	return nil
+}
+
+// AddMemo 写入备忘录文件及其引用的附件。引用的附件不存在时记入 Missing，不中断导出
+func (b *Bundle) AddMemo(memo *store.Memo) error {
+	data, err := b.store.ReadMemoFile(memo.ID)
+	if err != nil {
+		return err
+	}
+	if err := b.AddFile(path.Join("memos", memo.ID+".md"), data, memo.UpdatedAt); err != nil {
+		return err
+	}
+	b.Memos++
+
+	for _, name := range store.ReferencedAttachments(memo.Content) {
+		ok, err := b.AddAttachment(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			b.Missing = append(b.Missing, name)
+		}
+	}
+	return nil
+}
+
+// AddAttachment 从 static 目录复制附件，文件不存在时返回 false
+func (b *Bundle) AddAttachment(name string) (bool, error) {
+	entry := path.Join("static", name)
+	if b.added[entry] {
+		return true, nil
+	}
+
+	f, err := b.store.OpenAttachment(name)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取附件 %s 失败: %w", name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false, nil
+	}
+	if err := b.addReader(entry, f, info.ModTime()); err != nil {
+		return false, err
+	}
+	b.Attachments++
+	return true, nil
+}
+
+// Close 写入 manifest.json 和 ZIP 目录，不关闭底层的 io.Writer
+func (b *Bundle) Close() error {
+	b.manifest.ExportedAt = time.Now().Truncate(time.Second)
+	b.manifest.Memos = b.Memos
+	b.manifest.Attachments = b.Attachments
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成清单失败: %w", err)
+	}
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: b.manifest.ExportedAt})
+	if err != nil {
+		return fmt.Errorf("写入清单失败: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入清单失败: %w", err)
+	}
+	return b.zw.Close()
+}
+
+// WriteArchive 把全部备忘录和 static 目录中的全部附件以流的方式写入 w。
+// 逐个读取文件，不会把所有内容同时读入内存
+func WriteArchive(w io.Writer, memoStore *store.MemoStore) (*Bundle, error) {
+	bundle := NewBundle(w, memoStore)
+
+	ids, err := memoStore.MemoIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		memo, err := memoStore.GetMemo(id)
+		if err != nil {
+			// 导出过程中被删除的备忘录
+			continue
+		}
+		if err := bundle.AddMemo(memo); err != nil {
+			return nil, err
+		}
+	}
+
+	staticDir := memoStore.GetStaticDir()
+	err = filepath.WalkDir(staticDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(staticDir, p)
+		if err != nil {
+			return err
+		}
+		_, err = bundle.AddAttachment(filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取附件失败: %w", err)
+	}
+
+	if err := bundle.Close(); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// WriteMemoArchive 只导出给定的备忘录及其引用的附件，格式与 WriteArchive 相同
+func WriteMemoArchive(w io.Writer, memoStore *store.MemoStore, memos []*store.Memo) (*Bundle, error) {
+	bundle := NewBundle(w, memoStore)
+	for _, memo := range memos {
+		if err := bundle.AddMemo(memo); err != nil {
+			return nil, err
+		}
+	}
+	if err := bundle.Close(); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
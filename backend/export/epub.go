@@ -0,0 +1,431 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// EPUB 中可以直接使用的图片类型，其余附件保留为文字
+var epubImageTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// EPUBOptions EPUB 导出的选项
+type EPUBOptions struct {
+	Title  string // 书名，默认 Ramblog
+	Author string // 作者
+	Query  string // 筛选条件，显示在扉页
+}
+
+type epubMemo struct {
+	*store.Memo
+	Anchor string // 在章节中的锚点
+	HTML   template.HTML
+}
+
+type epubChapter struct {
+	ID    string // 2024-01
+	Title string // 2024年1月
+	File  string // 2024-01.xhtml
+	Memos []*epubMemo
+}
+
+type epubTag struct {
+	Name   string
+	Anchor string
+	Memos  []epubTagEntry
+}
+
+type epubTagEntry struct {
+	Href  string
+	Label string
+}
+
+type epubImage struct {
+	ID        string
+	Href      string
+	MediaType string
+	source    string // static 目录中的名称
+}
+
+type epubBook struct {
+	EPUBOptions
+	Identifier string
+	Modified   string
+	Count      int
+	Chapters   []*epubChapter
+	Tags       []*epubTag
+	Images     []*epubImage
+
+	images map[string]*epubImage // 按附件名称索引
+}
+
+// WriteEPUB 把备忘录生成为 EPUB 3 电子书：按月份分章，附带目录和标签索引，
+// 正文引用的 static 目录中的图片会打包进书中。备忘录按创建时间排列
+func WriteEPUB(w io.Writer, memoStore *store.MemoStore, memos []*store.Memo, opts EPUBOptions) error {
+	if opts.Title == "" {
+		opts.Title = "Ramblog"
+	}
+	sorted := make([]*store.Memo, len(memos))
+	copy(sorted, memos)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	book := &epubBook{
+		EPUBOptions: opts,
+		Identifier:  bookIdentifier(opts.Title, sorted),
+		Modified:    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Count:       len(sorted),
+		images:      make(map[string]*epubImage),
+	}
+	tags := make(map[string]*epubTag)
+
+	for _, memo := range sorted {
+		month := memo.CreatedAt.Format("2006-01")
+		if len(book.Chapters) == 0 || book.Chapters[len(book.Chapters)-1].ID != month {
+			book.Chapters = append(book.Chapters, &epubChapter{
+				ID:    month,
+				Title: fmt.Sprintf("%d年%d月", memo.CreatedAt.Year(), memo.CreatedAt.Month()),
+				File:  month + ".xhtml",
+			})
+		}
+		chapter := book.Chapters[len(book.Chapters)-1]
+
+		anchor := "memo-" + memo.ID
+		content, err := book.memoXHTML(memoStore, memo, anchor)
+		if err != nil {
+			return err
+		}
+		chapter.Memos = append(chapter.Memos, &epubMemo{Memo: memo, Anchor: anchor, HTML: template.HTML(content)})
+
+		for _, name := range memo.Tags {
+			tag, ok := tags[name]
+			if !ok {
+				tag = &epubTag{Name: name}
+				tags[name] = tag
+			}
+			tag.Memos = append(tag.Memos, epubTagEntry{Href: chapter.File + "#" + anchor, Label: memoLabel(memo)})
+		}
+	}
+
+	for _, tag := range tags {
+		book.Tags = append(book.Tags, tag)
+	}
+	sort.Slice(book.Tags, func(i, j int) bool { return book.Tags[i].Name < book.Tags[j].Name })
+	for i, tag := range book.Tags {
+		tag.Anchor = fmt.Sprintf("tag-%d", i+1)
+	}
+
+	return book.write(w, memoStore)
+}
+
+// 写入 EPUB 压缩包，mimetype 必须是第一个文件且不压缩
+func (b *epubBook) write(w io.Writer, memoStore *store.MemoStore) error {
+	zw := zip.NewWriter(w)
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct{ name, tmpl string }{
+		{"META-INF/container.xml", "container"},
+		{"OEBPS/content.opf", "opf"},
+		{"OEBPS/nav.xhtml", "nav"},
+		{"OEBPS/title.xhtml", "title"},
+		{"OEBPS/tags.xhtml", "tags"},
+	}
+	for _, f := range files {
+		if err := b.writeTemplate(zw, f.name, f.tmpl, b); err != nil {
+			return err
+		}
+	}
+	sw, err := zw.Create("OEBPS/style.css")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sw, epubStyleSheet+render.StyleSheet()); err != nil {
+		return err
+	}
+	for _, chapter := range b.Chapters {
+		if err := b.writeTemplate(zw, "OEBPS/"+chapter.File, "chapter", chapter); err != nil {
+			return err
+		}
+	}
+
+	for _, image := range b.Images {
+		f, err := memoStore.OpenAttachment(image.source)
+		if err != nil {
+			return fmt.Errorf("读取附件 %s 失败: %w", image.source, err)
+		}
+		iw, err := zw.Create("OEBPS/" + image.Href)
+		if err == nil {
+			_, err = io.Copy(iw, f)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("写入附件 %s 失败: %w", image.source, err)
+		}
+	}
+	return zw.Close()
+}
+
+func (b *epubBook) writeTemplate(zw *zip.Writer, name, tmpl string, data any) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	if err := epubTemplate.ExecuteTemplate(fw, tmpl, data); err != nil {
+		return fmt.Errorf("生成 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// 渲染备忘录并转换为 XHTML：图片改为书中的路径，元素ID加上备忘录前缀以免同一章中重复
+func (b *epubBook) memoXHTML(memoStore *store.MemoStore, memo *store.Memo, anchor string) (string, error) {
+	rendered, err := render.HTML(memo.Content)
+	if err != nil {
+		return "", err
+	}
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(rendered), body)
+	if err != nil {
+		return "", fmt.Errorf("解析备忘录 %s 失败: %w", memo.ID, err)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		b.rewriteNode(memoStore, n, anchor)
+		if err := html.Render(&buf, n); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func (b *epubBook) rewriteNode(memoStore *store.MemoStore, n *html.Node, anchor string) {
+	if n.Type == html.ElementNode {
+		for i, attr := range n.Attr {
+			switch {
+			case attr.Key == "id":
+				n.Attr[i].Val = anchor + "-" + attr.Val
+			case attr.Key == "href" && strings.HasPrefix(attr.Val, "#"):
+				n.Attr[i].Val = "#" + anchor + "-" + attr.Val[1:]
+			}
+		}
+		if n.DataAtom == atom.Img {
+			b.rewriteImage(memoStore, n)
+		}
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		b.rewriteNode(memoStore, c, anchor)
+		c = next
+	}
+}
+
+// 把引用附件的图片打包进书中，无法打包的图片替换为说明文字
+func (b *epubBook) rewriteImage(memoStore *store.MemoStore, n *html.Node) {
+	for i, attr := range n.Attr {
+		if attr.Key != "src" || !strings.HasPrefix(attr.Val, "/static/") {
+			continue
+		}
+		name := strings.TrimPrefix(attr.Val, "/static/")
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		if image := b.addImage(memoStore, name); image != nil {
+			n.Attr[i].Val = image.Href
+			return
+		}
+		if n.Parent != nil {
+			n.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: "[图片: " + path.Base(name) + "]"}, n)
+			n.Parent.RemoveChild(n)
+		}
+		return
+	}
+}
+
+func (b *epubBook) addImage(memoStore *store.MemoStore, name string) *epubImage {
+	if image, ok := b.images[name]; ok {
+		return image
+	}
+	ext := strings.ToLower(path.Ext(name))
+	mediaType, ok := epubImageTypes[ext]
+	if !ok || !memoStore.AttachmentExists(name) {
+		return nil
+	}
+	id := fmt.Sprintf("image-%d", len(b.Images)+1)
+	image := &epubImage{ID: id, Href: "images/" + id + ext, MediaType: mediaType, source: name}
+	b.Images = append(b.Images, image)
+	b.images[name] = image
+	return image
+}
+
+// 目录和标签索引中显示的备忘录名称
+func memoLabel(memo *store.Memo) string {
+	if memo.Title != "" {
+		return memo.Title
+	}
+	return memo.CreatedAt.Format("2006-01-02 15:04")
+}
+
+// 由书名和备忘录ID生成稳定的标识符，同样的内容重复导出时阅读器能识别为同一本书
+func bookIdentifier(title string, memos []*store.Memo) string {
+	h := sha1.New()
+	io.WriteString(h, title)
+	for _, memo := range memos {
+		io.WriteString(h, "\x00"+memo.ID)
+	}
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// 书中的样式，代码高亮的样式附加在后面
+const epubStyleSheet = `body { line-height: 1.7; }
+h1 { font-size: 1.5em; }
+article { margin: 1.5em 0; padding-bottom: 1em; border-bottom: 1px solid #ddd; }
+img { max-width: 100%; }
+pre { white-space: pre-wrap; font-size: .85em; }
+.meta { font-size: .85em; color: #777; }
+.tag { margin-left: .3em; }
+.heading-anchor { display: none; }
+.title-page { text-align: center; margin-top: 30%; }
+`
+
+var epubTemplate = template.Must(template.New("epub").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"label":    memoLabel,
+	"xml":      func() template.HTML { return `<?xml version="1.0" encoding="UTF-8"?>` },
+}).Parse(`
+{{define "container"}}{{xml}}
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+{{end}}
+
+{{define "opf"}}{{xml}}
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="zh-CN">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.Identifier}}</dc:identifier>
+    <dc:title>{{.Title}}</dc:title>
+    <dc:language>zh-CN</dc:language>
+    {{if .Author}}<dc:creator>{{.Author}}</dc:creator>{{end}}
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+    {{range .Chapters}}<item id="chapter-{{.ID}}" href="{{.File}}" media-type="application/xhtml+xml"/>
+    {{end}}<item id="tags" href="tags.xhtml" media-type="application/xhtml+xml"/>
+    {{range .Images}}<item id="{{.ID}}" href="{{.Href}}" media-type="{{.MediaType}}"/>
+    {{end}}
+  </manifest>
+  <spine>
+    <itemref idref="title"/>
+    <itemref idref="nav"/>
+    {{range .Chapters}}<itemref idref="chapter-{{.ID}}"/>
+    {{end}}<itemref idref="tags"/>
+  </spine>
+</package>
+{{end}}
+
+{{define "head"}}{{xml}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="zh-CN" lang="zh-CN">
+<head>
+  <meta charset="utf-8"/>
+  <title>{{.}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+{{end}}
+
+{{define "title"}}{{template "head" .Title}}
+<body>
+  <section class="title-page" epub:type="titlepage">
+    <h1>{{.Title}}</h1>
+    {{if .Author}}<p>{{.Author}}</p>{{end}}
+    <p class="meta">共 {{.Count}} 条备忘录{{if .Query}}，筛选条件：{{.Query}}{{end}}</p>
+  </section>
+</body>
+</html>
+{{end}}
+
+{{define "nav"}}{{template "head" "目录"}}
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>目录</h1>
+    <ol>
+      {{range .Chapters}}<li><a href="{{.File}}">{{.Title}}</a>
+        <ol>
+          {{$file := .File}}{{range .Memos}}<li><a href="{{$file}}#{{.Anchor}}">{{label .Memo}}</a></li>
+          {{end}}
+        </ol>
+      </li>
+      {{end}}<li><a href="tags.xhtml">标签索引</a>{{if .Tags}}
+        <ol>
+          {{range .Tags}}<li><a href="tags.xhtml#{{.Anchor}}">#{{.Name}}</a></li>
+          {{end}}
+        </ol>{{end}}
+      </li>
+    </ol>
+  </nav>
+</body>
+</html>
+{{end}}
+
+{{define "chapter"}}{{template "head" .Title}}
+<body>
+  <section epub:type="chapter">
+    <h1>{{.Title}}</h1>
+    {{range .Memos}}<article id="{{.Anchor}}">
+      <p class="meta">{{datetime .CreatedAt}}{{range .Tags}} <span class="tag">#{{.}}</span>{{end}}</p>
+      {{if .Title}}<h2>{{.Title}}</h2>{{end}}
+      {{.HTML}}
+    </article>
+    {{end}}
+  </section>
+</body>
+</html>
+{{end}}
+
+{{define "tags"}}{{template "head" "标签索引"}}
+<body>
+  <section epub:type="index">
+    <h1>标签索引</h1>
+    {{range .Tags}}<h2 id="{{.Anchor}}">#{{.Name}}</h2>
+    <ul>
+      {{range .Memos}}<li><a href="{{.Href}}">{{.Label}}</a></li>
+      {{end}}
+    </ul>
+    {{end}}
+  </section>
+</body>
+</html>
+{{end}}
+`))
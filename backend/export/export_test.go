@@ -0,0 +1,282 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+func newTestStore(t *testing.T) *store.MemoStore {
+	dir, err := os.MkdirTemp("", "memo-export-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	return memoStore
+}
+
+func createMemo(t *testing.T, memoStore *store.MemoStore, content, visibility string) *store.Memo {
+	memo := &store.Memo{Content: content, Visibility: visibility}
+	if err := memoStore.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	return memo
+}
+
+func writeAttachment(t *testing.T, memoStore *store.MemoStore, name, content string) {
+	if err := os.WriteFile(filepath.Join(memoStore.GetStaticDir(), name), []byte(content), 0644); err != nil {
+		t.Fatalf("写入附件失败: %v", err)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := newTestStore(t)
+	writeAttachment(t, src, "a.png", "IMG")
+	memo := createMemo(t, src, "第一条 ![](/static/a.png) #旅行", store.VisibilityPublic)
+
+	var buf bytes.Buffer
+	bundle, err := WriteArchive(&buf, src)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if bundle.Memos != 1 || bundle.Attachments != 1 {
+		t.Errorf("导出数量不正确: %d 条备忘录, %d 个附件", bundle.Memos, bundle.Attachments)
+	}
+
+	dst := newTestStore(t)
+	data := buf.Bytes()
+	report, err := RestoreArchive(bytes.NewReader(data), int64(len(data)), dst, "")
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if report.Conflict != ConflictSkip || report.Counts[RestoreCreated] != 2 {
+		t.Errorf("恢复报告不正确: %+v", report.Counts)
+	}
+	restored, err := dst.GetMemo(memo.ID)
+	if err != nil {
+		t.Fatalf("获取恢复的备忘录失败: %v", err)
+	}
+	if restored.Content != memo.Content || !restored.IsPublic() || len(restored.Tags) != 1 {
+		t.Errorf("恢复的备忘录不正确: %+v", restored)
+	}
+
+	// 再次恢复时内容相同的文件保持不变
+	report, err = RestoreArchive(bytes.NewReader(data), int64(len(data)), dst, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("重复恢复失败: %v", err)
+	}
+	if report.Counts[RestoreUnchanged] != 2 {
+		t.Errorf("相同的文件应保持不变: %+v", report.Counts)
+	}
+}
+
+func TestRestoreRename(t *testing.T) {
+	src := newTestStore(t)
+	writeAttachment(t, src, "a.png", "NEW")
+	memo := createMemo(t, src, "新的 ![](/static/a.png)", "")
+	var buf bytes.Buffer
+	if _, err := WriteArchive(&buf, src); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	// 目标目录中已有同名但内容不同的备忘录和附件
+	dst := newTestStore(t)
+	writeAttachment(t, dst, "a.png", "OLD")
+	createMemo(t, dst, "原有的", "")
+
+	data := buf.Bytes()
+	report, err := RestoreArchive(bytes.NewReader(data), int64(len(data)), dst, ConflictRename)
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if report.Counts[RestoreRenamed] != 2 {
+		t.Fatalf("冲突的文件应改名保存: %+v", report.Files)
+	}
+
+	original, _ := dst.GetMemo(memo.ID)
+	if original == nil || original.Content != "原有的" {
+		t.Errorf("原有的备忘录不应被覆盖: %+v", original)
+	}
+	var renamedMemo, renamedFile string
+	for _, entry := range report.Files {
+		if strings.HasPrefix(entry.Path, "memos/") {
+			renamedMemo = entry.RenamedTo
+		} else {
+			renamedFile = entry.RenamedTo
+		}
+	}
+	restored, err := dst.GetMemo(renamedMemo)
+	if err != nil {
+		t.Fatalf("获取改名后的备忘录失败: %v", err)
+	}
+	if !strings.Contains(restored.Content, "/static/"+renamedFile) {
+		t.Errorf("备忘录应引用改名后的附件 %s: %q", renamedFile, restored.Content)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst.GetStaticDir(), "a.png")); string(data) != "OLD" {
+		t.Errorf("原有的附件不应被覆盖: %q", data)
+	}
+}
+
+func TestRestoreRejectsCorruptArchive(t *testing.T) {
+	src := newTestStore(t)
+	createMemo(t, src, "内容", "")
+	var buf bytes.Buffer
+	if _, err := WriteArchive(&buf, src); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	// 重新打包，篡改备忘录内容但保留原清单
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("读取压缩包失败: %v", err)
+	}
+	var tampered bytes.Buffer
+	zw := zip.NewWriter(&tampered)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if strings.HasPrefix(f.Name, "memos/") {
+			data = append(data, "篡改"...)
+		}
+		w, _ := zw.Create(f.Name)
+		w.Write(data)
+	}
+	zw.Close()
+
+	dst := newTestStore(t)
+	_, err = RestoreArchive(bytes.NewReader(tampered.Bytes()), int64(tampered.Len()), dst, "")
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("校验失败时应返回 ErrInvalidArchive, 实际 %v", err)
+	}
+	if ids, _ := dst.MemoIDs(); len(ids) != 0 {
+		t.Errorf("校验失败时不应写入任何文件: %v", ids)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	memoStore := newTestStore(t)
+	createMemo(t, memoStore, "第一行, \"引号\" #读书 #工作", store.VisibilityPublic)
+
+	memos, _ := memoStore.ListMemos()
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, memos); err != nil {
+		t.Fatalf("导出 CSV 失败: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+	if len(records) != 2 || len(records[1]) != len(CSVHeader) {
+		t.Fatalf("CSV 行数或列数不正确: %v", records)
+	}
+	if records[1][2] != "读书 工作" || records[1][3] != store.VisibilityPublic || records[1][5] != "false" {
+		t.Errorf("CSV 内容不正确: %v", records[1])
+	}
+}
+
+func TestWriteHTMLInlinesImages(t *testing.T) {
+	memoStore := newTestStore(t)
+	writeAttachment(t, memoStore, "图片.png", "PNG")
+	createMemo(t, memoStore, "看图 ![](/static/%E5%9B%BE%E7%89%87.png) ![](/static/missing.png) <script>alert(1)</script>", "")
+
+	memos, _ := memoStore.ListMemos()
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, memoStore, memos, HTMLOptions{Title: "导出", Query: "tag:x"}); err != nil {
+		t.Fatalf("导出 HTML 失败: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `src="data:image/png;base64,UE5H"`) {
+		t.Errorf("图片应以 data URI 内联: %s", out)
+	}
+	if !strings.Contains(out, `src="/static/missing.png"`) {
+		t.Errorf("找不到的图片应保留原链接: %s", out)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("正文中的脚本应被清理: %s", out)
+	}
+	if !strings.Contains(out, "<title>导出</title>") || !strings.Contains(out, "tag:x") {
+		t.Errorf("页面头部不正确: %s", out)
+	}
+}
+
+func TestWriteEPUB(t *testing.T) {
+	memoStore := newTestStore(t)
+	writeAttachment(t, memoStore, "cover.png", "PNG")
+	for _, m := range []struct {
+		at      time.Time
+		content string
+	}{
+		{time.Date(2024, 2, 3, 8, 0, 0, 0, time.Local), "二月 #旅行\n\n## 小节\n\n脚注[^1]\n\n[^1]: 说明"},
+		{time.Date(2024, 1, 5, 8, 0, 0, 0, time.Local), "一月 ![封面](/static/cover.png) ![](/static/doc.pdf)<br> #旅行 #读书"},
+	} {
+		memoStore.SetClock(func() time.Time { return m.at })
+		createMemo(t, memoStore, m.content, "")
+	}
+
+	memos, _ := memoStore.ListMemos()
+	var buf bytes.Buffer
+	if err := WriteEPUB(&buf, memoStore, memos, EPUBOptions{Title: "2024 年的笔记"}); err != nil {
+		t.Fatalf("导出 EPUB 失败: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("读取 EPUB 失败: %v", err)
+	}
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("mimetype 应为第一个不压缩的文件: %s", first.Name)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+		// 所有 XHTML 和 XML 文件都必须是格式正确的 XML
+		if ext := filepath.Ext(f.Name); ext == ".xhtml" || ext == ".opf" || ext == ".xml" {
+			dec := xml.NewDecoder(bytes.NewReader(data))
+			dec.Strict = true
+			for {
+				if _, err := dec.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s 不是有效的 XML: %v\n%s", f.Name, err, data)
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"OEBPS/2024-01.xhtml", "OEBPS/2024-02.xhtml", "OEBPS/tags.xhtml", "OEBPS/nav.xhtml", "OEBPS/images/image-1.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("缺少 %s", name)
+		}
+	}
+	if !strings.Contains(files["OEBPS/2024-01.xhtml"], `src="images/image-1.png"`) || !strings.Contains(files["OEBPS/2024-01.xhtml"], "[图片: doc.pdf]") {
+		t.Errorf("图片未正确处理: %s", files["OEBPS/2024-01.xhtml"])
+	}
+	if nav := files["OEBPS/nav.xhtml"]; strings.Index(nav, "2024年1月") > strings.Index(nav, "2024年2月") || !strings.Contains(nav, "#旅行") {
+		t.Errorf("目录不正确: %s", nav)
+	}
+	if tags := files["OEBPS/tags.xhtml"]; strings.Count(tags, "2024-01.xhtml#memo-") != 2 || strings.Count(tags, "2024-02.xhtml#memo-") != 1 {
+		t.Errorf("标签索引不正确: %s", tags)
+	}
+	if !strings.Contains(files["OEBPS/content.opf"], `media-type="image/png"`) {
+		t.Errorf("清单中缺少图片: %s", files["OEBPS/content.opf"])
+	}
+}
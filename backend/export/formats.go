@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"ramblog-app/backend/store"
+)
+
+// Format 一种导出格式
+type Format struct {
+	Name        string
+	ContentType string
+	Ext         string
+}
+
+// Formats 支持的导出格式，第一个为默认格式
+var Formats = []Format{
+	{Name: "zip", ContentType: "application/zip", Ext: ".zip"},
+	{Name: "jsonl", ContentType: "application/x-ndjson; charset=utf-8", Ext: ".jsonl"},
+	{Name: "csv", ContentType: "text/csv; charset=utf-8", Ext: ".csv"},
+	{Name: "html", ContentType: "text/html; charset=utf-8", Ext: ".html"},
+	{Name: "epub", ContentType: "application/epub+zip", Ext: ".epub"},
+}
+
+// LookupFormat 按名称查找导出格式
+func LookupFormat(name string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// FormatNames 返回所有导出格式的名称
+func FormatNames() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ErrUnknownFormat 不支持的导出格式
+var ErrUnknownFormat = fmt.Errorf("format 只能是 %s", strings.Join(FormatNames(), "、"))
+
+// Options 导出选项
+type Options struct {
+	Title  string // html 和 epub 的标题
+	Author string // epub 的作者
+	Query  string // 筛选条件。zip 格式为空时导出整个数据目录，忽略 memos
+}
+
+// Write 以指定格式导出备忘录
+func Write(w io.Writer, memoStore *store.MemoStore, format string, memos []*store.Memo, opts Options) error {
+	var err error
+	switch format {
+	case "zip":
+		if opts.Query == "" {
+			_, err = WriteArchive(w, memoStore)
+		} else {
+			_, err = WriteMemoArchive(w, memoStore, memos)
+		}
+	case "jsonl":
+		err = WriteJSONLines(w, memos)
+	case "csv":
+		err = WriteCSV(w, memos)
+	case "html":
+		err = WriteHTML(w, memoStore, memos, HTMLOptions{Title: opts.Title, Query: opts.Query})
+	case "epub":
+		err = WriteEPUB(w, memoStore, memos, EPUBOptions{Title: opts.Title, Author: opts.Author, Query: opts.Query})
+	default:
+		err = ErrUnknownFormat
+	}
+	return err
+}
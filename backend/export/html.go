@@ -0,0 +1,146 @@
+package export
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"time"
+
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// 超过该大小的图片不内联，仍保留原链接
+const maxInlineImageSize = 10 << 20
+
+// HTMLOptions 单文件 HTML 导出的选项
+type HTMLOptions struct {
+	Title string // 页面标题
+	Query string // 筛选条件，显示在页面顶部
+}
+
+// 渲染后 HTML 中引用附件的图片
+var staticImagePattern = regexp.MustCompile(`(<img[^>]*?\ssrc=")/static/([^"]+)(")`)
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`{{define "header"}}<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <style>
+    body { max-width: 720px; margin: 0 auto; padding: 2rem 1rem; font-family: -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; line-height: 1.7; color: #222; }
+    a { color: #2563eb; text-decoration: none; }
+    header { border-bottom: 2px solid #222; }
+    header h1 { margin-bottom: .2rem; font-size: 1.4rem; }
+    article { border-bottom: 1px solid #eee; padding: 1.5rem 0; }
+    article img { max-width: 100%; }
+    .meta { font-size: .85rem; color: #888; }
+    .tag { margin-right: .5rem; }
+    pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+    .heading-anchor { margin-left: .3rem; color: #ccc; }
+    {{.StyleSheet}}
+  </style>
+</head>
+<body>
+  <header>
+    <h1>{{.Title}}</h1>
+    <p class="meta">导出于 {{datetime .ExportedAt}}，共 {{.Count}} 条{{if .Query}}，筛选条件：{{.Query}}{{end}}</p>
+  </header>
+  <main>
+{{end}}
+{{define "memo"}}
+<article id="{{.ID}}">
+  <div class="meta">
+    <a href="#{{.ID}}">{{datetime .CreatedAt}}</a>
+    {{range .Tags}}<span class="tag">#{{.}}</span>{{end}}
+  </div>
+  {{if .Title}}<h2>{{.Title}}</h2>{{end}}
+  {{.HTML}}
+</article>
+{{end}}
+{{define "footer"}}
+  </main>
+</body>
+</html>
+{{end}}`))
+
+type htmlMemo struct {
+	*store.Memo
+	HTML template.HTML
+}
+
+// WriteHTML 把备忘录渲染为单个 HTML 文件，static 目录中的图片以 data URI 内联，
+// 不依赖任何外部资源。逐条渲染写入，不会把全部备忘录同时保存在内存中
+func WriteHTML(w io.Writer, memoStore *store.MemoStore, memos []*store.Memo, opts HTMLOptions) error {
+	if opts.Title == "" {
+		opts.Title = "Ramblog"
+	}
+	header := struct {
+		HTMLOptions
+		ExportedAt time.Time
+		Count      int
+		StyleSheet template.CSS
+	}{opts, time.Now(), len(memos), template.CSS(render.StyleSheet())}
+	if err := htmlTemplate.ExecuteTemplate(w, "header", header); err != nil {
+		return err
+	}
+
+	for _, memo := range memos {
+		rendered, err := render.HTML(memo.Content)
+		if err != nil {
+			return err
+		}
+		rendered = inlineImages(memoStore, rendered)
+		if err := htmlTemplate.ExecuteTemplate(w, "memo", htmlMemo{Memo: memo, HTML: template.HTML(rendered)}); err != nil {
+			return err
+		}
+	}
+	return htmlTemplate.ExecuteTemplate(w, "footer", nil)
+}
+
+// 把引用附件的图片替换为 data URI，读取失败的图片保留原链接
+func inlineImages(memoStore *store.MemoStore, rendered string) string {
+	return staticImagePattern.ReplaceAllStringFunc(rendered, func(match string) string {
+		parts := staticImagePattern.FindStringSubmatch(match)
+		name := html.UnescapeString(parts[2])
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		uri, err := dataURI(memoStore, name)
+		if err != nil {
+			return match
+		}
+		return parts[1] + uri + parts[3]
+	})
+}
+
+func dataURI(memoStore *store.MemoStore, name string) (string, error) {
+	f, err := memoStore.OpenAttachment(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxInlineImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxInlineImageSize {
+		return "", fmt.Errorf("附件 %s 过大", name)
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
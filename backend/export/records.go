@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// WriteJSONLines 每行写入一条完整的备忘录 JSON，字段与 /api/memos 返回的相同
+func WriteJSONLines(w io.Writer, memos []*store.Memo) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, memo := range memos {
+		if err := enc.Encode(memo); err != nil {
+			return fmt.Errorf("写入备忘录 %s 失败: %w", memo.ID, err)
+		}
+	}
+	return nil
+}
+
+// CSVHeader CSV 导出的列
+var CSVHeader = []string{
+	"id", "title", "tags", "visibility", "parent", "pinned", "archived",
+	"createdAt", "updatedAt", "characters", "attachments",
+}
+
+// WriteCSV 写入备忘录的元数据，不包含正文。多个标签以空格分隔
+func WriteCSV(w io.Writer, memos []*store.Memo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, memo := range memos {
+		visibility := memo.Visibility
+		if visibility == "" {
+			visibility = "private"
+		}
+		record := []string{
+			memo.ID,
+			memo.Title,
+			strings.Join(memo.Tags, " "),
+			visibility,
+			memo.ParentID(),
+			strconv.FormatBool(memo.IsPinned()),
+			strconv.FormatBool(memo.IsArchived()),
+			memo.CreatedAt.Format(time.RFC3339),
+			memo.UpdatedAt.Format(time.RFC3339),
+			strconv.Itoa(len([]rune(memo.Content))),
+			strconv.Itoa(len(store.ReferencedAttachments(memo.Content))),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,281 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"ramblog-app/backend/store"
+)
+
+// 恢复时遇到同名备忘录或附件的处理方式
+const (
+	ConflictSkip      = "skip"      // 保留现有的文件
+	ConflictOverwrite = "overwrite" // 用压缩包中的文件覆盖
+	ConflictRename    = "rename"    // 以新的名称保存压缩包中的文件
+)
+
+// 每个文件的恢复结果
+const (
+	RestoreCreated     = "created"
+	RestoreOverwritten = "overwritten"
+	RestoreRenamed     = "renamed"
+	RestoreSkipped     = "skipped"
+	RestoreUnchanged   = "unchanged" // 已存在内容相同的文件
+)
+
+// RestoreEntry 一个文件的恢复结果
+type RestoreEntry struct {
+	Path      string `json:"path"`
+	Action    string `json:"action"`
+	RenamedTo string `json:"renamedTo,omitempty"`
+}
+
+// RestoreReport 恢复压缩包的结果
+type RestoreReport struct {
+	Conflict string          `json:"conflict"`
+	Counts   map[string]int  `json:"counts"`
+	Files    []*RestoreEntry `json:"files"`
+}
+
+func (r *RestoreReport) add(entry *RestoreEntry) {
+	r.Files = append(r.Files, entry)
+	r.Counts[entry.Action]++
+}
+
+var (
+	// ErrInvalidArchive 压缩包不是有效的导出或校验失败
+	ErrInvalidArchive = errors.New("无效的导出压缩包")
+	// ErrInvalidConflict 未知的冲突处理方式
+	ErrInvalidConflict = errors.New("conflict 只能是 skip、overwrite 或 rename")
+)
+
+// RestoreArchive 把 WriteArchive 生成的压缩包恢复到数据目录。
+// 先按 manifest.json 校验全部文件，校验通过后才开始写入；
+// 已存在内容相同的文件时跳过，内容不同时按 conflict 处理。
+// 附件以新名称保存时，压缩包中引用它的备忘录会随之修改
+func RestoreArchive(r io.ReaderAt, size int64, memoStore *store.MemoStore, conflict string) (*RestoreReport, error) {
+	switch conflict {
+	case "":
+		conflict = ConflictSkip
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return nil, ErrInvalidConflict
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	memos, attachments, err := verifyArchive(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{Conflict: conflict, Counts: make(map[string]int), Files: []*RestoreEntry{}}
+
+	// 先恢复附件，得到需要改名的附件
+	renamed := make(map[string]string)
+	for _, f := range attachments {
+		name := strings.TrimPrefix(f.Name, "static/")
+		entry := &RestoreEntry{Path: f.Name, Action: RestoreCreated}
+		if memoStore.AttachmentExists(name) {
+			same, err := sameAttachment(memoStore, name, f)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case same:
+				entry.Action = RestoreUnchanged
+			case conflict == ConflictSkip:
+				entry.Action = RestoreSkipped
+			case conflict == ConflictOverwrite:
+				entry.Action = RestoreOverwritten
+			default:
+				entry.Action = RestoreRenamed
+				entry.RenamedTo = renameAttachment(memoStore, name, f)
+				renamed[name] = entry.RenamedTo
+			}
+		}
+
+		if entry.Action != RestoreUnchanged && entry.Action != RestoreSkipped {
+			target := name
+			if entry.RenamedTo != "" {
+				target = entry.RenamedTo
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = memoStore.RestoreAttachment(target, rc, f.Modified, entry.Action == RestoreOverwritten)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		report.add(entry)
+	}
+
+	for _, f := range memos {
+		id := strings.TrimSuffix(strings.TrimPrefix(f.Name, "memos/"), ".md")
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for old, name := range renamed {
+			data = bytes.ReplaceAll(data, []byte("/static/"+old), []byte("/static/"+name))
+		}
+
+		entry := &RestoreEntry{Path: f.Name, Action: RestoreCreated}
+		if existing, err := memoStore.ReadMemoFile(id); err == nil {
+			switch {
+			case bytes.Equal(existing, data):
+				entry.Action = RestoreUnchanged
+			case conflict == ConflictSkip:
+				entry.Action = RestoreSkipped
+			case conflict == ConflictOverwrite:
+				entry.Action = RestoreOverwritten
+			default:
+				entry.Action = RestoreRenamed
+			}
+		}
+
+		switch entry.Action {
+		case RestoreCreated, RestoreOverwritten:
+			err = memoStore.RestoreMemoFile(id, data, f.Modified, entry.Action == RestoreOverwritten)
+		case RestoreRenamed:
+			entry.RenamedTo, err = memoStore.RestoreMemoAsNew(id, data, f.Modified)
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.add(entry)
+	}
+	return report, nil
+}
+
+// 读取并校验清单，返回按名称排序的备忘录和附件文件
+func verifyArchive(zr *zip.Reader) (memos, attachments []*zip.File, err error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files[f.Name] = f
+		}
+	}
+	manifestFile, ok := files[ManifestName]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: 缺少 %s", ErrInvalidArchive, ManifestName)
+	}
+	data, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("%w: 解析清单失败: %v", ErrInvalidArchive, err)
+	}
+	if manifest.Format != ManifestFormat {
+		return nil, nil, fmt.Errorf("%w: 未知的格式 %q", ErrInvalidArchive, manifest.Format)
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		listed[mf.Path] = true
+		f, ok := files[mf.Path]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: 缺少文件 %s", ErrInvalidArchive, mf.Path)
+		}
+		if err := verifyFile(f, mf); err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(mf.Path, "memos/") && path.Ext(mf.Path) == ".md" && path.Dir(mf.Path) == "memos":
+			memos = append(memos, f)
+		case strings.HasPrefix(mf.Path, "static/"):
+			attachments = append(attachments, f)
+		}
+	}
+	for name := range files {
+		if name != ManifestName && !listed[name] {
+			return nil, nil, fmt.Errorf("%w: %s 不在清单中", ErrInvalidArchive, name)
+		}
+	}
+
+	sort.Slice(memos, func(i, j int) bool { return memos[i].Name < memos[j].Name })
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].Name < attachments[j].Name })
+	return memos, attachments, nil
+}
+
+// 校验文件的大小和 SHA-256
+func verifyFile(f *zip.File, mf ManifestFile) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: 无法读取 %s: %v", ErrInvalidArchive, f.Name, err)
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, rc)
+	if err != nil {
+		return fmt.Errorf("%w: 无法读取 %s: %v", ErrInvalidArchive, f.Name, err)
+	}
+	if size != mf.Size || hex.EncodeToString(hash.Sum(nil)) != mf.SHA256 {
+		return fmt.Errorf("%w: %s 校验失败", ErrInvalidArchive, f.Name)
+	}
+	return nil
+}
+
+// 读取压缩包中的小文件
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// 比较现有附件与压缩包中的文件是否相同
+func sameAttachment(memoStore *store.MemoStore, name string, f *zip.File) (bool, error) {
+	existing, err := memoStore.OpenAttachment(name)
+	if err != nil {
+		return false, err
+	}
+	defer existing.Close()
+	a := sha256.New()
+	if _, err := io.Copy(a, existing); err != nil {
+		return false, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	b := sha256.New()
+	if _, err := io.Copy(b, rc); err != nil {
+		return false, err
+	}
+	return bytes.Equal(a.Sum(nil), b.Sum(nil)), nil
+}
+
+// 为冲突的附件生成不重复的新名称：在扩展名前加上内容哈希
+func renameAttachment(memoStore *store.MemoStore, name string, f *zip.File) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	suffix := fmt.Sprintf("%08x", f.CRC32)
+	candidate := base + "-" + suffix + ext
+	for i := 2; memoStore.AttachmentExists(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%s-%d%s", base, suffix, i, ext)
+	}
+	return candidate
+}
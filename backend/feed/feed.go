@@ -0,0 +1,307 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// DefaultLimit 订阅源默认包含的最大条目数
+const DefaultLimit = 50
+
+// Options 描述生成订阅源所需的站点信息
+type Options struct {
+	Title    string // 站点标题
+	BaseURL  string // 站点根地址，用于生成绝对链接
+	FeedURL  string // 订阅源自身的地址
+	Tag      string // 非空时表示按标签过滤的订阅源，包含子标签；需为标签名而不是别名
+	Limit    int    // 最大条目数，0 表示使用 DefaultLimit
+	MemoURL  func(id string) string
+	Language string
+}
+
+// 返回备忘录的访问地址，默认为服务器上的公开备忘录页面 /memos/<id>
+func (o Options) memoURL(id string) string {
+	if o.MemoURL != nil {
+		return o.MemoURL(id)
+	}
+	return strings.TrimSuffix(o.BaseURL, "/") + "/memos/" + id
+}
+
+// 返回订阅源标题，标签订阅源附带标签名
+func (o Options) title() string {
+	title := o.Title
+	if title == "" {
+		title = "Ramblog"
+	}
+	if o.Tag != "" {
+		title = fmt.Sprintf("%s #%s", title, o.Tag)
+	}
+	return title
+}
+
+// Select 挑选出现在订阅源中的备忘录：仅公开备忘录，可按标签（含子标签）过滤，按创建时间从新到旧排序
+func Select(memos []*store.Memo, opts Options) []*store.Memo {
+	selected := make([]*store.Memo, 0, len(memos))
+	for _, memo := range memos {
+		if !memo.IsPublic() {
+			continue
+		}
+		if opts.Tag != "" && !store.HasTagOrChild(memo.Tags, opts.Tag) {
+			continue
+		}
+		selected = append(selected, memo)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].CreatedAt.After(selected[j].CreatedAt)
+	})
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
+// LastModified 返回备忘录中最近的更新时间
+func LastModified(memos []*store.Memo) time.Time {
+	var latest time.Time
+	for _, memo := range memos {
+		if memo.UpdatedAt.After(latest) {
+			latest = memo.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// EntryTitle 返回条目标题，备忘录无标题时取正文首行
+func EntryTitle(memo *store.Memo) string {
+	if memo.Title != "" {
+		return memo.Title
+	}
+	line := strings.TrimSpace(strings.SplitN(memo.Content, "\n", 2)[0])
+	line = strings.TrimLeft(line, "#> -*")
+	line = strings.TrimSpace(line)
+	const maxRunes = 40
+	if utf8.RuneCountInString(line) > maxRunes {
+		line = string([]rune(line)[:maxRunes]) + "…"
+	}
+	if line == "" {
+		return memo.ID
+	}
+	return line
+}
+
+// Atom 相关结构
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom 生成 Atom 1.0 订阅源
+func Atom(memos []*store.Memo, opts Options) ([]byte, error) {
+	feed := atomFeed{
+		Title:   opts.title(),
+		ID:      opts.FeedURL,
+		Updated: LastModified(memos).UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: opts.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: strings.TrimSuffix(opts.BaseURL, "/") + "/"},
+		},
+	}
+
+	renderer := render.New(render.Options{BaseURL: opts.BaseURL})
+	for _, memo := range memos {
+		html, err := renderer.Render(memo.Content)
+		if err != nil {
+			return nil, fmt.Errorf("渲染备忘录 %s 失败: %w", memo.ID, err)
+		}
+		url := opts.memoURL(memo.ID)
+		entry := atomEntry{
+			Title:     EntryTitle(memo),
+			ID:        url,
+			Link:      atomLink{Href: url},
+			Published: memo.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   memo.UpdatedAt.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Body: html},
+		}
+		for _, tag := range memo.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
+
+// RSS 相关结构
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description rssCDATA `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+// RSS 生成 RSS 2.0 订阅源
+func RSS(memos []*store.Memo, opts Options) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         opts.title(),
+			Link:          strings.TrimSuffix(opts.BaseURL, "/") + "/",
+			Description:   opts.title(),
+			Language:      opts.Language,
+			LastBuildDate: LastModified(memos).UTC().Format(time.RFC1123Z),
+			AtomLink:      atomLink{Href: opts.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+
+	renderer := render.New(render.Options{BaseURL: opts.BaseURL})
+	for _, memo := range memos {
+		html, err := renderer.Render(memo.Content)
+		if err != nil {
+			return nil, fmt.Errorf("渲染备忘录 %s 失败: %w", memo.ID, err)
+		}
+		url := opts.memoURL(memo.ID)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       EntryTitle(memo),
+			Link:        url,
+			GUID:        rssGUID{IsPermaLink: true, Value: url},
+			PubDate:     memo.CreatedAt.UTC().Format(time.RFC1123Z),
+			Categories:  memo.Tags,
+			Description: rssCDATA{Value: html},
+		})
+	}
+
+	return marshalXML(feed)
+}
+
+// JSON Feed 1.1 相关结构
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url"`
+	FeedURL     string     `json:"feed_url"`
+	Language    string     `json:"language,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	ContentText   string   `json:"content_text"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// JSON 生成 JSON Feed 1.1 订阅源
+func JSON(memos []*store.Memo, opts Options) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       opts.title(),
+		HomePageURL: strings.TrimSuffix(opts.BaseURL, "/") + "/",
+		FeedURL:     opts.FeedURL,
+		Language:    opts.Language,
+		Items:       []jsonItem{},
+	}
+
+	renderer := render.New(render.Options{BaseURL: opts.BaseURL})
+	for _, memo := range memos {
+		html, err := renderer.Render(memo.Content)
+		if err != nil {
+			return nil, fmt.Errorf("渲染备忘录 %s 失败: %w", memo.ID, err)
+		}
+		feed.Items = append(feed.Items, jsonItem{
+			ID:            memo.ID,
+			URL:           opts.memoURL(memo.ID),
+			Title:         EntryTitle(memo),
+			ContentHTML:   html,
+			ContentText:   memo.Content,
+			DatePublished: memo.CreatedAt.UTC().Format(time.RFC3339),
+			DateModified:  memo.UpdatedAt.UTC().Format(time.RFC3339),
+			Tags:          memo.Tags,
+		})
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化订阅源失败: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
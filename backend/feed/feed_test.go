@@ -0,0 +1,194 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+var testTime = time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func testMemos() []*store.Memo {
+	memo := func(id string, hours int, visibility string, tags ...string) *store.Memo {
+		at := testTime.Add(time.Duration(hours) * time.Hour)
+		return &store.Memo{
+			ID:         id,
+			Content:    "正文 " + id + " ![图](/static/" + id + ".png)",
+			Tags:       tags,
+			Visibility: visibility,
+			CreatedAt:  at,
+			UpdatedAt:  at.Add(time.Minute),
+		}
+	}
+	return []*store.Memo{
+		memo("2024-03-02-1", 0, store.VisibilityPublic, "读书"),
+		memo("2024-03-02-2", 1, store.VisibilityPublic, "读书/小说"),
+		memo("2024-03-02-3", 2, store.VisibilityPublic, "读书会"),
+		memo("2024-03-02-4", 3, store.VisibilityPrivate, "读书"),
+		memo("2024-03-02-5", 4, store.VisibilityPublic),
+	}
+}
+
+func ids(memos []*store.Memo) []string {
+	result := []string{}
+	for _, memo := range memos {
+		result = append(result, memo.ID)
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{}, []string{"2024-03-02-5", "2024-03-02-3", "2024-03-02-2", "2024-03-02-1"}},
+		// 标签订阅源包含子标签，但不包含前缀相同的其他标签
+		{Options{Tag: "读书"}, []string{"2024-03-02-2", "2024-03-02-1"}},
+		{Options{Tag: "读书/小说"}, []string{"2024-03-02-2"}},
+		{Options{Tag: "不存在"}, []string{}},
+		{Options{Limit: 2}, []string{"2024-03-02-5", "2024-03-02-3"}},
+	}
+	for _, tt := range tests {
+		if got := ids(Select(testMemos(), tt.opts)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%+v): 期望 %v, 实际 %v", tt.opts, tt.want, got)
+		}
+	}
+}
+
+func TestEntryTitle(t *testing.T) {
+	long := strings.Repeat("长", 50)
+	tests := []struct {
+		memo *store.Memo
+		want string
+	}{
+		{&store.Memo{ID: "1", Title: "标题", Content: "正文"}, "标题"},
+		{&store.Memo{ID: "1", Content: "# 第一行\n第二行"}, "第一行"},
+		{&store.Memo{ID: "1", Content: long}, strings.Repeat("长", 40) + "…"},
+		{&store.Memo{ID: "2024-03-02-1", Content: "\n"}, "2024-03-02-1"},
+	}
+	for _, tt := range tests {
+		if got := EntryTitle(tt.memo); got != tt.want {
+			t.Errorf("EntryTitle(%q): 期望 %q, 实际 %q", tt.memo.Content, tt.want, got)
+		}
+	}
+}
+
+func testOptions() Options {
+	return Options{
+		Title:   "测试",
+		BaseURL: "https://example.com/",
+		FeedURL: "https://example.com/tags/%E8%AF%BB%E4%B9%A6/feed.xml",
+		Tag:     "读书",
+	}
+}
+
+func TestAtom(t *testing.T) {
+	opts := testOptions()
+	data, err := Atom(Select(testMemos(), opts), opts)
+	if err != nil {
+		t.Fatalf("生成 Atom 失败: %v", err)
+	}
+	var parsed atomFeed
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("解析 Atom 失败: %v\n%s", err, data)
+	}
+	if parsed.Title != "测试 #读书" || parsed.ID != opts.FeedURL || parsed.Updated != "2024-03-02T10:01:00Z" {
+		t.Errorf("订阅源信息不正确: %+v", parsed)
+	}
+	if len(parsed.Entries) != 2 {
+		t.Fatalf("应有 2 个条目, 实际 %d", len(parsed.Entries))
+	}
+	entry := parsed.Entries[0]
+	if entry.ID != "https://example.com/memos/2024-03-02-2" || entry.Link.Href != entry.ID {
+		t.Errorf("默认的条目地址应为 /memos/<id>: %+v", entry)
+	}
+	if entry.Published != "2024-03-02T10:00:00Z" || entry.Updated != "2024-03-02T10:01:00Z" {
+		t.Errorf("条目时间不正确: %+v", entry)
+	}
+	if len(entry.Categories) != 1 || entry.Categories[0].Term != "读书/小说" {
+		t.Errorf("条目分类不正确: %+v", entry.Categories)
+	}
+	if !strings.Contains(entry.Content.Body, `src="https://example.com/static/2024-03-02-2.png"`) {
+		t.Errorf("附件地址应为绝对地址: %s", entry.Content.Body)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	opts := testOptions()
+	opts.MemoURL = func(id string) string { return "https://example.com/memos/" + id + ".html" }
+	data, err := RSS(Select(testMemos(), opts), opts)
+	if err != nil {
+		t.Fatalf("生成 RSS 失败: %v", err)
+	}
+	var parsed struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Link        string   `xml:"link"`
+				GUID        string   `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Categories  []string `xml:"category"`
+				Description string   `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("解析 RSS 失败: %v\n%s", err, data)
+	}
+	if parsed.Version != "2.0" || parsed.Channel.Title != "测试 #读书" || parsed.Channel.LastBuildDate != "Sat, 02 Mar 2024 10:01:00 +0000" {
+		t.Errorf("频道信息不正确: %+v", parsed.Channel)
+	}
+	if len(parsed.Channel.Items) != 2 {
+		t.Fatalf("应有 2 个条目, 实际 %d", len(parsed.Channel.Items))
+	}
+	item := parsed.Channel.Items[1]
+	if item.Link != "https://example.com/memos/2024-03-02-1.html" || item.GUID != item.Link {
+		t.Errorf("应使用 MemoURL 生成条目地址: %+v", item)
+	}
+	if item.PubDate != "Sat, 02 Mar 2024 09:00:00 +0000" || !reflect.DeepEqual(item.Categories, []string{"读书"}) {
+		t.Errorf("条目不正确: %+v", item)
+	}
+	if !strings.Contains(item.Description, "<p>正文 2024-03-02-1") {
+		t.Errorf("条目内容应为渲染后的 HTML: %s", item.Description)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	opts := testOptions()
+	opts.Tag = ""
+	data, err := JSON(Select(testMemos(), opts), opts)
+	if err != nil {
+		t.Fatalf("生成 JSON Feed 失败: %v", err)
+	}
+	var parsed jsonFeed
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("解析 JSON Feed 失败: %v", err)
+	}
+	if parsed.Version != "https://jsonfeed.org/version/1.1" || parsed.Title != "测试" || parsed.HomePageURL != "https://example.com/" {
+		t.Errorf("订阅源信息不正确: %+v", parsed)
+	}
+	if len(parsed.Items) != 4 {
+		t.Fatalf("应有 4 个条目, 实际 %d", len(parsed.Items))
+	}
+	item := parsed.Items[0]
+	if item.ID != "2024-03-02-5" || item.URL != "https://example.com/memos/2024-03-02-5" || item.Tags != nil {
+		t.Errorf("条目不正确: %+v", item)
+	}
+	if item.ContentText != testMemos()[4].Content || item.DateModified != "2024-03-02T13:01:00Z" {
+		t.Errorf("条目内容不正确: %+v", item)
+	}
+
+	// 没有条目时 items 为空数组而不是 null
+	empty, _ := JSON(nil, opts)
+	if !strings.Contains(string(empty), `"items": []`) {
+		t.Errorf("空订阅源的 items 应为空数组: %s", empty)
+	}
+}
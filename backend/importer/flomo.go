@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// flomo 导出的时间格式（本地时间）
+const flomoTimeLayout = "2006-01-02 15:04:05"
+
+// flomoImporter 导入 flomo 的 HTML 导出。导出是一个目录，包含一个 HTML 文件和存放附件的 file 目录，
+// 每条备忘录是一个 div.memo，其中 div.time 为创建时间，div.content 为正文，div.files 为附件
+type flomoImporter struct{}
+
+func (flomoImporter) Name() string { return "flomo" }
+
+func (flomoImporter) Detect(path string) bool {
+	file, err := flomoHTMLFile(path)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(file)
+	return err == nil && strings.Contains(string(data), `class="memo"`)
+}
+
+// 找到导出中的 HTML 文件，path 可以是 HTML 文件本身或导出目录
+func flomoHTMLFile(path string) (string, error) {
+	if !isDir(path) {
+		if !hasExt(path, ".html", ".htm") {
+			return "", fmt.Errorf("不是 HTML 文件: %s", path)
+		}
+		return path, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(path, "*.html"))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("目录中没有 HTML 文件: %s", path)
+	}
+	return matches[0], nil
+}
+
+func (flomoImporter) Read(path string) ([]*Item, error) {
+	file, err := flomoHTMLFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取 flomo 导出失败: %w", err)
+	}
+	defer f.Close()
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("解析 flomo 导出失败: %w", err)
+	}
+
+	baseDir := filepath.Dir(file)
+	var items []*Item
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Div && hasClass(n, "memo") {
+			item, err := readFlomoMemo(n, baseDir)
+			if err == nil {
+				item.Source = fmt.Sprintf("%s#%d", filepath.Base(file), len(items)+1)
+				items = append(items, item)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s 中没有找到 flomo 备忘录", file)
+	}
+	return items, nil
+}
+
+// 解析一个 div.memo
+func readFlomoMemo(memo *html.Node, baseDir string) (*Item, error) {
+	item := &Item{}
+	var files []string
+	for c := memo.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch {
+		case hasClass(c, "time"):
+			t, err := time.ParseInLocation(flomoTimeLayout, strings.TrimSpace(textContent(c)), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("无效的时间: %w", err)
+			}
+			item.CreatedAt = t
+			item.UpdatedAt = t
+		case hasClass(c, "content"):
+			item.Content = htmlToMarkdown(c)
+		case hasClass(c, "files"):
+			files = append(files, fileLinks(c)...)
+		}
+	}
+	if item.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("缺少时间")
+	}
+
+	// 附件附加在正文末尾
+	var refs []string
+	for _, src := range files {
+		if u, err := url.Parse(src); err == nil && u.Scheme != "" {
+			// 远程地址保留原样
+			refs = append(refs, "![]("+src+")")
+			continue
+		}
+		local := src
+		if unescaped, err := url.PathUnescape(src); err == nil {
+			local = unescaped
+		}
+		att := fileAttachment(src, baseDir, filepath.Join(baseDir, filepath.FromSlash(local)), false)
+		if !isImage(src) {
+			refs = append(refs, "["+att.Name+"]("+src+")")
+		} else {
+			refs = append(refs, "![]("+src+")")
+		}
+		item.Attachments = append(item.Attachments, att)
+	}
+	if len(refs) > 0 {
+		item.Content = strings.TrimSpace(item.Content + "\n\n" + strings.Join(refs, "\n"))
+	}
+	return item, nil
+}
+
+// 收集 div.files 中的文件地址
+func fileLinks(n *html.Node) []string {
+	var links []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Img, atom.Audio, atom.Video, atom.Source:
+				if src := attr(n, "src"); src != "" {
+					links = append(links, src)
+				}
+			case atom.A:
+				if href := attr(n, "href"); href != "" {
+					links = append(links, href)
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return links
+}
+
+// 元素中的全部文字
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
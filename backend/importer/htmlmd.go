@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlToMarkdown 把导出文件中的 HTML 片段转换为 Markdown，只处理笔记中常见的元素
+func htmlToMarkdown(n *html.Node) string {
+	var w mdWriter
+	w.children(n)
+	return strings.TrimSpace(collapseBlankLines(w.String()))
+}
+
+// 连续的空白在 HTML 中显示为一个空格
+var spacePattern = regexp.MustCompile(`\s+`)
+
+type mdWriter struct {
+	strings.Builder
+	lists []listState // 嵌套的列表
+	pre   bool
+}
+
+type listState struct {
+	ordered bool
+	index   int
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// 开始新的块，确保与前面的内容之间有空行
+func (w *mdWriter) block() {
+	if w.Len() > 0 && !strings.HasSuffix(w.String(), "\n\n") {
+		if strings.HasSuffix(w.String(), "\n") {
+			w.WriteString("\n")
+		} else {
+			w.WriteString("\n\n")
+		}
+	}
+}
+
+// 包裹行内内容，内容为空时不输出标记
+func (w *mdWriter) wrap(n *html.Node, mark string) {
+	var inner mdWriter
+	inner.pre = w.pre
+	inner.children(n)
+	if text := strings.TrimSpace(inner.String()); text != "" {
+		w.WriteString(mark + text + mark)
+	}
+}
+
+func (w *mdWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre {
+			w.WriteString(n.Data)
+			return
+		}
+		text := spacePattern.ReplaceAllString(n.Data, " ")
+		if w.Len() == 0 || strings.HasSuffix(w.String(), "\n") || strings.HasSuffix(w.String(), " ") {
+			text = strings.TrimLeft(text, " ")
+		}
+		w.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.WriteString("\n")
+	case atom.P, atom.Div:
+		w.block()
+		w.children(n)
+		w.block()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block()
+		w.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.block()
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "*")
+	case atom.S, atom.Del:
+		w.wrap(n, "~~")
+	case atom.Code:
+		if w.pre {
+			w.children(n)
+		} else {
+			w.wrap(n, "`")
+		}
+	case atom.Pre:
+		w.block()
+		w.WriteString("```\n")
+		w.pre = true
+		w.children(n)
+		w.pre = false
+		if !strings.HasSuffix(w.String(), "\n") {
+			w.WriteString("\n")
+		}
+		w.WriteString("```")
+		w.block()
+	case atom.Blockquote:
+		w.block()
+		var inner mdWriter
+		inner.children(n)
+		for _, line := range strings.Split(strings.TrimSpace(collapseBlankLines(inner.String())), "\n") {
+			w.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		w.block()
+	case atom.Ul, atom.Ol:
+		if len(w.lists) == 0 {
+			w.block()
+		} else if !strings.HasSuffix(w.String(), "\n") {
+			w.WriteString("\n")
+		}
+		w.lists = append(w.lists, listState{ordered: n.DataAtom == atom.Ol})
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.block()
+		}
+	case atom.Li:
+		if !strings.HasSuffix(w.String(), "\n") && w.Len() > 0 {
+			w.WriteString("\n")
+		}
+		depth := len(w.lists)
+		marker := "- "
+		if depth > 0 {
+			list := &w.lists[depth-1]
+			list.index++
+			if list.ordered {
+				marker = strconv.Itoa(list.index) + ". "
+			}
+			w.WriteString(strings.Repeat("  ", depth-1))
+		}
+		w.WriteString(marker)
+		var inner mdWriter
+		inner.lists = w.lists
+		inner.children(n)
+		w.WriteString(strings.TrimSpace(collapseBlankLines(inner.String())))
+		w.WriteString("\n")
+	case atom.A:
+		href := attr(n, "href")
+		var inner mdWriter
+		inner.children(n)
+		text := strings.TrimSpace(inner.String())
+		switch {
+		case href == "":
+			w.WriteString(text)
+		case text == "" || text == href:
+			w.WriteString("<" + href + ">")
+		default:
+			w.WriteString("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		if src := attr(n, "src"); src != "" {
+			w.WriteString("![" + attr(n, "alt") + "](" + src + ")")
+		}
+	case atom.Script, atom.Style, atom.Head:
+	default:
+		w.children(n)
+	}
+}
+
+// 读取元素属性
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// 判断元素是否带有指定的 class
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// 合并连续的空行，去掉行尾空白
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,372 @@
+// Package importer 从其他笔记工具的导出文件中导入备忘录
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// Item 从导出文件中读出的一条备忘录
+type Item struct {
+	Source      string // 在来源中的标识，如文件路径或原ID，用于报告
+	Title       string
+	Content     string
+	Tags        []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Visibility  string
+	Pinned      bool
+	Archived    bool
+	Attachments []*Attachment
+}
+
+// Attachment 备忘录正文中引用的、需要迁移到 static 目录的文件
+type Attachment struct {
+	Ref   string // 正文中要替换的原文
+	Embed bool   // 为 true 时整个 Ref 替换为 Markdown 图片或链接，否则只替换为新地址
+	Name  string // 原文件名
+	Open  func() ([]byte, error)
+}
+
+// Importer 一种导出格式的导入器
+type Importer interface {
+	// Name 格式名称，用于 --from 参数
+	Name() string
+	// Detect 判断路径（文件或目录）是否像是这种格式的导出
+	Detect(path string) bool
+	// Read 读出全部备忘录
+	Read(path string) ([]*Item, error)
+}
+
+// 已注册的导入器，按注册顺序自动识别
+var importers []Importer
+
+// Register 注册导入器
+func Register(imp Importer) {
+	importers = append(importers, imp)
+}
+
+// Lookup 按名称查找导入器
+func Lookup(name string) (Importer, error) {
+	for _, imp := range importers {
+		if imp.Name() == name {
+			return imp, nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的导入格式: %s（可用: %s）", name, strings.Join(Names(), ", "))
+}
+
+// Names 返回所有导入器的名称
+func Names() []string {
+	names := make([]string, 0, len(importers))
+	for _, imp := range importers {
+		names = append(names, imp.Name())
+	}
+	return names
+}
+
+// Detect 自动识别路径的导出格式
+func Detect(path string) (Importer, error) {
+	for _, imp := range importers {
+		if imp.Detect(path) {
+			return imp, nil
+		}
+	}
+	return nil, fmt.Errorf("无法识别 %s 的格式，请指定导入格式（可用: %s）", path, strings.Join(Names(), ", "))
+}
+
+func init() {
+	Register(flomoImporter{})
+	Register(usememosImporter{})
+	Register(localStorageImporter{})
+	Register(obsidianImporter{})
+}
+
+// 导入时对每条备忘录的处理
+const (
+	ActionCreate = "create"
+	ActionSkip   = "skip"
+)
+
+// MemoReport 一条备忘录的导入结果
+type MemoReport struct {
+	Source      string    `json:"source"`
+	ID          string    `json:"id,omitempty"` // 生成的ID，dry run 时为将要使用的ID
+	Title       string    `json:"title,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Tags        []string  `json:"tags"`
+	Attachments int       `json:"attachments"`
+	Action      string    `json:"action"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// Report 一次导入的结果
+type Report struct {
+	Format             string        `json:"format"`
+	DryRun             bool          `json:"dryRun"`
+	Created            int           `json:"created"`
+	Skipped            int           `json:"skipped"`
+	Attachments        int           `json:"attachments"` // 新写入的附件数
+	MissingAttachments []string      `json:"missingAttachments"`
+	Memos              []*MemoReport `json:"memos"`
+}
+
+// Options 导入选项
+type Options struct {
+	DryRun bool // 只生成报告，不写入任何文件
+}
+
+// Run 用导入器读取 path 并写入存储。已存在创建时间和正文都相同的备忘录时跳过，
+// 因此重复导入同一份导出是安全的
+func Run(memoStore *store.MemoStore, imp Importer, path string, opts Options) (*Report, error) {
+	items, err := imp.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+
+	existing, err := memoStore.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, memo := range existing {
+		seen[dedupKey(memo.CreatedAt, memo.Content)] = true
+	}
+
+	report := &Report{
+		Format:             imp.Name(),
+		DryRun:             opts.DryRun,
+		MissingAttachments: []string{},
+		Memos:              []*MemoReport{},
+	}
+	pending := make(map[string]*Attachment) // 需要写入的附件的新文件名 -> 附件
+	var memos []*store.Memo
+	var created []*MemoReport
+	var attachments [][]string // 每条要创建的备忘录引用的附件
+	for _, item := range items {
+		content, names := migrateAttachments(memoStore, item, pending, report)
+
+		memo := &store.Memo{
+			Title:      strings.TrimSpace(item.Title),
+			Content:    strings.TrimSpace(content),
+			Tags:       item.Tags,
+			CreatedAt:  item.CreatedAt,
+			UpdatedAt:  item.UpdatedAt,
+			Visibility: item.Visibility,
+		}
+		if item.Pinned {
+			memo.Pinned = &item.Pinned
+		}
+		if item.Archived {
+			memo.Archived = &item.Archived
+		}
+
+		entry := &MemoReport{
+			Source:      item.Source,
+			Title:       memo.Title,
+			CreatedAt:   memo.CreatedAt,
+			Tags:        memo.Tags,
+			Attachments: len(names),
+			Action:      ActionCreate,
+		}
+		report.Memos = append(report.Memos, entry)
+
+		key := dedupKey(memo.CreatedAt, memo.Content)
+		if memo.Content == "" && memo.Title == "" {
+			entry.Action, entry.Reason = ActionSkip, "内容为空"
+		} else if seen[key] {
+			entry.Action, entry.Reason = ActionSkip, "已存在相同的备忘录"
+		}
+		if entry.Action == ActionSkip {
+			report.Skipped++
+			continue
+		}
+		seen[key] = true
+		memos = append(memos, memo)
+		created = append(created, entry)
+		attachments = append(attachments, names)
+	}
+
+	if err := memoStore.ImportMemos(memos, opts.DryRun); err != nil {
+		return nil, err
+	}
+	// 备忘录写入成功后再写入它们引用的附件，导入失败时不会留下多余的文件
+	for _, names := range attachments {
+		for _, name := range names {
+			att, ok := pending[name]
+			if !ok {
+				continue
+			}
+			delete(pending, name)
+			report.Attachments++
+			if opts.DryRun {
+				continue
+			}
+			data, err := att.Open()
+			if err == nil {
+				err = memoStore.CreateAttachment(&store.Attachment{ID: name, Data: data})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("写入附件 %s 失败: %w", att.Name, err)
+			}
+		}
+	}
+	for i, memo := range memos {
+		created[i].ID = memo.ID
+		created[i].CreatedAt = memo.CreatedAt
+		created[i].Tags = memo.Tags
+	}
+	report.Created = len(memos)
+	return report, nil
+}
+
+// Import 导入 path 指向的文件、目录或 ZIP 压缩包，format 为空时自动识别格式
+func Import(memoStore *store.MemoStore, format, path string, opts Options) (*Report, error) {
+	if !isDir(path) && hasExt(path, ".zip") {
+		dir, err := os.MkdirTemp("", "ramblog-import")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		if path, err = Unzip(path, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var imp Importer
+	var err error
+	if format == "" {
+		imp, err = Detect(path)
+	} else {
+		imp, err = Lookup(format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Run(memoStore, imp, path, opts)
+}
+
+// 判断重复时使用的键：创建时间（精确到秒）和忽略空白差异的正文
+func dedupKey(createdAt time.Time, content string) string {
+	return fmt.Sprintf("%d\x00%s", createdAt.Unix(), strings.Join(strings.Fields(content), " "))
+}
+
+// 把正文中的附件引用替换为 static 目录中的新地址，返回新的正文和引用的附件文件名。
+// 附件按内容哈希命名，重复导入时复用已有的文件；static 中还没有的附件记入 pending，
+// 由调用方在备忘录写入后写入
+func migrateAttachments(memoStore *store.MemoStore, item *Item, pending map[string]*Attachment, report *Report) (string, []string) {
+	content := item.Content
+	var names []string
+	for _, att := range item.Attachments {
+		data, err := att.Open()
+		if err != nil {
+			report.MissingAttachments = append(report.MissingAttachments, fmt.Sprintf("%s: %s", item.Source, att.Name))
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		name := hex.EncodeToString(sum[:6]) + "_" + safeFilename(att.Name)
+		link := "/static/" + url.PathEscape(name)
+		if _, ok := pending[name]; !ok {
+			if _, err := os.Stat(filepath.Join(memoStore.GetStaticDir(), name)); os.IsNotExist(err) {
+				pending[name] = att
+			}
+		}
+		names = append(names, name)
+
+		replacement := link
+		if att.Embed {
+			label := strings.NewReplacer("[", "", "]", "").Replace(att.Name)
+			replacement = "[" + label + "](" + link + ")"
+			if isImage(att.Name) {
+				replacement = "!" + replacement
+			}
+		}
+		content = strings.ReplaceAll(content, att.Ref, replacement)
+	}
+	return content, names
+}
+
+// 去掉文件名中的目录和不适合出现在地址中的字符
+func safeFilename(name string) string {
+	name = path.Base(filepath.ToSlash(name))
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '/', '\\', '?', '#', '%', '(', ')', '[', ']', '"', '\'', '<', '>':
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." {
+		name = "file"
+	}
+	return name
+}
+
+// 根据扩展名判断是否为图片
+func isImage(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp", ".avif", ".heic":
+		return true
+	}
+	return false
+}
+
+// 读取本地文件的附件，文件必须在导出目录 root 内
+func fileAttachment(ref, root, file string, embed bool) *Attachment {
+	return &Attachment{
+		Ref:   ref,
+		Embed: embed,
+		Name:  filepath.Base(file),
+		Open: func() ([]byte, error) {
+			if !insideDir(root, file) {
+				return nil, fmt.Errorf("附件不在导出目录中: %s", file)
+			}
+			return os.ReadFile(file)
+		},
+	}
+}
+
+// 判断 file 是否在 root 目录内（解析符号链接后）。导出文件中引用的路径不可信，
+// 不能读取导出目录以外的文件，否则导入会把服务器上的任意文件复制到公开的 static 目录
+func insideDir(root, file string) bool {
+	resolve := func(p string) string {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			p = real
+		}
+		return p
+	}
+	rel, err := filepath.Rel(resolve(root), resolve(file))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// 判断路径是否为目录
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// 判断文件扩展名（不区分大小写）
+func hasExt(p string, exts ...string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// 在临时目录中写入文件，返回目录路径
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := os.MkdirTemp("", "memo-import-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+	return dir
+}
+
+func newTestStore(t *testing.T) *store.MemoStore {
+	dir, err := os.MkdirTemp("", "memo-import-store")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	memoStore, err := store.NewMemoStore(dir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	return memoStore
+}
+
+func TestImportFlomo(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"flomo/index.html": `<html><body><div class="memos">
+<div class="memo"><div class="time">2023-05-01 10:20:30</div>
+<div class="content"><p>读完了 <strong>三体</strong> #读书/小说</p><ul><li>一</li><li>二</li></ul></div>
+<div class="files"><img src="file/2023-05-01/1/cover.png" /></div></div>
+<div class="memo"><div class="time">2023-05-01 09:00:00</div><div class="content"><p>早上好</p></div><div class="files"></div></div>
+</div></body></html>`,
+		"flomo/file/2023-05-01/1/cover.png": "PNG",
+	})
+	memoStore := newTestStore(t)
+
+	// dry run 只生成报告
+	report, err := Import(memoStore, "", filepath.Join(dir, "flomo"), Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if report.Format != "flomo" || report.Created != 2 || report.Attachments != 1 {
+		t.Errorf("dry run 报告不正确: %+v", report)
+	}
+	if ids := []string{report.Memos[0].ID, report.Memos[1].ID}; !reflect.DeepEqual(ids, []string{"2023-05-01-1", "2023-05-01-2"}) {
+		t.Errorf("应按创建时间分配ID: %v", ids)
+	}
+	if memos, _ := memoStore.ListMemos(); len(memos) != 0 {
+		t.Errorf("dry run 不应写入备忘录, 实际 %d 条", len(memos))
+	}
+
+	if _, err := Import(memoStore, "", filepath.Join(dir, "flomo"), Options{}); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	memo, err := memoStore.GetMemo("2023-05-01-2")
+	if err != nil {
+		t.Fatalf("获取导入的备忘录失败: %v", err)
+	}
+	want := "读完了 **三体** #读书/小说\n\n- 一\n- 二\n\n![](/static/"
+	if !strings.HasPrefix(memo.Content, want) {
+		t.Errorf("转换后的正文不正确: %q", memo.Content)
+	}
+	if !reflect.DeepEqual(memo.Tags, []string{"读书/小说"}) {
+		t.Errorf("标签不正确: %v", memo.Tags)
+	}
+	if want := time.Date(2023, 5, 1, 10, 20, 30, 0, time.Local); !memo.CreatedAt.Equal(want) {
+		t.Errorf("应保留原创建时间, 实际 %v", memo.CreatedAt)
+	}
+	refs := store.ReferencedAttachments(memo.Content)
+	if len(refs) != 1 {
+		t.Fatalf("应引用迁移后的附件: %q", memo.Content)
+	}
+	if data, err := os.ReadFile(filepath.Join(memoStore.GetStaticDir(), refs[0])); err != nil || string(data) != "PNG" {
+		t.Errorf("附件未迁移: %v", err)
+	}
+
+	// 重复导入时跳过已有的备忘录
+	report, err = Import(memoStore, "flomo", filepath.Join(dir, "flomo", "index.html"), Options{})
+	if err != nil {
+		t.Fatalf("重复导入失败: %v", err)
+	}
+	if report.Created != 0 || report.Skipped != 2 || report.Attachments != 0 {
+		t.Errorf("重复导入应全部跳过: %+v", report)
+	}
+}
+
+func TestImportObsidian(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".obsidian/app.json": "{}",
+		"日记/周报.md":           "---\ntags: [work, \"#周报\"]\ncreated: 2024-03-01 09:30\npublish: true\n---\n本周 ![[图表.png|300]] 见 [[计划]] ![[缺失.jpg]]\n",
+		"计划.md":              "![说明](附件/清单.pdf)\n",
+		"附件/图表.png":          "IMG",
+		"附件/清单.pdf":          "PDF",
+	})
+	memoStore := newTestStore(t)
+
+	report, err := Import(memoStore, "", dir, Options{})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if report.Format != "obsidian" || report.Created != 2 || report.Attachments != 2 {
+		t.Errorf("报告不正确: %+v", report)
+	}
+	if len(report.MissingAttachments) != 1 || !strings.Contains(report.MissingAttachments[0], "缺失.jpg") {
+		t.Errorf("应报告缺少的附件: %v", report.MissingAttachments)
+	}
+
+	memo, err := memoStore.GetMemo("2024-03-01-1")
+	if err != nil {
+		t.Fatalf("获取导入的备忘录失败: %v", err)
+	}
+	if memo.Title != "周报" || !memo.IsPublic() || !reflect.DeepEqual(memo.Tags, []string{"work", "周报"}) {
+		t.Errorf("头部信息不正确: %+v", memo)
+	}
+	if !strings.Contains(memo.Content, "![图表.png](/static/") || !strings.Contains(memo.Content, "[[计划]]") || !strings.Contains(memo.Content, "![[缺失.jpg]]") {
+		t.Errorf("正文转换不正确: %q", memo.Content)
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"local.json": `{"ramblog-memos": "[{\"id\":\"1\",\"content\":\"离线记录\",\"tags\":[\"草稿\"],\"createdAt\":\"2024-02-03T04:05:06.000Z\",\"updatedAt\":\"2024-02-04T00:00:00.000Z\",\"isPinned\":true}]"}`,
+		"memos.json": `[{"id":7,"createdTs":1700000000,"updatedTs":1700000100,"rowStatus":"ARCHIVED","content":"旧版 #memos","visibility":"PUBLIC",
+			"resourceList":[{"id":3,"filename":"a.txt","externalLink":""},{"id":4,"filename":"b.jpg","externalLink":"https://cdn/b.jpg"}]}]`,
+		"a.txt": "附件",
+	})
+	memoStore := newTestStore(t)
+
+	report, err := Import(memoStore, "", filepath.Join(dir, "local.json"), Options{})
+	if err != nil {
+		t.Fatalf("导入 localStorage 失败: %v", err)
+	}
+	if report.Format != "localstorage" || report.Created != 1 {
+		t.Fatalf("报告不正确: %+v", report)
+	}
+	memo, err := memoStore.GetMemo(report.Memos[0].ID)
+	if err != nil {
+		t.Fatalf("获取导入的备忘录失败: %v", err)
+	}
+	if !memo.IsPinned() || memo.Content != "离线记录" || !memo.UpdatedAt.Equal(time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("导入的备忘录不正确: %+v", memo)
+	}
+
+	report, err = Import(memoStore, "", filepath.Join(dir, "memos.json"), Options{})
+	if err != nil {
+		t.Fatalf("导入 memos 失败: %v", err)
+	}
+	if report.Format != "memos" || report.Created != 1 || report.Attachments != 1 {
+		t.Fatalf("报告不正确: %+v", report)
+	}
+	memo, err = memoStore.GetMemo(report.Memos[0].ID)
+	if err != nil {
+		t.Fatalf("获取导入的备忘录失败: %v", err)
+	}
+	if !memo.IsArchived() || !memo.IsPublic() || !memo.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("导入的备忘录不正确: %+v", memo)
+	}
+	if !strings.Contains(memo.Content, "[a.txt](/static/") || !strings.Contains(memo.Content, "![b.jpg](https://cdn/b.jpg)") {
+		t.Errorf("附件处理不正确: %q", memo.Content)
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	tests := []struct {
+		buf  []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, ^uint64(0), 9},
+	}
+	for _, tt := range tests {
+		if got, n := readVarint(tt.buf); got != tt.want || n != tt.n {
+			t.Errorf("readVarint(%x) = %d, %d, 期望 %d, %d", tt.buf, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestImportAttachmentOutsideExport(t *testing.T) {
+	secret := filepath.Join(writeFiles(t, map[string]string{"secret.txt": "SECRET"}), "secret.txt")
+	dir := writeFiles(t, map[string]string{})
+	// 导出中引用导出目录以外的文件：相对路径用 ../ 跳出，usememos 使用绝对路径
+	escape := func(exportDir string) string {
+		rel, err := filepath.Rel(filepath.Join(dir, exportDir), secret)
+		if err != nil {
+			t.Fatalf("计算相对路径失败: %v", err)
+		}
+		return filepath.ToSlash(rel)
+	}
+	internalPath, _ := json.Marshal(secret)
+	files := map[string]string{
+		"vault/笔记.md": "![](" + escape("vault") + ")\n",
+		"flomo/index.html": `<html><body><div class="memos"><div class="memo"><div class="time">2023-05-01 10:20:30</div>
+<div class="content"><p>正文</p></div><div class="files"><img src="` + escape("flomo") + `" /></div></div></div></body></html>`,
+		"memos.json": `[{"id":1,"createdTs":1700000000,"updatedTs":1700000000,"content":"正文",
+			"resourceList":[{"id":2,"filename":"secret.txt","internalPath":` + string(internalPath) + `}]}]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+
+	for _, path := range []string{"vault", "flomo", "memos.json"} {
+		memoStore := newTestStore(t)
+		report, err := Import(memoStore, "", filepath.Join(dir, path), Options{})
+		if err != nil {
+			t.Fatalf("导入 %s 失败: %v", path, err)
+		}
+		if report.Created != 1 || report.Attachments != 0 || len(report.MissingAttachments) != 1 {
+			t.Errorf("%s: 导出目录以外的文件应报告为缺失: %+v", path, report)
+		}
+		if entries, _ := os.ReadDir(memoStore.GetStaticDir()); len(entries) != 0 {
+			t.Errorf("%s: 不应复制导出目录以外的文件", path)
+		}
+	}
+}
+
+// 返回固定条目的导入器
+type stubImporter []*Item
+
+func (stubImporter) Name() string                   { return "stub" }
+func (stubImporter) Detect(string) bool             { return false }
+func (s stubImporter) Read(string) ([]*Item, error) { return s, nil }
+
+func TestImportFailureLeavesNoAttachments(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.png": "PNG"})
+	memoStore := newTestStore(t)
+	items := stubImporter{{
+		Content:     "![](a.png)",
+		CreatedAt:   time.Now(),
+		Visibility:  "无效",
+		Attachments: []*Attachment{fileAttachment("a.png", dir, filepath.Join(dir, "a.png"), false)},
+	}}
+	if _, err := Run(memoStore, items, dir, Options{}); err == nil {
+		t.Fatal("可见性无效时导入应失败")
+	}
+	if entries, _ := os.ReadDir(memoStore.GetStaticDir()); len(entries) != 0 {
+		t.Errorf("导入失败时不应写入附件, 实际 %d 个文件", len(entries))
+	}
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 前端在 localStorage 中保存备忘录使用的键
+const localStorageKey = "ramblog-memos"
+
+// localStorageImporter 导入前端离线模式保存在 localStorage 中的备忘录。
+// 可以是 ramblog-memos 的值（备忘录数组），也可以是包含该键的整个 localStorage 导出，
+// 值可以是数组或 JSON 字符串
+type localStorageImporter struct{}
+
+func (localStorageImporter) Name() string { return "localstorage" }
+
+func (localStorageImporter) Detect(path string) bool {
+	if isDir(path) || !hasExt(path, ".json") {
+		return false
+	}
+	memos, err := readLocalStorage(path)
+	return err == nil && len(memos) > 0
+}
+
+// 前端的备忘录格式，见 src/types/index.ts
+type localStorageMemo struct {
+	ID         string    `json:"id"`
+	Content    string    `json:"content"`
+	Tags       []string  `json:"tags"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	IsPinned   bool      `json:"isPinned"`
+	IsArchived bool      `json:"isArchived"`
+}
+
+// 读取导出文件
+func readLocalStorage(path string) ([]*localStorageMemo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 localStorage 导出失败: %w", err)
+	}
+	data = []byte(strings.TrimSpace(string(data)))
+
+	if len(data) > 0 && data[0] == '{' {
+		var storage map[string]json.RawMessage
+		if err := json.Unmarshal(data, &storage); err != nil {
+			return nil, fmt.Errorf("解析 localStorage 导出失败: %w", err)
+		}
+		value, ok := storage[localStorageKey]
+		if !ok {
+			return nil, fmt.Errorf("localStorage 导出中没有 %s", localStorageKey)
+		}
+		// localStorage 中的值是字符串，导出时可能保留了这一层
+		var encoded string
+		if json.Unmarshal(value, &encoded) == nil {
+			value = json.RawMessage(encoded)
+		}
+		data = value
+	}
+
+	var memos []*localStorageMemo
+	if err := json.Unmarshal(data, &memos); err != nil {
+		return nil, fmt.Errorf("解析 localStorage 导出失败: %w", err)
+	}
+	for _, memo := range memos {
+		if memo.ID == "" || memo.CreatedAt.IsZero() {
+			return nil, fmt.Errorf("不是 %s 导出: 缺少 id 或 createdAt", localStorageKey)
+		}
+	}
+	return memos, nil
+}
+
+func (localStorageImporter) Read(path string) ([]*Item, error) {
+	memos, err := readLocalStorage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*Item, 0, len(memos))
+	for _, memo := range memos {
+		items = append(items, &Item{
+			Source:    localStorageKey + "/" + memo.ID,
+			Content:   memo.Content,
+			Tags:      memo.Tags,
+			CreatedAt: memo.CreatedAt,
+			UpdatedAt: memo.UpdatedAt,
+			Pinned:    memo.IsPinned,
+			Archived:  memo.IsArchived,
+		})
+	}
+	return items, nil
+}
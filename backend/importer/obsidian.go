@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"ramblog-app/backend/store"
+)
+
+var (
+	// ![[图片.png]]、![[图片.png|300]]
+	obsidianEmbedPattern = regexp.MustCompile(`!\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]*)?\]\]`)
+	// ![说明](图片.png)、![说明](<带 空格.png>)
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\((?:<([^>]+)>|([^)\s]+))(?:\s+"[^"]*")?\)`)
+)
+
+// 头部中可能表示时间的字段
+var (
+	obsidianCreatedKeys = []string{"created", "created_at", "date"}
+	obsidianUpdatedKeys = []string{"updated", "updated_at", "modified"}
+)
+
+// obsidianImporter 导入 Obsidian 仓库目录。每个笔记成为一条备忘录，文件名作为标题，
+// [[链接]] 保持原样（ramblog 按标题解析），嵌入的图片和附件迁移到 static 目录
+type obsidianImporter struct{}
+
+func (obsidianImporter) Name() string { return "obsidian" }
+
+func (obsidianImporter) Detect(path string) bool {
+	if !isDir(path) {
+		return false
+	}
+	if isDir(filepath.Join(path, ".obsidian")) {
+		return true
+	}
+	notes, _ := filepath.Glob(filepath.Join(path, "*.md"))
+	return len(notes) > 0
+}
+
+// 仓库中的文件索引，用于解析附件
+type vault struct {
+	root   string
+	byName map[string][]string // 小写文件名 -> 相对路径
+}
+
+// 相对路径按 Obsidian 的规则解析：先按仓库根目录，再按笔记所在目录，最后按文件名查找
+func (v *vault) resolve(noteDir, target string) (string, bool) {
+	target = filepath.FromSlash(strings.TrimSpace(target))
+	for _, candidate := range []string{filepath.Join(v.root, target), filepath.Join(noteDir, target)} {
+		if !insideDir(v.root, candidate) {
+			continue
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	matches := v.byName[strings.ToLower(filepath.Base(target))]
+	if len(matches) == 0 {
+		return "", false
+	}
+	// 同名文件取路径最短的一个
+	best := matches[0]
+	for _, m := range matches[1:] {
+		if len(m) < len(best) {
+			best = m
+		}
+	}
+	return filepath.Join(v.root, best), true
+}
+
+func (obsidianImporter) Read(path string) ([]*Item, error) {
+	v := &vault{root: path, byName: make(map[string][]string)}
+	var notes []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != path {
+			// 跳过 .obsidian、.trash 等
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(path, p)
+		v.byName[strings.ToLower(d.Name())] = append(v.byName[strings.ToLower(d.Name())], rel)
+		if hasExt(p, ".md") {
+			notes = append(notes, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取 Obsidian 仓库失败: %w", err)
+	}
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("%s 中没有笔记", path)
+	}
+
+	items := make([]*Item, 0, len(notes))
+	for _, rel := range notes {
+		item, err := v.readNote(rel)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// 读取一篇笔记
+func (v *vault) readNote(rel string) (*Item, error) {
+	file := filepath.Join(v.root, rel)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取笔记 %s 失败: %w", rel, err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, body := splitFrontMatter(data)
+	item := &Item{
+		Source:    filepath.ToSlash(rel),
+		Title:     strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel)),
+		Content:   body,
+		Tags:      obsidianTags(meta),
+		CreatedAt: firstTime(meta, obsidianCreatedKeys, info.ModTime()),
+		UpdatedAt: firstTime(meta, obsidianUpdatedKeys, info.ModTime()),
+	}
+	if title, ok := meta["title"].(string); ok && strings.TrimSpace(title) != "" {
+		item.Title = title
+	}
+	// Obsidian Publish 用 publish: true 标记公开的笔记
+	if publish, ok := meta["publish"].(bool); ok && publish {
+		item.Visibility = store.VisibilityPublic
+	}
+
+	noteDir := filepath.Dir(file)
+	for _, match := range obsidianEmbedPattern.FindAllStringSubmatch(body, -1) {
+		target := strings.TrimSpace(match[1])
+		if ext := filepath.Ext(target); ext == "" || strings.EqualFold(ext, ".md") {
+			// 嵌入其他笔记，保留原样
+			continue
+		}
+		item.Attachments = append(item.Attachments, v.attachment(match[0], noteDir, target, true))
+	}
+	for _, match := range markdownImagePattern.FindAllStringSubmatch(body, -1) {
+		ref := match[1] + match[2]
+		if strings.Contains(ref, "://") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "data:") {
+			continue
+		}
+		target := ref
+		if unescaped, err := url.PathUnescape(ref); err == nil {
+			target = unescaped
+		}
+		item.Attachments = append(item.Attachments, v.attachment(ref, noteDir, target, false))
+	}
+	return item, nil
+}
+
+// 创建附件，找不到文件时在导入报告中列为缺失的附件
+func (v *vault) attachment(ref, noteDir, target string, embed bool) *Attachment {
+	if file, ok := v.resolve(noteDir, target); ok {
+		return fileAttachment(ref, v.root, file, embed)
+	}
+	return &Attachment{
+		Ref:   ref,
+		Embed: embed,
+		Name:  filepath.Base(target),
+		Open:  func() ([]byte, error) { return nil, fmt.Errorf("找不到附件 %s", target) },
+	}
+}
+
+// 拆分 YAML 头部和正文，头部无法解析时当作正文的一部分
+func splitFrontMatter(data []byte) (map[string]any, string) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return nil, string(data)
+	}
+	rest := data[4:]
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, string(data)
+	}
+	var meta map[string]any
+	if err := yaml.Unmarshal(rest[:end], &meta); err != nil {
+		return nil, string(data)
+	}
+	body := rest[end+4:]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+	return meta, string(body)
+}
+
+// 头部中的标签，可以是列表或用逗号、空格分隔的字符串
+func obsidianTags(meta map[string]any) []string {
+	var raw []string
+	for _, key := range []string{"tags", "tag"} {
+		switch v := meta[key].(type) {
+		case string:
+			raw = append(raw, strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })...)
+		case []any:
+			for _, tag := range v {
+				if s, ok := tag.(string); ok {
+					raw = append(raw, s)
+				}
+			}
+		}
+	}
+	var tags []string
+	for _, tag := range raw {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// 笔记头部时间可能的格式
+var obsidianTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// 按顺序取第一个能解析的时间字段，都没有时返回 fallback
+func firstTime(meta map[string]any, keys []string, fallback time.Time) time.Time {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			for _, layout := range obsidianTimeLayouts {
+				if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	return fallback
+}
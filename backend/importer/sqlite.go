@@ -0,0 +1,400 @@
+package importer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// 只读的 SQLite 数据库文件解析，足以读出 usememos 数据库中的表，避免引入 cgo 驱动。
+// 只支持 UTF-8 编码和普通的 rowid 表，不读取 WAL 文件中尚未合并的内容
+
+// sqliteDB 已读入内存的数据库文件
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int // 每页可用的字节数（去掉保留区）
+}
+
+// sqliteTable sqlite_master 中的一张表
+type sqliteTable struct {
+	name     string
+	rootPage int
+	columns  []string
+}
+
+// sqliteRow 表中的一行，按列名取值
+type sqliteRow map[string]any
+
+const sqliteHeader = "SQLite format 3\x00"
+
+// 判断文件是否为 SQLite 数据库
+func isSQLiteFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, len(sqliteHeader))
+	_, err = f.Read(buf)
+	return err == nil && string(buf) == sqliteHeader
+}
+
+// 打开数据库文件
+func openSQLite(path string) (*sqliteDB, error) {
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() > 0 {
+		return nil, fmt.Errorf("%s-wal 中还有未合并的数据，请先停止 memos 服务或执行 PRAGMA wal_checkpoint", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库失败: %w", err)
+	}
+	if len(data) < 100 || string(data[:len(sqliteHeader)]) != sqliteHeader {
+		return nil, fmt.Errorf("%s 不是 SQLite 数据库", path)
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	// 页大小是 512 到 65536 之间的 2 的幂，去掉保留区后至少 480 字节
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("数据库页大小无效: %d", pageSize)
+	}
+	usable := pageSize - int(data[20])
+	if usable < 480 {
+		return nil, fmt.Errorf("数据库保留区过大: %d", data[20])
+	}
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding > 1 {
+		return nil, fmt.Errorf("不支持 UTF-16 编码的数据库")
+	}
+	return &sqliteDB{data: data, pageSize: pageSize, usable: usable}, nil
+}
+
+// 返回页号对应的内容，页号从 1 开始
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	if n < 1 || n > len(db.data)/db.pageSize {
+		return nil, fmt.Errorf("数据库页号越界: %d", n)
+	}
+	start := (n - 1) * db.pageSize
+	return db.data[start : start+db.pageSize], nil
+}
+
+// 按顺序遍历 B 树中的每一行，fn 收到 rowid 和解码后的字段
+func (db *sqliteDB) scan(root int, fn func(rowid int64, values []any) error) error {
+	return db.scanPage(root, 0, make(map[int]bool), fn)
+}
+
+// 遍历一页及其子页。visited 记录已经访问过的页，损坏的文件中子页指针形成环时返回错误
+func (db *sqliteDB) scanPage(n, depth int, visited map[int]bool, fn func(int64, []any) error) error {
+	if depth > 64 {
+		return errors.New("数据库 B 树层数异常")
+	}
+	if visited[n] {
+		return fmt.Errorf("数据库页 %d 被重复引用", n)
+	}
+	visited[n] = true
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if n == 1 {
+		offset = 100
+	}
+	// 单元格只能位于可用区域内
+	page = page[:db.usable]
+	if offset+8 > len(page) {
+		return errors.New("数据库页头越界")
+	}
+	header := page[offset:]
+	cells := int(binary.BigEndian.Uint16(header[3:5]))
+
+	headerSize := 8
+	if header[0] == 0x05 {
+		headerSize = 12
+	}
+	if headerSize+2*cells > len(header) {
+		return errors.New("数据库单元格指针越界")
+	}
+	pointers := header[headerSize : headerSize+2*cells]
+
+	switch header[0] {
+	case 0x05: // 内部节点
+		for i := 0; i < cells; i++ {
+			cell := int(binary.BigEndian.Uint16(pointers[2*i:]))
+			if cell+4 > len(page) {
+				return errors.New("数据库单元格越界")
+			}
+			child := int(binary.BigEndian.Uint32(page[cell:]))
+			if err := db.scanPage(child, depth+1, visited, fn); err != nil {
+				return err
+			}
+		}
+		return db.scanPage(int(binary.BigEndian.Uint32(header[8:12])), depth+1, visited, fn)
+	case 0x0d: // 叶子节点
+		for i := 0; i < cells; i++ {
+			cell := int(binary.BigEndian.Uint16(pointers[2*i:]))
+			payload, rowid, err := db.cellPayload(page, cell)
+			if err != nil {
+				return err
+			}
+			values, err := decodeRecord(payload)
+			if err != nil {
+				return err
+			}
+			if err := fn(rowid, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的数据库页类型: %#x", header[0])
+	}
+}
+
+// 读取叶子节点单元格的完整内容，包括溢出页
+func (db *sqliteDB) cellPayload(page []byte, cell int) ([]byte, int64, error) {
+	if cell >= len(page) {
+		return nil, 0, errors.New("数据库单元格越界")
+	}
+	size, n := readVarint(page[cell:])
+	cell += n
+	if cell >= len(page) {
+		return nil, 0, errors.New("数据库单元格越界")
+	}
+	rowid, n := readVarint(page[cell:])
+	cell += n
+
+	// 内容不会比整个文件还大
+	if size > uint64(len(db.data)) {
+		return nil, 0, fmt.Errorf("数据库单元格长度无效: %d", size)
+	}
+	total := int(size)
+	maxLocal := db.usable - 35
+	if total <= maxLocal {
+		if cell+total > len(page) {
+			return nil, 0, errors.New("数据库单元格越界")
+		}
+		return page[cell : cell+total], int64(rowid), nil
+	}
+
+	minLocal := (db.usable-12)*32/255 - 23
+	local := minLocal + (total-minLocal)%(db.usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	if cell+local+4 > len(page) {
+		return nil, 0, errors.New("数据库单元格越界")
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, page[cell:cell+local]...)
+	next := int(binary.BigEndian.Uint32(page[cell+local:]))
+	visited := make(map[int]bool)
+	for len(payload) < total {
+		if visited[next] {
+			return nil, 0, fmt.Errorf("数据库溢出页 %d 被重复引用", next)
+		}
+		visited[next] = true
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, 0, err
+		}
+		next = int(binary.BigEndian.Uint32(overflow))
+		chunk := min(total-len(payload), db.usable-4)
+		payload = append(payload, overflow[4:4+chunk]...)
+	}
+	return payload, int64(rowid), nil
+}
+
+// 解码一条记录中的各个字段
+func decodeRecord(payload []byte) ([]any, error) {
+	headerSize, n := readVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, errors.New("数据库记录头越界")
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		t, n := readVarint(payload[pos:int(headerSize)])
+		if n == 0 {
+			return nil, errors.New("数据库记录头越界")
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	values := make([]any, 0, len(types))
+	body := payload[headerSize:]
+	for _, t := range types {
+		var size uint64
+		switch {
+		case t == 0, t == 8, t == 9:
+			size = 0
+		case t <= 4:
+			size = t
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t >= 12:
+			size = (t - 12) / 2
+		default:
+			return nil, fmt.Errorf("无效的字段类型: %d", t)
+		}
+		if size > uint64(len(body)) {
+			return nil, errors.New("数据库记录越界")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t == 8:
+			values = append(values, int64(0))
+		case t == 9:
+			values = append(values, int64(1))
+		case t <= 6:
+			// 大端有符号整数
+			var v int64
+			for _, b := range field {
+				v = v<<8 | int64(b)
+			}
+			shift := 64 - 8*size
+			values = append(values, v<<shift>>shift)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case t%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		default:
+			values = append(values, string(field))
+		}
+	}
+	return values, nil
+}
+
+// 读取 SQLite 的变长整数，返回值和占用的字节数
+func readVarint(buf []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(buf); i++ {
+		if i == 8 {
+			return v<<8 | uint64(buf[i]), 9
+		}
+		v = v<<7 | uint64(buf[i]&0x7f)
+		if buf[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, len(buf)
+}
+
+// 读取 sqlite_master 中的所有表
+func (db *sqliteDB) tables() (map[string]*sqliteTable, error) {
+	tables := make(map[string]*sqliteTable)
+	err := db.scan(1, func(_ int64, values []any) error {
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		name, _ := values[1].(string)
+		root, _ := values[3].(int64)
+		sql, _ := values[4].(string)
+		tables[name] = &sqliteTable{name: name, rootPage: int(root), columns: parseColumns(sql)}
+		return nil
+	})
+	return tables, err
+}
+
+// 读取表中的全部行。INTEGER PRIMARY KEY 列在记录中存为 NULL，用 rowid 补上
+func (db *sqliteDB) rows(table *sqliteTable) ([]sqliteRow, error) {
+	var rows []sqliteRow
+	err := db.scan(table.rootPage, func(rowid int64, values []any) error {
+		row := make(sqliteRow, len(table.columns))
+		for i, column := range table.columns {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+		if len(table.columns) > 0 && row[table.columns[0]] == nil {
+			row[table.columns[0]] = rowid
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+// 从 CREATE TABLE 语句中解析列名
+func parseColumns(sql string) []string {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	// 按不在括号内的逗号切分
+	var parts []string
+	depth, last := 0, start+1
+	for i := start + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, sql[last:i])
+				last = i + 1
+			}
+		}
+	}
+	parts = append(parts, sql[last:end])
+
+	var columns []string
+	for _, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue
+		}
+		columns = append(columns, strings.Trim(fields[0], "`\"[]"))
+	}
+	return columns
+}
+
+// 读取整数字段
+func (r sqliteRow) int(column string) int64 {
+	switch v := r[column].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// 读取文本字段
+func (r sqliteRow) string(column string) string {
+	switch v := r[column].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// 读取二进制字段
+func (r sqliteRow) bytes(column string) []byte {
+	switch v := r[column].(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return nil
+}
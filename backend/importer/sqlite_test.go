@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/memos.db 是用 sqlite3 按 memos v0.18 的表结构生成的数据库：
+// 121 条备忘录（第 3 条已归档，最后一条正文超过一页），memo_organizer 中置顶了第 2 条，resource 中有第 1 条的附件
+const memosFixture = "testdata/memos.db"
+
+func TestImportUsememosDB(t *testing.T) {
+	memoStore := newTestStore(t)
+	report, err := Import(memoStore, "", memosFixture, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if report.Format != "memos" || report.Created != 121 || report.Attachments != 1 {
+		t.Errorf("dry run 报告不正确: %+v", report)
+	}
+
+	memos, err := readUsememosDB(memosFixture)
+	if err != nil {
+		t.Fatalf("读取数据库失败: %v", err)
+	}
+	if len(memos) != 121 {
+		t.Fatalf("应读出 121 条备忘录, 实际 %d", len(memos))
+	}
+	if memos[0].Content != "第 1 条 #memos/导入" || memos[0].CreatedTs != 1700000060 || len(memos[0].ResourceList) != 1 {
+		t.Errorf("第 1 条不正确: %+v", memos[0])
+	}
+	if string(memos[0].ResourceList[0].blob) != "\x89PNG" {
+		t.Errorf("附件内容不正确: %q", memos[0].ResourceList[0].blob)
+	}
+	if !memos[1].Pinned || memos[2].RowStatus != "ARCHIVED" || memos[9].Visibility != "PUBLIC" {
+		t.Errorf("置顶、归档或可见性不正确: %+v %+v %+v", memos[1], memos[2], memos[9])
+	}
+	if long := memos[120].Content; len(long) < 4096 || !strings.HasSuffix(long, "很长的内容。") {
+		t.Errorf("溢出页中的正文不完整: %d 字节", len(long))
+	}
+}
+
+// 损坏的数据库文件应返回错误，不能导致 panic
+func TestImportCorruptSQLite(t *testing.T) {
+	fixture, err := os.ReadFile(memosFixture)
+	if err != nil {
+		t.Fatalf("读取测试数据库失败: %v", err)
+	}
+	db, err := openSQLite(memosFixture)
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	tables, err := db.tables()
+	if err != nil {
+		t.Fatalf("读取数据库结构失败: %v", err)
+	}
+	memoRoot := tables["memo"].rootPage
+	rootOffset := (memoRoot - 1) * db.pageSize
+	if fixture[rootOffset] != 0x05 {
+		t.Fatalf("memo 表的根页应是内部节点")
+	}
+	overflow := findOverflowPage(t, db)
+
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+	}{
+		{"页大小为 0", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[16:], 0)
+			return data
+		}},
+		{"页大小不是 2 的幂", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[16:], 1000)
+			return data
+		}},
+		{"保留区不小于页大小", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[16:], 512)
+			data[20] = 255
+			return data
+		}},
+		{"单元格指针越界", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[108:], 0xffff)
+			return data
+		}},
+		{"单元格数量越界", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[103:], 0xffff)
+			return data
+		}},
+		{"文件被截断", func(data []byte) []byte {
+			return data[:len(data)/2]
+		}},
+		{"只剩文件头", func(data []byte) []byte {
+			return data[:100]
+		}},
+		{"子页指向自身", func(data []byte) []byte {
+			binary.BigEndian.PutUint32(data[rootOffset+8:], uint32(memoRoot))
+			return data
+		}},
+		{"溢出页形成环", func(data []byte) []byte {
+			binary.BigEndian.PutUint32(data[(overflow-1)*db.pageSize:], uint32(overflow))
+			return data
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "memos.db")
+			data := tt.corrupt(append([]byte(nil), fixture...))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("写入文件失败: %v", err)
+			}
+			if _, err := Import(newTestStore(t), "memos", path, Options{DryRun: true}); err == nil {
+				t.Errorf("损坏的数据库应返回错误")
+			}
+		})
+	}
+}
+
+// 找到最后一条备忘录的第一个溢出页
+func findOverflowPage(t *testing.T, db *sqliteDB) int {
+	for n := 2; n <= len(db.data)/db.pageSize; n++ {
+		page, _ := db.page(n)
+		if page[0] != 0x0d {
+			continue
+		}
+		cells := int(binary.BigEndian.Uint16(page[3:5]))
+		for i := 0; i < cells; i++ {
+			cell := int(binary.BigEndian.Uint16(page[8+2*i:]))
+			size, n1 := readVarint(page[cell:])
+			_, n2 := readVarint(page[cell+n1:])
+			if int(size) <= db.usable-35 {
+				continue
+			}
+			minLocal := (db.usable-12)*32/255 - 23
+			local := minLocal + (int(size)-minLocal)%(db.usable-4)
+			if local > db.usable-35 {
+				local = minLocal
+			}
+			return int(binary.BigEndian.Uint32(page[cell+n1+n2+local:]))
+		}
+	}
+	t.Fatalf("测试数据库中没有溢出页")
+	return 0
+}
+
+func TestDecodeCorruptRecord(t *testing.T) {
+	tests := map[string][]byte{
+		"空记录":    {},
+		"记录头越界":  {0x10, 0x01},
+		"记录头不完整": {0x03, 0x81},
+		"字段长度巨大": {0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"字段越界":   {0x02, 0x21, 'a'},
+	}
+	for name, payload := range tests {
+		if _, err := decodeRecord(payload); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+
+	db := &sqliteDB{data: make([]byte, 4096), pageSize: 4096, usable: 4096}
+	page := make([]byte, 4096)
+	copy(page[10:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	if _, _, err := db.cellPayload(page, 10); err == nil {
+		t.Errorf("超过文件大小的单元格长度应返回错误")
+	}
+	if _, _, err := db.cellPayload(page, 4095); err == nil {
+		t.Errorf("单元格越界应返回错误")
+	}
+}
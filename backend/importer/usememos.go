@@ -0,0 +1,302 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// usememosImporter 导入 usememos（https://usememos.com）的数据：
+// SQLite 数据库文件（memos_prod.db），或接口返回的 JSON（旧版的数组或新版的 {"memos": [...]}）。
+// 附件不在正文中引用，导入后附加在正文末尾
+type usememosImporter struct{}
+
+func (usememosImporter) Name() string { return "memos" }
+
+func (usememosImporter) Detect(path string) bool {
+	if isDir(path) {
+		return false
+	}
+	if isSQLiteFile(path) {
+		return true
+	}
+	if !hasExt(path, ".json") {
+		return false
+	}
+	memos, err := readUsememosJSON(path)
+	return err == nil && len(memos) > 0
+}
+
+// 一条 usememos 备忘录，兼容新旧两种接口字段
+type usememosMemo struct {
+	ID         json.Number `json:"id"`
+	Name       string      `json:"name"` // 新版接口: memos/{uid}
+	UID        string      `json:"uid"`
+	CreatedTs  int64       `json:"createdTs"`
+	UpdatedTs  int64       `json:"updatedTs"`
+	CreateTime time.Time   `json:"createTime"`
+	UpdateTime time.Time   `json:"updateTime"`
+	RowStatus  string      `json:"rowStatus"`
+	State      string      `json:"state"`
+	Content    string      `json:"content"`
+	Visibility string      `json:"visibility"`
+	Pinned     bool        `json:"pinned"`
+	// 附件字段在不同版本中的名称
+	ResourceList []usememosResource `json:"resourceList"`
+	Resources    []usememosResource `json:"resources"`
+	Attachments  []usememosResource `json:"attachments"`
+}
+
+// usememos 的附件
+type usememosResource struct {
+	ID           json.Number `json:"id"`
+	Name         string      `json:"name"`
+	UID          string      `json:"uid"`
+	Filename     string      `json:"filename"`
+	ExternalLink string      `json:"externalLink"`
+	Content      string      `json:"content"` // 新版接口可能带有 base64 编码的内容
+	InternalPath string      `json:"internalPath"`
+
+	blob []byte
+}
+
+// 读取 JSON 导出
+func readUsememosJSON(path string) ([]*usememosMemo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 memos 导出失败: %w", err)
+	}
+	data = []byte(strings.TrimSpace(string(data)))
+
+	var memos []*usememosMemo
+	if len(data) > 0 && data[0] == '{' {
+		var wrapped struct {
+			Memos []*usememosMemo `json:"memos"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("解析 memos 导出失败: %w", err)
+		}
+		memos = wrapped.Memos
+	} else if err := json.Unmarshal(data, &memos); err != nil {
+		return nil, fmt.Errorf("解析 memos 导出失败: %w", err)
+	}
+
+	// 至少要有正文和时间，避免把其他 JSON 误认为 memos 导出
+	for _, memo := range memos {
+		if memo.CreatedTs == 0 && memo.CreateTime.IsZero() {
+			return nil, fmt.Errorf("不是 memos 导出: 缺少创建时间")
+		}
+	}
+	return memos, nil
+}
+
+func (usememosImporter) Read(path string) ([]*Item, error) {
+	var memos []*usememosMemo
+	var err error
+	if isSQLiteFile(path) {
+		memos, err = readUsememosDB(path)
+	} else {
+		memos, err = readUsememosJSON(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Dir(path)
+	items := make([]*Item, 0, len(memos))
+	for _, memo := range memos {
+		item := &Item{
+			Source:   memo.source(),
+			Content:  memo.Content,
+			Pinned:   memo.Pinned,
+			Archived: memo.RowStatus == "ARCHIVED" || memo.State == "ARCHIVED",
+		}
+		if memo.Visibility == "PUBLIC" {
+			item.Visibility = store.VisibilityPublic
+		}
+		if memo.CreatedTs != 0 {
+			item.CreatedAt = time.Unix(memo.CreatedTs, 0)
+			item.UpdatedAt = time.Unix(memo.UpdatedTs, 0)
+		} else {
+			item.CreatedAt = memo.CreateTime
+			item.UpdatedAt = memo.UpdateTime
+		}
+
+		var refs []string
+		resources := append(append(memo.ResourceList, memo.Resources...), memo.Attachments...)
+		for _, res := range resources {
+			link, att := res.attachment(baseDir)
+			label := "[" + res.Filename + "](" + link + ")"
+			if isImage(res.Filename) {
+				label = "!" + label
+			}
+			refs = append(refs, label)
+			if att != nil {
+				item.Attachments = append(item.Attachments, att)
+			}
+		}
+		if len(refs) > 0 {
+			item.Content = strings.TrimSpace(item.Content + "\n\n" + strings.Join(refs, "\n"))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// 在报告中标识备忘录
+func (m *usememosMemo) source() string {
+	switch {
+	case m.Name != "":
+		return m.Name
+	case m.UID != "":
+		return "memos/" + m.UID
+	}
+	return "memos/" + m.ID.String()
+}
+
+// 返回附件在正文中的地址；本地保存的附件同时返回需要迁移的文件，
+// 迁移失败时正文中仍保留原 memos 服务上的地址
+func (r *usememosResource) attachment(baseDir string) (string, *Attachment) {
+	if r.ExternalLink != "" && !strings.HasPrefix(r.ExternalLink, "/") {
+		return r.ExternalLink, nil
+	}
+
+	id := r.UID
+	if id == "" {
+		id = r.ID.String()
+	}
+	if r.Name != "" {
+		id = strings.TrimPrefix(strings.TrimPrefix(r.Name, "resources/"), "attachments/")
+	}
+	link := "/o/r/" + id + "/" + r.Filename
+
+	att := &Attachment{Ref: link, Name: r.Filename}
+	switch {
+	case len(r.blob) > 0:
+		blob := r.blob
+		att.Open = func() ([]byte, error) { return blob, nil }
+	case r.Content != "":
+		content := r.Content
+		att.Open = func() ([]byte, error) { return base64.StdEncoding.DecodeString(content) }
+	default:
+		candidates := []string{r.InternalPath, r.ExternalLink}
+		if r.Filename != "" {
+			candidates = append(candidates, r.Filename, filepath.Join("resources", r.Filename), filepath.Join("assets", r.Filename))
+		}
+		att.Open = func() ([]byte, error) {
+			for _, candidate := range candidates {
+				if candidate == "" {
+					continue
+				}
+				// 只读取导出目录内的文件，原服务器上的绝对路径不可信
+				p := filepath.FromSlash(candidate)
+				if filepath.IsAbs(p) {
+					continue
+				}
+				p = filepath.Join(baseDir, p)
+				if !insideDir(baseDir, p) {
+					continue
+				}
+				if data, err := os.ReadFile(p); err == nil {
+					return data, nil
+				}
+			}
+			return nil, fmt.Errorf("找不到附件 %s", r.Filename)
+		}
+	}
+	return link, att
+}
+
+// 读取 usememos 的 SQLite 数据库
+func readUsememosDB(path string) ([]*usememosMemo, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := db.tables()
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库结构失败: %w", err)
+	}
+	memoTable := tables["memo"]
+	if memoTable == nil {
+		return nil, fmt.Errorf("%s 中没有 memo 表，不是 memos 数据库", path)
+	}
+
+	rows, err := db.rows(memoTable)
+	if err != nil {
+		return nil, fmt.Errorf("读取 memo 表失败: %w", err)
+	}
+	byID := make(map[int64]*usememosMemo, len(rows))
+	memos := make([]*usememosMemo, 0, len(rows))
+	for _, row := range rows {
+		memo := &usememosMemo{
+			ID:         json.Number(strconv.FormatInt(row.int("id"), 10)),
+			UID:        row.string("uid"),
+			CreatedTs:  row.int("created_ts"),
+			UpdatedTs:  row.int("updated_ts"),
+			RowStatus:  row.string("row_status"),
+			Content:    row.string("content"),
+			Visibility: row.string("visibility"),
+			Pinned:     row.int("pinned") != 0,
+		}
+		byID[row.int("id")] = memo
+		memos = append(memos, memo)
+	}
+
+	// 旧版本的置顶状态保存在 memo_organizer 表中
+	if organizer := tables["memo_organizer"]; organizer != nil {
+		rows, err := db.rows(organizer)
+		if err != nil {
+			return nil, fmt.Errorf("读取 memo_organizer 表失败: %w", err)
+		}
+		for _, row := range rows {
+			if memo := byID[row.int("memo_id")]; memo != nil && row.int("pinned") != 0 {
+				memo.Pinned = true
+			}
+		}
+	}
+
+	// 附件表在新版本中改名为 attachment
+	resourceTable := tables["resource"]
+	if resourceTable == nil {
+		resourceTable = tables["attachment"]
+	}
+	if resourceTable != nil {
+		rows, err := db.rows(resourceTable)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 表失败: %w", resourceTable.name, err)
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].int("id") < rows[j].int("id") })
+		for _, row := range rows {
+			memo := byID[row.int("memo_id")]
+			if memo == nil {
+				continue
+			}
+			res := usememosResource{
+				ID:           json.Number(strconv.FormatInt(row.int("id"), 10)),
+				UID:          row.string("uid"),
+				Filename:     row.string("filename"),
+				ExternalLink: row.string("external_link"),
+				InternalPath: row.string("internal_path"),
+				blob:         row.bytes("blob"),
+			}
+			// 新版本用 storage_type 和 reference 记录附件位置
+			switch row.string("storage_type") {
+			case "LOCAL":
+				res.InternalPath = row.string("reference")
+			case "S3", "EXTERNAL":
+				res.ExternalLink = row.string("reference")
+			}
+			memo.ResourceList = append(memo.ResourceList, res)
+		}
+	}
+	return memos, nil
+}
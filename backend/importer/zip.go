@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Unzip 把 ZIP 压缩的导出解压到 dir，返回应当导入的路径：
+// 压缩包中只有一个顶层目录时返回该目录，否则返回 dir
+func Unzip(archive, dir string) (string, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		// 拒绝可能跳出解压目录的路径
+		name := filepath.FromSlash(f.Name)
+		if filepath.IsAbs(name) || strings.HasPrefix(filepath.Clean(name), "..") {
+			return "", fmt.Errorf("压缩包中的路径无效: %s", f.Name)
+		}
+		target := filepath.Join(dir, name)
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := extractFile(f, target); err != nil {
+			return "", fmt.Errorf("解压 %s 失败: %w", f.Name, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var visible []os.DirEntry
+	for _, entry := range entries {
+		// 忽略 macOS 压缩时附带的 __MACOSX 等目录
+		if !strings.HasPrefix(entry.Name(), "__") && !strings.HasPrefix(entry.Name(), ".") {
+			visible = append(visible, entry)
+		}
+	}
+	if len(visible) == 1 && visible[0].IsDir() {
+		return filepath.Join(dir, visible[0].Name()), nil
+	}
+	return dir, nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// 保留修改时间，没有其他时间信息的笔记以它作为创建时间
+	return os.Chtimes(target, f.Modified, f.Modified)
+}
@@ -1,18 +1,32 @@
 package main
 
 import (
+	"context"
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"ramblog-app/backend/api"
+	"ramblog-app/backend/backup"
 	"ramblog-app/backend/config"
 	"ramblog-app/backend/store"
+	"ramblog-app/backend/webhook"
 )
 
 func main() {
+	// 子命令（如 build-site）由对应的命令处理
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalf("%s 失败: %v", cmd.name, err)
+			}
+			return
+		}
+	}
+
 	// 加载配置
 	cfg := config.LoadConfig()
 
@@ -22,6 +36,60 @@ func main() {
 		log.Fatalf("无法初始化存储: %v", err)
 	}
 
+	if err := unlockStore(memoStore, cfg.KeyFile); err != nil {
+		log.Fatalf("无法解锁加密存储: %v", err)
+	}
+
+	hashtagPolicy, err := store.ParseHashtagPolicy(cfg.Hashtags)
+	if err != nil {
+		log.Fatalf("配置错误: %v", err)
+	}
+	memoStore.SetHashtagPolicy(hashtagPolicy)
+
+	shareStore, err := store.NewShareStore(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("无法初始化分享存储: %v", err)
+	}
+	memoStore.SetShareStore(shareStore)
+
+	collectionStore, err := store.NewCollectionStore(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("无法初始化合集存储: %v", err)
+	}
+
+	captureStore, err := store.NewCaptureStore(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("无法初始化收集入口: %v", err)
+	}
+
+	// 发现在编辑器或同步工具中对数据目录的修改
+	if cfg.WatchInterval > 0 {
+		go memoStore.WatchDisk(context.Background(), cfg.WatchInterval)
+	}
+
+	backupDir := cfg.BackupDir
+	if backupDir == "" {
+		backupDir = backup.DefaultDir(cfg.DataDir)
+	}
+	backups, err := backup.NewManager(memoStore, backupDir, backup.Policy{
+		Hourly: cfg.BackupHourly,
+		Daily:  cfg.BackupDaily,
+		Weekly: cfg.BackupWeekly,
+	})
+	if err != nil {
+		log.Fatalf("无法初始化备份: %v", err)
+	}
+	if cfg.BackupInterval > 0 {
+		go backups.Run(context.Background(), cfg.BackupInterval)
+	}
+
+	// 在创建 Dispatcher 时就开始接收事件，启动后的修改不会漏掉
+	webhooks, err := webhook.NewDispatcher(memoStore)
+	if err != nil {
+		log.Fatalf("无法初始化 webhook: %v", err)
+	}
+	go webhooks.Run(context.Background())
+
 	// 设置Gin模式
 	if !cfg.Debug {
 		gin.SetMode(gin.ReleaseMode)
@@ -35,12 +103,12 @@ func main() {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		
+
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
 		}
-		
+
 		c.Next()
 	})
 
@@ -51,8 +119,32 @@ func main() {
 		api.RegisterRoutes(apiGroup, memoStore)
 	}
 
-	// 设置静态文件服务
-	r.Static("/static", memoStore.GetStaticDir())
+	// 分享链接路由
+	api.RegisterShareRoutes(apiGroup, r, memoStore, shareStore)
+
+	// 智能合集路由
+	api.RegisterCollectionRoutes(apiGroup, memoStore, collectionStore, cfg.SiteTitle, cfg.BaseURL)
+
+	// 事件流路由
+	api.RegisterEventRoutes(apiGroup, memoStore)
+
+	// 同步路由
+	api.RegisterSyncRoutes(apiGroup, memoStore)
+
+	// 快照路由
+	api.RegisterBackupRoutes(apiGroup, backups)
+
+	// webhook 路由
+	api.RegisterWebhookRoutes(apiGroup, webhooks)
+
+	// 收集入口路由
+	api.RegisterCaptureRoutes(apiGroup, memoStore, captureStore)
+
+	// 订阅源路由
+	api.RegisterFeedRoutes(r, memoStore, cfg.SiteTitle, cfg.BaseURL)
+
+	// 设置附件服务，加密的附件读取时解密
+	r.StaticFS("/static", memoStore.AttachmentFS())
 
 	// 创建静态文件子文件系统
 	subFS, err := fs.Sub(StaticFiles, "out")
@@ -97,7 +189,10 @@ func main() {
 	log.Printf("服务器启动在 %s\n", cfg.ServerAddr)
 	log.Printf("数据目录: %s\n", cfg.DataDir)
 	log.Printf("调试模式: %v\n", cfg.Debug)
+	if cfg.BackupInterval > 0 {
+		log.Printf("自动备份: 每 %s 一次，保存到 %s\n", cfg.BackupInterval, backupDir)
+	}
 	if err := r.Run(cfg.ServerAddr); err != nil {
 		log.Fatalf("服务器启动失败: %v", err)
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"ramblog-app/backend/store"
+)
+
+// 未指定密钥文件时从该环境变量读取口令，便于以服务方式运行
+const passphraseEnv = "RAMBLOG_PASSPHRASE"
+
+// 多次输入口令时共用，避免缓冲区吞掉后面的输入
+var stdin = bufio.NewReader(os.Stdin)
+
+// 读取加密存储的口令：优先使用密钥文件，其次是环境变量，最后在终端中输入
+func readSecret(keyFile, prompt string) ([]byte, error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		// 去掉编辑器或 echo 添加的结尾换行
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+	if secret := os.Getenv(passphraseEnv); secret != "" {
+		return []byte(secret), nil
+	}
+	return readPassphrase(prompt)
+}
+
+// 读取新口令：指定了密钥文件时读取文件，否则在终端中输入两次。
+// useEnv 为 true 时也接受环境变量中的口令
+func readNewSecret(keyFile string, useEnv bool) ([]byte, error) {
+	if keyFile != "" || (useEnv && os.Getenv(passphraseEnv) != "") {
+		return readSecret(keyFile, "")
+	}
+	secret, err := readPassphrase("设置口令: ")
+	if err != nil {
+		return nil, err
+	}
+	confirm, err := readPassphrase("再次输入口令: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(secret, confirm) {
+		return nil, errors.New("两次输入的口令不一致")
+	}
+	return secret, nil
+}
+
+// 从终端读取一行口令，输入时不回显
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	restore := disableEcho(int(os.Stdin.Fd()))
+	line, err := stdin.ReadString('\n')
+	restore()
+	fmt.Fprintln(os.Stderr)
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("读取口令失败: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// 数据目录启用了加密存储时解锁密钥
+func unlockStore(memoStore *store.MemoStore, keyFile string) error {
+	if !store.EncryptionConfigured(memoStore.DataDir()) {
+		return nil
+	}
+	secret, err := readSecret(keyFile, "口令: ")
+	if err != nil {
+		return err
+	}
+	keyring, err := store.UnlockKeyring(memoStore.DataDir(), secret)
+	if err != nil {
+		return err
+	}
+	memoStore.SetKeyring(keyring)
+	log.Printf("已解锁加密存储（模式: %s）\n", keyring.Mode())
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package render 将备忘录的 Markdown 内容渲染为经过清理的 HTML，
+// 供 Web 界面、订阅源、分享页面和导出等所有输出统一使用。
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// 代码高亮使用的配色
+const highlightStyle = "github"
+
+// Options 渲染选项
+type Options struct {
+	BaseURL          string // 非空时将站内相对链接（如 /static/xxx）补全为绝对地址
+	AttachmentPrefix string // 非空时将 /static/ 下的附件地址改写为该前缀
+}
+
+// Renderer 将 Markdown 渲染为经过清理的 HTML，可并发使用
+type Renderer struct {
+	md goldmark.Markdown
+}
+
+// New 创建一个渲染器
+func New(opts Options) *Renderer {
+	links := &linkTransformer{
+		baseURL:          strings.TrimSuffix(opts.BaseURL, "/"),
+		attachmentPrefix: opts.AttachmentPrefix,
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			// GFM 扩展，表格对齐使用 align 属性以通过清理
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.TaskList,
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(highlightStyle),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(
+				util.Prioritized(links, 100),
+				util.Prioritized(&headingAnchorTransformer{}, 200),
+			),
+		),
+		// 允许正文中的原始HTML，统一交由清理策略处理
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	return &Renderer{md: md}
+}
+
+// Render 渲染Markdown内容并清理其中可能的XSS内容
+func (r *Renderer) Render(content string) (string, error) {
+	var buf bytes.Buffer
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	if err := r.md.Convert([]byte(content), &buf, parser.WithContext(ctx)); err != nil {
+		return "", fmt.Errorf("渲染Markdown失败: %w", err)
+	}
+	return policy.Sanitize(buf.String()), nil
+}
+
+var defaultRenderer = New(Options{})
+
+// HTML 使用默认选项渲染Markdown内容
+func HTML(content string) (string, error) {
+	return defaultRenderer.Render(content)
+}
+
+var (
+	styleSheetOnce sync.Once
+	styleSheet     string
+)
+
+// StyleSheet 返回代码高亮所需的CSS
+func StyleSheet() string {
+	styleSheetOnce.Do(func() {
+		var buf bytes.Buffer
+		formatter := chromahtml.New(chromahtml.WithClasses(true))
+		if err := formatter.WriteCSS(&buf, styles.Get(highlightStyle)); err == nil {
+			styleSheet = buf.String()
+		}
+	})
+	return styleSheet
+}
+
+// 清理策略：在UGC策略的基础上放开Markdown扩展需要的元素和属性
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// 标题锚点和脚注的ID可能包含中文
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}:_.-]+$`)).Globally()
+
+	// 代码高亮、脚注和标题锚点使用的class
+	p.AllowAttrs("class").
+		Matching(regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)).
+		OnElements("pre", "code", "span", "div", "a", "sup", "li", "ol", "input")
+
+	// 任务列表的复选框
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+
+	return p
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	content := "# 你好 World\n\n" +
+		"| 名称 | 数量 |\n|:--|--:|\n| 苹果 | 2 |\n\n" +
+		"- [x] 已完成\n- [ ] 未完成\n\n" +
+		"正文[^1]\n\n[^1]: 脚注内容\n\n" +
+		"```go\nfunc main() {}\n```\n"
+
+	html, err := HTML(content)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+
+	expected := []string{
+		`<h1 id="你好-world">`,                             // 保留中文的标题ID
+		`class="heading-anchor"`,                         // 标题锚点
+		`<th align="left">名称</th>`,                       // GFM 表格及对齐
+		`<input checked="" disabled="" type="checkbox">`, // 任务列表
+		`<sup id="fnref:1">`,                             // 脚注引用
+		`<li id="fn:1">`,                                 // 脚注内容
+		`<pre class="chroma">`,                           // 代码高亮
+		`<span class="kd">func</span>`,                   // 关键字高亮
+	}
+	for _, want := range expected {
+		if !strings.Contains(html, want) {
+			t.Errorf("渲染结果缺少 %q，实际为:\n%s", want, html)
+		}
+	}
+}
+
+func TestRenderDuplicateHeadingIDs(t *testing.T) {
+	html, err := HTML("## 标题\n\n## 标题\n")
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if !strings.Contains(html, `id="标题"`) || !strings.Contains(html, `id="标题-1"`) {
+		t.Errorf("重复标题的ID未去重: %s", html)
+	}
+}
+
+func TestRenderLinkRewriting(t *testing.T) {
+	content := "![图](/static/a.png) [页面](/memos/1) [外链](https://example.com/x)"
+
+	html, err := New(Options{BaseURL: "https://blog.example.com/"}).Render(content)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	for _, want := range []string{
+		`src="https://blog.example.com/static/a.png"`,
+		`href="https://blog.example.com/memos/1"`,
+		`href="https://example.com/x"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("绝对链接改写结果缺少 %q，实际为: %s", want, html)
+		}
+	}
+
+	html, err = New(Options{AttachmentPrefix: "/s/token/static/"}).Render(content)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if !strings.Contains(html, `src="/s/token/static/a.png"`) || !strings.Contains(html, `href="/memos/1"`) {
+		t.Errorf("附件前缀改写不正确: %s", html)
+	}
+}
+
+func TestRenderSanitizesXSS(t *testing.T) {
+	cases := []struct {
+		name      string
+		content   string
+		forbidden []string
+	}{
+		{"script标签", "<script>alert(1)</script>", []string{"<script", "alert(1)"}},
+		{"事件属性", `<img src="x.png" onerror="alert(1)">`, []string{"onerror"}},
+		{"javascript链接", "[点我](javascript:alert(1))", []string{"javascript:"}},
+		{"HTML中的javascript链接", `<a href="javascript:alert(1)">点我</a>`, []string{"javascript:"}},
+		{"大小写混淆", `<a href="JaVaScRiPt:alert(1)">点我</a>`, []string{"alert(1)"}},
+		{"实体编码", `<a href="&#106;avascript:alert(1)">点我</a>`, []string{"alert(1)"}},
+		{"data链接", `<a href="data:text/html;base64,PHNjcmlwdD4=">点我</a>`, []string{"data:text/html"}},
+		{"iframe", `<iframe src="https://evil.example.com"></iframe>`, []string{"<iframe"}},
+		{"svg脚本", `<svg onload="alert(1)"><script>alert(1)</script></svg>`, []string{"<svg", "onload", "<script"}},
+		{"style属性", `<p style="background:url(javascript:alert(1))">x</p>`, []string{"style=", "javascript:"}},
+		{"style标签", `<style>body{display:none}</style>`, []string{"<style"}},
+		{"表单", `<form action="https://evil.example.com"><input type="text" name="q"></form>`, []string{"<form", `type="text"`}},
+		{"复选框事件", `<input type="checkbox" onclick="alert(1)">`, []string{"onclick"}},
+		{"object", `<object data="evil.swf"></object>`, []string{"<object"}},
+		{"meta刷新", `<meta http-equiv="refresh" content="0;url=https://evil.example.com">`, []string{"<meta"}},
+		{"class注入脚本", `<span class="x" onmouseover="alert(1)">x</span>`, []string{"onmouseover"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			html, err := HTML(c.content)
+			if err != nil {
+				t.Fatalf("渲染失败: %v", err)
+			}
+			lower := strings.ToLower(html)
+			for _, f := range c.forbidden {
+				if strings.Contains(lower, strings.ToLower(f)) {
+					t.Errorf("清理后仍包含 %q: %s", f, html)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderKeepsSafeHTML(t *testing.T) {
+	html, err := HTML("<details><summary>摘要</summary>详情</details>\n\n<mark>高亮</mark>")
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	for _, want := range []string{"<details>", "<summary>摘要</summary>", "<mark>高亮</mark>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("安全的HTML被错误移除，缺少 %q: %s", want, html)
+		}
+	}
+}
+
+func TestStyleSheet(t *testing.T) {
+	if !strings.Contains(StyleSheet(), ".chroma") {
+		t.Error("代码高亮样式表为空")
+	}
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// linkTransformer 改写链接和图片地址：附件地址替换前缀，站内相对链接补全为绝对地址
+type linkTransformer struct {
+	baseURL          string
+	attachmentPrefix string
+}
+
+func (t *linkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if t.baseURL == "" && t.attachmentPrefix == "" {
+		return
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Image:
+			node.Destination = t.rewrite(node.Destination)
+		case *ast.Link:
+			node.Destination = t.rewrite(node.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func (t *linkTransformer) rewrite(dest []byte) []byte {
+	d := string(dest)
+	if t.attachmentPrefix != "" && strings.HasPrefix(d, "/static/") {
+		return []byte(t.attachmentPrefix + strings.TrimPrefix(d, "/static/"))
+	}
+	if t.baseURL != "" && strings.HasPrefix(d, "/") && !strings.HasPrefix(d, "//") {
+		return []byte(t.baseURL + d)
+	}
+	return dest
+}
+
+// headingAnchorTransformer 在每个标题后追加指向自身的锚点链接
+type headingAnchorTransformer struct{}
+
+func (t *headingAnchorTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, ok := heading.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		idBytes, ok := id.([]byte)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+
+		anchor := ast.NewLink()
+		anchor.Destination = append([]byte("#"), idBytes...)
+		anchor.SetAttributeString("class", []byte("heading-anchor"))
+		anchor.AppendChild(anchor, ast.NewString([]byte("#")))
+		heading.AppendChild(heading, ast.NewString([]byte(" ")))
+		heading.AppendChild(heading, anchor)
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// headingIDs 为标题生成ID，保留中文等非ASCII字符，同一文档内自动去重
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+			dash = false
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			if b.Len() > 0 && !dash {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		base = "heading"
+	}
+	id := base
+	for i := 1; s.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	s.used[id] = true
+	return []byte(id)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = true
+}
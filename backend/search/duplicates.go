@@ -0,0 +1,231 @@
+package search
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// DefaultDuplicateThreshold 判定为近似重复的默认相似度
+const DefaultDuplicateThreshold = 0.7
+
+// MinHash 参数：64 个哈希函数分为 16 段，每段 4 行。
+// 相似度 0.7 的两条备忘录至少在一段中完全相同的概率约为 99.9%
+const (
+	shingleSize = 3
+	numHashes   = 64
+	numBands    = 16
+	bandRows    = numHashes / numBands
+)
+
+// 预览文字的最大长度
+const previewLength = 80
+
+// DuplicateMemo 重复簇中的一条备忘录
+type DuplicateMemo struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"createdAt"`
+	Preview   string    `json:"preview"`
+}
+
+// DuplicateCluster 一组重复或近似重复的备忘录
+type DuplicateCluster struct {
+	Memos      []*DuplicateMemo `json:"memos"`      // 按创建时间从旧到新排序
+	Similarity float64          `json:"similarity"` // 簇内判定为重复的备忘录对中最低的相似度
+	Exact      bool             `json:"exact"`      // 忽略空白后正文完全相同
+}
+
+// 把词元序列切分为相邻 shingleSize 个词元组成的片段，返回片段哈希的集合
+func shingles(content string) map[uint64]bool {
+	tokens := Tokenize(content)
+	set := make(map[uint64]bool)
+	if len(tokens) == 0 {
+		return set
+	}
+	size := min(shingleSize, len(tokens))
+	for i := 0; i+size <= len(tokens); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens[i:i+size], "\x00")))
+		set[h.Sum64()] = true
+	}
+	return set
+}
+
+// splitmix64 混合函数，与不同的种子组合得到一族哈希函数
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// 计算片段集合的 MinHash 签名
+func minhash(set map[uint64]bool) [numHashes]uint64 {
+	var sig [numHashes]uint64
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for shingle := range set {
+		for i := range sig {
+			if h := mix(shingle ^ mix(uint64(i))); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// 两个片段集合的 Jaccard 相似度
+func jaccard(a, b map[uint64]bool) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	inter := 0
+	for x := range a {
+		if b[x] {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// FindDuplicates 用 shingling 和 MinHash 找出正文相似度不低于 threshold 的备忘录簇。
+// MinHash 分段只用于挑选候选对，候选对再用精确的 Jaccard 相似度确认
+func FindDuplicates(memos []*store.Memo, threshold float64) []*DuplicateCluster {
+	type entry struct {
+		memo *store.Memo
+		set  map[uint64]bool
+	}
+	var entries []entry
+	for _, memo := range memos {
+		if set := shingles(memo.Content); len(set) > 0 {
+			entries = append(entries, entry{memo: memo, set: set})
+		}
+	}
+
+	// 签名在某一段完全相同的备忘录成为候选对
+	buckets := make(map[string][]int)
+	for i, e := range entries {
+		sig := minhash(e.set)
+		for band := 0; band < numBands; band++ {
+			key := make([]byte, 2+8*bandRows)
+			binary.BigEndian.PutUint16(key, uint16(band))
+			for row := 0; row < bandRows; row++ {
+				binary.BigEndian.PutUint64(key[2+8*row:], sig[band*bandRows+row])
+			}
+			buckets[string(key)] = append(buckets[string(key)], i)
+		}
+	}
+
+	// 用并查集把确认重复的备忘录连成簇
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	type match struct {
+		a, b       int
+		similarity float64
+	}
+	var matches []match
+	checked := make(map[[2]int]bool)
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				a, b := members[x], members[y]
+				if checked[[2]int{a, b}] {
+					continue
+				}
+				checked[[2]int{a, b}] = true
+				if sim := jaccard(entries[a].set, entries[b].set); sim >= threshold {
+					parent[find(a)] = find(b)
+					matches = append(matches, match{a, b, sim})
+				}
+			}
+		}
+	}
+	minSimilarity := make(map[int]float64)
+	for _, m := range matches {
+		root := find(m.a)
+		if sim, ok := minSimilarity[root]; !ok || m.similarity < sim {
+			minSimilarity[root] = m.similarity
+		}
+	}
+
+	groups := make(map[int][]*store.Memo)
+	for i, e := range entries {
+		root := find(i)
+		groups[root] = append(groups[root], e.memo)
+	}
+
+	clusters := []*DuplicateCluster{}
+	for root, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if !group[i].CreatedAt.Equal(group[j].CreatedAt) {
+				return group[i].CreatedAt.Before(group[j].CreatedAt)
+			}
+			return group[i].ID < group[j].ID
+		})
+		cluster := &DuplicateCluster{Similarity: minSimilarity[root], Exact: true}
+		first := normalizeSpace(group[0].Content)
+		for _, memo := range group {
+			if normalizeSpace(memo.Content) != first {
+				cluster.Exact = false
+			}
+			cluster.Memos = append(cluster.Memos, &DuplicateMemo{
+				ID:        memo.ID,
+				Title:     memo.Title,
+				Tags:      memo.Tags,
+				CreatedAt: memo.CreatedAt,
+				Preview:   preview(memo.Content),
+			})
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	// 完全重复的在前，其次按相似度从高到低
+	sort.Slice(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		if a.Exact != b.Exact {
+			return a.Exact
+		}
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		return a.Memos[0].ID < b.Memos[0].ID
+	})
+	return clusters
+}
+
+// 合并连续空白
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// 截取正文开头作为预览
+func preview(content string) string {
+	runes := []rune(normalizeSpace(content))
+	if len(runes) <= previewLength {
+		return string(runes)
+	}
+	return string(runes[:previewLength]) + "…"
+}
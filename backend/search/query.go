@@ -0,0 +1,628 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ramblog-app/backend/store"
+)
+
+// 查询语法:
+//
+//	query   = or
+//	or      = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = ( "-" | "NOT" ) unary | primary
+//	primary = "(" or ")" | field ":" value | "\"短语\"" | 词
+//
+// 例如 tag:work -tag:done created:>2024-01 has:attachment is:pinned "exact phrase"
+
+// ParseError 查询语法错误，Pos 为出错位置（从1开始的字符序号）
+type ParseError struct {
+	Pos int    `json:"position"`
+	Msg string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("查询语法错误（第 %d 个字符）: %s", e.Pos, e.Msg)
+}
+
+// Node 查询语法树的节点
+type Node interface {
+	// Match 判断备忘录是否满足条件
+	Match(m *Subject) bool
+	// String 返回节点的规范文本形式，用于调试和测试
+	String() string
+}
+
+// Subject 被查询的备忘录，派生的属性在第一次用到时计算
+type Subject struct {
+	Memo *store.Memo
+
+	text        string
+	attachments []string
+	tasks       []*store.Task
+	links       []store.WikiLink
+	computed    map[string]bool
+}
+
+// NewSubject 包装一条备忘录用于查询
+func NewSubject(memo *store.Memo) *Subject {
+	return &Subject{Memo: memo, computed: make(map[string]bool)}
+}
+
+// 标题和正文合并后的小写文本，空白合并为一个空格
+func (m *Subject) lowerText() string {
+	if !m.computed["text"] {
+		m.text = strings.ToLower(normalizeSpace(m.Memo.Title + " " + m.Memo.Content))
+		m.computed["text"] = true
+	}
+	return m.text
+}
+
+func (m *Subject) attachmentList() []string {
+	if !m.computed["attachments"] {
+		m.attachments = store.ReferencedAttachments(m.Memo.Content)
+		m.computed["attachments"] = true
+	}
+	return m.attachments
+}
+
+func (m *Subject) taskList() []*store.Task {
+	if !m.computed["tasks"] {
+		m.tasks = store.ExtractTasks(m.Memo.ID, m.Memo.Content)
+		m.computed["tasks"] = true
+	}
+	return m.tasks
+}
+
+func (m *Subject) linkList() []store.WikiLink {
+	if !m.computed["links"] {
+		m.links = store.ExtractWikiLinks(m.Memo.Content)
+		m.computed["links"] = true
+	}
+	return m.links
+}
+
+// AndNode 所有子条件都满足
+type AndNode struct{ Children []Node }
+
+func (n *AndNode) Match(m *Subject) bool {
+	for _, child := range n.Children {
+		if !child.Match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *AndNode) String() string { return joinNodes("AND", n.Children) }
+
+// OrNode 任一子条件满足
+type OrNode struct{ Children []Node }
+
+func (n *OrNode) Match(m *Subject) bool {
+	for _, child := range n.Children {
+		if child.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *OrNode) String() string { return joinNodes("OR", n.Children) }
+
+func joinNodes(op string, nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
+
+// NotNode 子条件不满足
+type NotNode struct{ Child Node }
+
+func (n *NotNode) Match(m *Subject) bool { return !n.Child.Match(m) }
+
+func (n *NotNode) String() string { return "(NOT " + n.Child.String() + ")" }
+
+// TextNode 标题或正文中包含的文字，忽略大小写
+type TextNode struct {
+	Text   string
+	Phrase bool // 带引号的短语
+}
+
+func (n *TextNode) Match(m *Subject) bool {
+	return strings.Contains(m.lowerText(), strings.ToLower(normalizeSpace(n.Text)))
+}
+
+func (n *TextNode) String() string {
+	if n.Phrase {
+		return fmt.Sprintf("%q", n.Text)
+	}
+	return n.Text
+}
+
+// TagNode 使用了标签或其子标签
+type TagNode struct{ Tag string }
+
+func (n *TagNode) Match(m *Subject) bool {
+	for _, tag := range m.Memo.Tags {
+		if strings.EqualFold(tag, n.Tag) || strings.HasPrefix(strings.ToLower(tag), strings.ToLower(n.Tag)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *TagNode) String() string { return "tag:" + n.Tag }
+
+// DateNode 创建或更新时间在范围 [From, To) 内，零值表示不限
+type DateNode struct {
+	Field    string // created 或 updated
+	From, To time.Time
+	raw      string
+}
+
+func (n *DateNode) Match(m *Subject) bool {
+	t := m.Memo.CreatedAt
+	if n.Field == "updated" {
+		t = m.Memo.UpdatedAt
+	}
+	if !n.From.IsZero() && t.Before(n.From) {
+		return false
+	}
+	if !n.To.IsZero() && !t.Before(n.To) {
+		return false
+	}
+	return true
+}
+
+func (n *DateNode) String() string { return n.Field + ":" + n.raw }
+
+// FieldNode has:、is:、title:、id: 等其他条件
+type FieldNode struct {
+	Field, Value string
+	match        func(m *Subject) bool
+}
+
+func (n *FieldNode) Match(m *Subject) bool { return n.match(m) }
+
+func (n *FieldNode) String() string { return n.Field + ":" + n.Value }
+
+// has: 和 is: 支持的取值
+var (
+	hasMatchers = map[string]func(m *Subject) bool{
+		"attachment": func(m *Subject) bool { return len(m.attachmentList()) > 0 },
+		"task":       func(m *Subject) bool { return len(m.taskList()) > 0 },
+		"open-task": func(m *Subject) bool {
+			for _, task := range m.taskList() {
+				if !task.Done {
+					return true
+				}
+			}
+			return false
+		},
+		"link":  func(m *Subject) bool { return len(m.linkList()) > 0 },
+		"tag":   func(m *Subject) bool { return len(m.Memo.Tags) > 0 },
+		"title": func(m *Subject) bool { return strings.TrimSpace(m.Memo.Title) != "" },
+	}
+	isMatchers = map[string]func(m *Subject) bool{
+		"pinned":   func(m *Subject) bool { return m.Memo.IsPinned() },
+		"archived": func(m *Subject) bool { return m.Memo.IsArchived() },
+		"public":   func(m *Subject) bool { return m.Memo.IsPublic() },
+		"private":  func(m *Subject) bool { return !m.Memo.IsPublic() },
+		"reply":    func(m *Subject) bool { return m.Memo.Parent != nil },
+		"reviewed": func(m *Subject) bool { return m.Memo.Review != nil },
+	}
+)
+
+// 词法单元
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenPhrase
+	tokenField // field:value，value 可以带引号
+	tokenLParen
+	tokenRParen
+	tokenNot // - 前缀或 NOT
+	tokenAnd
+	tokenOr
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // 词、短语或字段值
+	field string
+	pos   int // 从1开始的字符序号
+}
+
+// 把查询切分为词法单元
+func lex(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	i := 0
+
+	// 读取引号中的内容，i 指向左引号
+	readQuoted := func() (string, error) {
+		start := i
+		var b strings.Builder
+		for i++; i < len(runes); i++ {
+			switch runes[i] {
+			case '\\':
+				if i+1 < len(runes) {
+					i++
+					b.WriteRune(runes[i])
+				}
+			case '"':
+				i++
+				return b.String(), nil
+			default:
+				b.WriteRune(runes[i])
+			}
+		}
+		return "", &ParseError{Pos: start + 1, Msg: "引号没有闭合"}
+	}
+	isDelimiter := func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == ')'
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: pos})
+			i++
+		case r == '-' && i+1 < len(runes) && !isDelimiter(runes[i+1]):
+			tokens = append(tokens, token{kind: tokenNot, pos: pos})
+			i++
+		case r == '"':
+			text, err := readQuoted()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, text: text, pos: pos})
+		default:
+			start := i
+			for i < len(runes) && !isDelimiter(runes[i]) && runes[i] != '"' && runes[i] != ':' {
+				i++
+			}
+			word := string(runes[start:i])
+			if i < len(runes) && runes[i] == ':' && isFieldName(word) {
+				i++
+				var value string
+				if i < len(runes) && runes[i] == '"' {
+					var err error
+					if value, err = readQuoted(); err != nil {
+						return nil, err
+					}
+				} else {
+					valueStart := i
+					for i < len(runes) && !isDelimiter(runes[i]) {
+						i++
+					}
+					value = string(runes[valueStart:i])
+				}
+				tokens = append(tokens, token{kind: tokenField, field: strings.ToLower(word), text: value, pos: pos})
+				continue
+			}
+			// 不是字段的冒号和引号作为普通文字
+			for i < len(runes) && !isDelimiter(runes[i]) {
+				i++
+			}
+			word = string(runes[start:i])
+			switch word {
+			case "OR":
+				tokens = append(tokens, token{kind: tokenOr, pos: pos})
+			case "AND":
+				tokens = append(tokens, token{kind: tokenAnd, pos: pos})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot, pos: pos})
+			default:
+				tokens = append(tokens, token{kind: tokenWord, text: word, pos: pos})
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
+
+// 字段名只包含 ASCII 字母
+func isFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// ParseQuery 解析查询，空查询返回 nil
+func ParseQuery(query string) (Node, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &ParseError{Pos: t.pos, Msg: "多余的 )"}
+	}
+	return node, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for p.peek().kind == tokenOr {
+		p.next()
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &OrNode{Children: children}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var children []Node
+	for {
+		t := p.peek()
+		switch t.kind {
+		case tokenEOF, tokenRParen, tokenOr:
+			if len(children) == 0 {
+				return nil, &ParseError{Pos: t.pos, Msg: "缺少查询条件"}
+			}
+			if len(children) == 1 {
+				return children[0], nil
+			}
+			return &AndNode{Children: children}, nil
+		case tokenAnd:
+			if len(children) == 0 {
+				return nil, &ParseError{Pos: t.pos, Msg: "AND 前缺少查询条件"}
+			}
+			p.next()
+			if next := p.peek().kind; next == tokenEOF || next == tokenRParen || next == tokenOr || next == tokenAnd {
+				return nil, &ParseError{Pos: p.peek().pos, Msg: "AND 后缺少查询条件"}
+			}
+			continue
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		t := p.next()
+		switch p.peek().kind {
+		case tokenEOF, tokenRParen, tokenOr, tokenAnd:
+			return nil, &ParseError{Pos: t.pos, Msg: "排除条件后缺少内容"}
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotNode{Child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		if p.peek().kind == tokenRParen {
+			return nil, &ParseError{Pos: t.pos, Msg: "括号中缺少查询条件"}
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenRParen {
+			return nil, &ParseError{Pos: t.pos, Msg: "括号没有闭合"}
+		}
+		p.next()
+		return node, nil
+	case tokenWord:
+		return &TextNode{Text: t.text}, nil
+	case tokenPhrase:
+		if strings.TrimSpace(t.text) == "" {
+			return nil, &ParseError{Pos: t.pos, Msg: "短语不能为空"}
+		}
+		return &TextNode{Text: t.text, Phrase: true}, nil
+	case tokenField:
+		return parseField(t)
+	case tokenRParen:
+		return nil, &ParseError{Pos: t.pos, Msg: "多余的 )"}
+	}
+	return nil, &ParseError{Pos: t.pos, Msg: "缺少查询条件"}
+}
+
+// 值在字段名和冒号之后的位置
+func valuePos(t token) int {
+	return t.pos + utf8.RuneCountInString(t.field) + 1
+}
+
+func parseField(t token) (Node, error) {
+	if t.text == "" {
+		return nil, &ParseError{Pos: valuePos(t), Msg: fmt.Sprintf("%s: 缺少值", t.field)}
+	}
+	switch t.field {
+	case "tag":
+		return &TagNode{Tag: strings.TrimPrefix(t.text, "#")}, nil
+	case "created", "updated":
+		return parseDate(t)
+	case "has":
+		match, ok := hasMatchers[strings.ToLower(t.text)]
+		if !ok {
+			return nil, &ParseError{Pos: valuePos(t), Msg: "未知的 has: 条件: " + t.text + "，可用: " + matcherNames(hasMatchers)}
+		}
+		return &FieldNode{Field: t.field, Value: strings.ToLower(t.text), match: match}, nil
+	case "is":
+		match, ok := isMatchers[strings.ToLower(t.text)]
+		if !ok {
+			return nil, &ParseError{Pos: valuePos(t), Msg: "未知的 is: 条件: " + t.text + "，可用: " + matcherNames(isMatchers)}
+		}
+		return &FieldNode{Field: t.field, Value: strings.ToLower(t.text), match: match}, nil
+	case "title":
+		value := strings.ToLower(normalizeSpace(t.text))
+		return &FieldNode{Field: t.field, Value: t.text, match: func(m *Subject) bool {
+			return strings.Contains(strings.ToLower(normalizeSpace(m.Memo.Title)), value)
+		}}, nil
+	case "id":
+		return &FieldNode{Field: t.field, Value: t.text, match: func(m *Subject) bool {
+			return m.Memo.ID == t.text || strings.HasPrefix(m.Memo.ID, t.text+"-")
+		}}, nil
+	case "parent":
+		return &FieldNode{Field: t.field, Value: t.text, match: func(m *Subject) bool {
+			return m.Memo.ParentID() == t.text
+		}}, nil
+	}
+	// 形如 https://example.com 的网址按普通文字处理
+	if strings.HasPrefix(t.text, "//") {
+		return &TextNode{Text: t.field + ":" + t.text}, nil
+	}
+	return nil, &ParseError{Pos: t.pos, Msg: "未知的字段: " + t.field + "，可用: tag、created、updated、has、is、title、id、parent"}
+}
+
+func matcherNames(matchers map[string]func(m *Subject) bool) string {
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "、")
+}
+
+// 日期支持 2024、2024-01、2024-01-02 三种精度，前面可以带 >、>=、<、<=；
+// 也可以用 2024-01..2024-03 表示包含两端的范围
+func parseDate(t token) (Node, error) {
+	value := t.text
+	pos := valuePos(t)
+	node := &DateNode{Field: t.field, raw: value}
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		start, _, err := parsePeriod(from)
+		if err != nil && from != "" {
+			return nil, &ParseError{Pos: pos, Msg: "无效的日期: " + from}
+		}
+		_, end, err := parsePeriod(to)
+		if err != nil && to != "" {
+			return nil, &ParseError{Pos: pos + utf8.RuneCountInString(from) + 2, Msg: "无效的日期: " + to}
+		}
+		node.From, node.To = start, end
+		return node, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, prefix) {
+			op = prefix
+			value = value[len(prefix):]
+			break
+		}
+	}
+	start, end, err := parsePeriod(value)
+	if err != nil {
+		return nil, &ParseError{Pos: pos + len(op), Msg: "无效的日期: " + value + "，格式为 2024、2024-01 或 2024-01-02"}
+	}
+	switch op {
+	case ">":
+		node.From = end
+	case ">=":
+		node.From = start
+	case "<":
+		node.To = start
+	case "<=":
+		node.To = end
+	default:
+		node.From, node.To = start, end
+	}
+	return node, nil
+}
+
+// 解析年、月或日，返回该时间段的起止时间 [start, end)，使用本地时区
+func parsePeriod(s string) (time.Time, time.Time, error) {
+	for _, layout := range []struct {
+		format string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if len(s) != len(layout.format) {
+			continue
+		}
+		start, err := time.ParseInLocation(layout.format, s, time.Local)
+		if err != nil {
+			continue
+		}
+		return start, start.AddDate(layout.years, layout.months, layout.days), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("无效的日期: %s", s)
+}
+
+// Filter 返回满足查询的备忘录，保持原有顺序。node 为 nil 时返回全部
+func Filter(memos []*store.Memo, node Node) []*store.Memo {
+	if node == nil {
+		return memos
+	}
+	result := []*store.Memo{}
+	for _, memo := range memos {
+		if node.Match(NewSubject(memo)) {
+			result = append(result, memo)
+		}
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"tag:work -tag:done", "(AND tag:work (NOT tag:done))"},
+		{`读书 OR "exact phrase"`, `(OR 读书 "exact phrase")`},
+		{"(tag:a OR tag:b) is:pinned", "(AND (OR tag:a tag:b) is:pinned)"},
+		{"created:>2024-01 has:attachment", "(AND created:>2024-01 has:attachment)"},
+		{`title:"周 报" AND NOT is:archived`, "(AND title:周 报 (NOT is:archived))"},
+		{"https://example.com", "https://example.com"},
+	}
+	for _, c := range cases {
+		node, err := ParseQuery(c.query)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", c.query, err)
+			continue
+		}
+		if got := node.String(); got != c.want {
+			t.Errorf("解析 %q 的结果不正确: 期望 %s, 实际 %s", c.query, c.want, got)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		pos   int
+	}{
+		{`tag:work "未闭合`, 10},
+		{"(tag:a", 1},
+		{"tag:a)", 6},
+		{"tag:a OR", 9},
+		{"标签 foo:bar", 4},
+		{"is:starred", 4},
+		{"created:>2024-13", 10},
+		{"tag:", 5},
+		{"()", 1},
+	}
+	for _, c := range cases {
+		_, err := ParseQuery(c.query)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("解析 %q 应当失败", c.query)
+			continue
+		}
+		if parseErr.Pos != c.pos {
+			t.Errorf("解析 %q 的错误位置不正确: 期望 %d, 实际 %d (%v)", c.query, c.pos, parseErr.Pos, parseErr)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	pinned := true
+	memos := []*store.Memo{
+		{ID: "2024-01-15-1", Tags: []string{"work/项目"}, Content: "会议纪要 ![图](/static/a.png)", CreatedAt: time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local), Pinned: &pinned},
+		{ID: "2024-02-03-1", Tags: []string{"work", "done"}, Content: "Exact  Phrase here", CreatedAt: time.Date(2024, 2, 3, 10, 0, 0, 0, time.Local)},
+		{ID: "2023-12-31-1", Tags: []string{"life"}, Content: "- [ ] 买菜", CreatedAt: time.Date(2023, 12, 31, 10, 0, 0, 0, time.Local)},
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"tag:work -tag:done", []string{"2024-01-15-1"}},
+		{"created:>2024-01", []string{"2024-02-03-1"}},
+		{"created:2024-01", []string{"2024-01-15-1"}},
+		{"created:<2024", []string{"2023-12-31-1"}},
+		{"created:2023-12..2024-01", []string{"2024-01-15-1", "2023-12-31-1"}},
+		{"has:attachment is:pinned", []string{"2024-01-15-1"}},
+		{`"exact phrase"`, []string{"2024-02-03-1"}},
+		{"has:open-task OR 会议", []string{"2024-01-15-1", "2023-12-31-1"}},
+	}
+	for _, c := range cases {
+		node, err := ParseQuery(c.query)
+		if err != nil {
+			t.Fatalf("解析 %q 失败: %v", c.query, err)
+		}
+		got := Filter(memos, node)
+		ids := make([]string, len(got))
+		for i, memo := range got {
+			ids[i] = memo.ID
+		}
+		if !reflect.DeepEqual(ids, c.want) {
+			t.Errorf("查询 %q 的结果不正确: 期望 %v, 实际 %v", c.query, c.want, ids)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package search
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+
+	"ramblog-app/backend/store"
+)
+
+// 标签重合度（Jaccard 系数）在相关度中的权重
+const tagWeight = 0.3
+
+// 标题中的词元计数的倍数
+const titleBoost = 2
+
+// Related 一条相关的备忘录
+type Related struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Score      float64  `json:"score"`      // 综合得分
+	Similarity float64  `json:"similarity"` // TF-IDF 余弦相似度
+	SharedTags []string `json:"sharedTags"` // 共同的标签
+}
+
+// 已索引的备忘录
+type document struct {
+	hash  uint64 // 标题和正文的哈希，用于判断是否需要重新分词
+	title string
+	tags  []string
+	tf    map[string]float64 // 词频
+	norm  float64            // TF-IDF 向量的长度，idf 变化后需要重新计算
+}
+
+// RelatedIndex 保存每条备忘录的词频和全局文档频率。
+// 每次查询前与当前备忘录对比，只重新分词新增或内容变化的备忘录，
+// 因此直接在磁盘上修改的文件也能被发现
+type RelatedIndex struct {
+	mutex     sync.Mutex
+	docs      map[string]*document
+	df        map[string]int // 包含某个词元的备忘录数
+	normDirty bool
+}
+
+// NewRelatedIndex 创建一个空的相关度索引
+func NewRelatedIndex() *RelatedIndex {
+	return &RelatedIndex{
+		docs: make(map[string]*document),
+		df:   make(map[string]int),
+	}
+}
+
+// 计算标题和正文的哈希
+func memoHash(memo *store.Memo) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(memo.Title))
+	h.Write([]byte{0})
+	h.Write([]byte(memo.Content))
+	return h.Sum64()
+}
+
+// 为备忘录计算词频
+func newDocument(memo *store.Memo, hash uint64) *document {
+	doc := &document{
+		hash:  hash,
+		title: memo.Title,
+		tags:  memo.Tags,
+		tf:    make(map[string]float64),
+	}
+	for _, token := range Tokenize(memo.Title) {
+		doc.tf[token] += titleBoost
+	}
+	for _, token := range Tokenize(memo.Content) {
+		doc.tf[token]++
+	}
+	return doc
+}
+
+// 从文档频率中加上或减去一篇文档，调用方需持有锁
+func (idx *RelatedIndex) count(doc *document, delta int) {
+	for token := range doc.tf {
+		idx.df[token] += delta
+		if idx.df[token] <= 0 {
+			delete(idx.df, token)
+		}
+	}
+	idx.normDirty = true
+}
+
+// Sync 使索引与给定的备忘录保持一致：删除不存在的，重新索引新增或内容变化的
+func (idx *RelatedIndex) Sync(memos []*store.Memo) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	seen := make(map[string]bool, len(memos))
+	for _, memo := range memos {
+		seen[memo.ID] = true
+		hash := memoHash(memo)
+		old, ok := idx.docs[memo.ID]
+		if ok && old.hash == hash {
+			old.tags = memo.Tags
+			continue
+		}
+		if ok {
+			idx.count(old, -1)
+		}
+		doc := newDocument(memo, hash)
+		idx.docs[memo.ID] = doc
+		idx.count(doc, 1)
+	}
+	for id, doc := range idx.docs {
+		if !seen[id] {
+			idx.count(doc, -1)
+			delete(idx.docs, id)
+		}
+	}
+}
+
+// 词元的逆文档频率，使用平滑避免出现在所有文档中的词权重为零
+func (idx *RelatedIndex) idf(token string) float64 {
+	return math.Log(1+float64(len(idx.docs))/float64(1+idx.df[token])) + 1
+}
+
+// 文档频率变化后重新计算所有向量的长度，调用方需持有锁
+func (idx *RelatedIndex) updateNorms() {
+	if !idx.normDirty {
+		return
+	}
+	for _, doc := range idx.docs {
+		sum := 0.0
+		for token, tf := range doc.tf {
+			w := tf * idx.idf(token)
+			sum += w * w
+		}
+		doc.norm = math.Sqrt(sum)
+	}
+	idx.normDirty = false
+}
+
+// Related 按 TF-IDF 余弦相似度和标签重合度为其他备忘录打分，返回得分最高的 limit 条。
+// id 不在索引中时返回 nil
+func (idx *RelatedIndex) Related(id string, limit int) []*Related {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	target, ok := idx.docs[id]
+	if !ok {
+		return nil
+	}
+	idx.updateNorms()
+
+	weights := make(map[string]float64, len(target.tf))
+	for token, tf := range target.tf {
+		weights[token] = tf * idx.idf(token)
+	}
+
+	results := []*Related{}
+	for otherID, doc := range idx.docs {
+		if otherID == id {
+			continue
+		}
+
+		similarity := 0.0
+		if target.norm > 0 && doc.norm > 0 {
+			for token, w := range weights {
+				if tf, ok := doc.tf[token]; ok {
+					similarity += w * tf * idx.idf(token)
+				}
+			}
+			similarity /= target.norm * doc.norm
+		}
+
+		shared := sharedTags(target.tags, doc.tags)
+		score := similarity
+		if union := len(target.tags) + len(doc.tags) - len(shared); union > 0 {
+			score += tagWeight * float64(len(shared)) / float64(union)
+		}
+		if score <= 0 {
+			continue
+		}
+		results = append(results, &Related{
+			ID:         otherID,
+			Title:      doc.title,
+			Score:      score,
+			Similarity: similarity,
+			SharedTags: shared,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// 两组标签的交集，保持 a 中的顺序
+func sharedTags(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, tag := range b {
+		in[tag] = true
+	}
+	shared := []string{}
+	for _, tag := range a {
+		if in[tag] {
+			shared = append(shared, tag)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("学习Go语言, Hello 世界! 猫 v2")
+	want := []string{"学习", "go", "语言", "hello", "世界", "猫", "v2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("分词结果不正确: 期望 %v, 实际 %v", want, got)
+	}
+}
+
+func TestRelatedIndex(t *testing.T) {
+	now := time.Now()
+	memos := []*store.Memo{
+		{ID: "a", Content: "今天学习了 Go 语言的并发模型", UpdatedAt: now},
+		{ID: "b", Content: "Go 语言的并发模型和 channel", UpdatedAt: now},
+		{ID: "c", Content: "晚饭吃了火锅", Tags: []string{"生活"}, UpdatedAt: now},
+		{ID: "d", Content: "周末去爬山", Tags: []string{"生活"}, UpdatedAt: now},
+	}
+
+	index := NewRelatedIndex()
+	index.Sync(memos)
+	related := index.Related("a", 10)
+	if len(related) != 1 || related[0].ID != "b" || related[0].Similarity <= 0 {
+		t.Errorf("相关备忘录不正确: %+v", related)
+	}
+
+	// 只有标签相同的备忘录也有得分
+	related = index.Related("c", 10)
+	if len(related) != 1 || related[0].ID != "d" || !reflect.DeepEqual(related[0].SharedTags, []string{"生活"}) {
+		t.Errorf("标签重合的备忘录不正确: %+v", related)
+	}
+
+	// 修改和删除备忘录后索引随之更新
+	memos[2] = &store.Memo{ID: "c", Content: "Go 语言的并发模型总结", UpdatedAt: now}
+	index.Sync(memos[:3])
+	related = index.Related("a", 10)
+	if len(related) != 2 {
+		t.Errorf("更新后的相关备忘录数量不正确: %+v", related)
+	}
+	if index.Related("d", 10) != nil {
+		t.Error("已删除的备忘录不应在索引中")
+	}
+	for token, n := range index.df {
+		if n > 3 {
+			t.Errorf("文档频率未正确更新: %s=%d", token, n)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	now := time.Now()
+	memos := []*store.Memo{
+		{ID: "a", Content: "今天读完了《百年孤独》，印象最深的是马孔多的雨下了四年", CreatedAt: now},
+		{ID: "b", Content: "今天读完了《百年孤独》，印象最深的是马孔多的雨下了四年十一个月", CreatedAt: now.Add(time.Minute)},
+		{ID: "c", Content: "Buy   milk and eggs", CreatedAt: now},
+		{ID: "d", Content: "Buy milk and eggs", CreatedAt: now.Add(time.Hour)},
+		{ID: "e", Content: "完全无关的一条备忘录，讲的是周末爬山", CreatedAt: now},
+	}
+
+	clusters := FindDuplicates(memos, DefaultDuplicateThreshold)
+	if len(clusters) != 2 {
+		t.Fatalf("重复簇数量不正确: 期望 2, 实际 %d", len(clusters))
+	}
+	if !clusters[0].Exact || clusters[0].Memos[0].ID != "c" || clusters[0].Memos[1].ID != "d" {
+		t.Errorf("完全重复的簇不正确: %+v", clusters[0])
+	}
+	near := clusters[1]
+	if near.Exact || len(near.Memos) != 2 || near.Memos[0].ID != "a" || near.Similarity < DefaultDuplicateThreshold {
+		t.Errorf("近似重复的簇不正确: %+v", near)
+	}
+}
@@ -0,0 +1,56 @@
+// Package search 提供本地的分词和文本相似度计算
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 判断是否为中日韩文字，这些文字之间没有空格，按字切分
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Tokenize 将文本切分为词元：拉丁字母和数字按单词切分并转为小写，
+// 连续的中日韩文字切分为相邻两字组成的二元组，单独一个字时保留单字
+func Tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	var cjk []rune
+
+	flushWord := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, strings.ToLower(word.String()))
+			word.Reset()
+		}
+	}
+	flushCJK := func() {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			tokens = append(tokens, string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			flushCJK()
+			word.WriteRune(r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
@@ -0,0 +1,10 @@
+package site
+
+import (
+	"embed"
+)
+
+// Templates 内置的默认页面模板，可通过模板目录逐个覆盖
+//
+//go:embed templates
+var Templates embed.FS
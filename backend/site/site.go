@@ -0,0 +1,632 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ramblog-app/backend/feed"
+	"ramblog-app/backend/render"
+	"ramblog-app/backend/store"
+)
+
+// DefaultPageSize 首页每页显示的备忘录数量
+const DefaultPageSize = 20
+
+// 页面模板名称
+var pageTemplates = []string{"index.html", "memo.html", "tags.html", "archive.html"}
+
+// 输出目录中记录上次生成的文件列表，重新生成时删除这次没有生成的文件
+const manifestFile = ".ramblog-manifest"
+
+// Options 静态站点生成选项
+type Options struct {
+	OutDir      string // 输出目录
+	BaseURL     string // 站点发布后的访问地址
+	Title       string // 站点标题
+	TemplateDir string // 自定义模板目录，其中的同名文件覆盖内置模板
+	PageSize    int    // 首页每页条数，0 表示使用 DefaultPageSize
+}
+
+// Report 汇总一次生成的结果
+type Report struct {
+	Memos       int      `json:"memos"`
+	Pages       int      `json:"pages"`
+	Attachments int      `json:"attachments"`
+	Removed     int      `json:"removed,omitempty"`     // 删除的上次生成、这次不再需要的文件数
+	SkippedTags []string `json:"skippedTags,omitempty"` // 无法作为目录名的标签，不生成标签页
+}
+
+// Builder 将存储中的公开备忘录生成为静态站点
+type Builder struct {
+	store     *store.MemoStore
+	opts      Options
+	basePath  string
+	templates map[string]*template.Template
+	sitemap   []sitemapURL
+	report    Report
+	builtAt   time.Time
+	written   map[string]bool // 这次生成的文件，以 / 分隔的相对路径
+}
+
+// NewBuilder 创建一个新的静态站点生成器
+func NewBuilder(memoStore *store.MemoStore, opts Options) (*Builder, error) {
+	if opts.OutDir == "" {
+		return nil, fmt.Errorf("未指定输出目录")
+	}
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("无效的站点地址: %q", opts.BaseURL)
+	}
+	if opts.Title == "" {
+		opts.Title = "Ramblog"
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+
+	b := &Builder{
+		store:     memoStore,
+		opts:      opts,
+		basePath:  strings.TrimSuffix(base.Path, "/"),
+		templates: make(map[string]*template.Template),
+		builtAt:   time.Now(),
+		written:   make(map[string]bool),
+	}
+	if err := b.loadTemplates(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// 站点信息
+type siteData struct {
+	Title   string
+	BaseURL string
+	BuiltAt time.Time
+}
+
+// 页面中展示的备忘录
+type memoView struct {
+	ID        string
+	Title     string
+	Tags      []string
+	HTML      template.HTML
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type tagView struct {
+	Name  string
+	Count int
+}
+
+type pagination struct {
+	Page  int
+	Total int
+	Prev  string
+	Next  string
+}
+
+type monthArchive struct {
+	Year  int
+	Month int
+	Count int
+	URL   string
+}
+
+type yearArchive struct {
+	Year   int
+	URL    string
+	Months []monthArchive
+}
+
+// 传给模板的页面数据
+type pageData struct {
+	Site       siteData
+	Title      string
+	Heading    string
+	Memos      []*memoView
+	Memo       *memoView
+	Tags       []tagView
+	Archives   []yearArchive
+	Pagination *pagination
+}
+
+// Build 生成整个站点
+func (b *Builder) Build() (*Report, error) {
+	memos, err := b.store.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+
+	public := make([]*store.Memo, 0, len(memos))
+	for _, memo := range memos {
+		if memo.IsPublic() {
+			public = append(public, memo)
+		}
+	}
+	sort.SliceStable(public, func(i, j int) bool {
+		return public[i].CreatedAt.After(public[j].CreatedAt)
+	})
+
+	renderer := render.New(render.Options{BaseURL: b.opts.BaseURL})
+	views := make([]*memoView, 0, len(public))
+	for _, memo := range public {
+		html, err := renderer.Render(memo.Content)
+		if err != nil {
+			return nil, fmt.Errorf("渲染备忘录 %s 失败: %w", memo.ID, err)
+		}
+		views = append(views, &memoView{
+			ID:        memo.ID,
+			Title:     memo.Title,
+			Tags:      memo.Tags,
+			HTML:      template.HTML(html),
+			CreatedAt: memo.CreatedAt,
+			UpdatedAt: memo.UpdatedAt,
+		})
+	}
+	b.report.Memos = len(views)
+
+	steps := []func() error{
+		func() error { return b.buildIndex(views) },
+		func() error { return b.buildMemos(views) },
+		func() error { return b.buildTags(views, public) },
+		func() error { return b.buildArchives(views) },
+		func() error { return b.buildFeeds(public, "", "") },
+		func() error { return b.copyAttachments(public) },
+		b.buildSitemap,
+		b.removeStale,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &b.report, nil
+}
+
+// 生成分页的首页
+func (b *Builder) buildIndex(views []*memoView) error {
+	total := (len(views) + b.opts.PageSize - 1) / b.opts.PageSize
+	if total == 0 {
+		total = 1
+	}
+
+	for page := 1; page <= total; page++ {
+		start := (page - 1) * b.opts.PageSize
+		end := start + b.opts.PageSize
+		if end > len(views) {
+			end = len(views)
+		}
+
+		p := &pagination{Page: page, Total: total}
+		if page > 1 {
+			p.Prev = indexPageURL(page - 1)
+		}
+		if page < total {
+			p.Next = indexPageURL(page + 1)
+		}
+
+		data := b.page("")
+		data.Memos = views[start:end]
+		data.Pagination = p
+		if err := b.writePage(indexPageURL(page), "index.html", data, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 返回首页第n页的地址
+func indexPageURL(page int) string {
+	if page == 1 {
+		return "/"
+	}
+	return fmt.Sprintf("/page/%d/", page)
+}
+
+// 生成每条备忘录的页面
+func (b *Builder) buildMemos(views []*memoView) error {
+	for _, view := range views {
+		data := b.page(view.Title)
+		if data.Title == "" {
+			data.Title = view.ID
+		}
+		data.Memo = view
+		if err := b.writePage("/memos/"+view.ID+".html", "memo.html", data, view.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 生成标签索引页、各标签页以及标签订阅源
+func (b *Builder) buildTags(views []*memoView, memos []*store.Memo) error {
+	// 标签页与标签订阅源一致，包含子标签的备忘录
+	byTag := make(map[string][]*memoView)
+	skipped := make(map[string]bool)
+	for _, view := range views {
+		for _, tag := range view.Tags {
+			if !safeTag(tag) {
+				if !skipped[tag] {
+					skipped[tag] = true
+					b.report.SkippedTags = append(b.report.SkippedTags, tag)
+				}
+				continue
+			}
+			byTag[tag] = nil
+		}
+	}
+	sort.Strings(b.report.SkippedTags)
+	for tag := range byTag {
+		for _, view := range views {
+			if store.HasTagOrChild(view.Tags, tag) {
+				byTag[tag] = append(byTag[tag], view)
+			}
+		}
+	}
+
+	tags := make([]tagView, 0, len(byTag))
+	for tag, tagged := range byTag {
+		tags = append(tags, tagView{Name: tag, Count: len(tagged)})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	data := b.page("标签")
+	data.Tags = tags
+	if err := b.writePage("/tags/", "tags.html", data, time.Time{}); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		data := b.page("#" + tag.Name)
+		data.Heading = "#" + tag.Name
+		data.Memos = byTag[tag.Name]
+		dir := "/tags/" + tag.Name + "/"
+		if err := b.writePage(dir, "index.html", data, time.Time{}); err != nil {
+			return err
+		}
+		if err := b.buildFeeds(memos, tag.Name, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 标签目录下生成的文件，标签的任何一级都不能与之同名，
+// 否则 x/feed.xml 的目录会与 x 的订阅源冲突
+var tagFiles = map[string]bool{"index.html": true, "feed.xml": true, "rss.xml": true, "feed.json": true}
+
+// 标签会作为目录名使用，拒绝可能跳出输出目录或与生成的文件冲突的标签
+func safeTag(tag string) bool {
+	for _, segment := range strings.Split(tag, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, `\:*?"<>|`) || tagFiles[segment] {
+			return false
+		}
+	}
+	return true
+}
+
+// 生成按年、按月的归档页
+func (b *Builder) buildArchives(views []*memoView) error {
+	type monthKey struct{ year, month int }
+	byMonth := make(map[monthKey][]*memoView)
+	byYear := make(map[int][]*memoView)
+	for _, view := range views {
+		year, month := view.CreatedAt.Year(), int(view.CreatedAt.Month())
+		byMonth[monthKey{year, month}] = append(byMonth[monthKey{year, month}], view)
+		byYear[year] = append(byYear[year], view)
+	}
+
+	years := make([]int, 0, len(byYear))
+	for year := range byYear {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+
+	archives := make([]yearArchive, 0, len(years))
+	for _, year := range years {
+		archive := yearArchive{Year: year, URL: fmt.Sprintf("/archive/%d/", year)}
+		for month := 12; month >= 1; month-- {
+			tagged, ok := byMonth[monthKey{year, month}]
+			if !ok {
+				continue
+			}
+			m := monthArchive{
+				Year:  year,
+				Month: month,
+				Count: len(tagged),
+				URL:   fmt.Sprintf("/archive/%d/%02d/", year, month),
+			}
+			archive.Months = append(archive.Months, m)
+
+			data := b.page(fmt.Sprintf("%d-%02d", year, month))
+			data.Heading = data.Title
+			data.Memos = tagged
+			if err := b.writePage(m.URL, "index.html", data, time.Time{}); err != nil {
+				return err
+			}
+		}
+		archives = append(archives, archive)
+
+		data := b.page(fmt.Sprintf("%d", year))
+		data.Heading = data.Title
+		data.Memos = byYear[year]
+		if err := b.writePage(archive.URL, "index.html", data, time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	data := b.page("归档")
+	data.Archives = archives
+	return b.writePage("/archive/", "archive.html", data, time.Time{})
+}
+
+// 在dir目录下生成订阅源，tag 非空时只包含该标签
+func (b *Builder) buildFeeds(memos []*store.Memo, tag, dir string) error {
+	if dir == "" {
+		dir = "/"
+	}
+	formats := []struct {
+		name  string
+		build func([]*store.Memo, feed.Options) ([]byte, error)
+	}{
+		{"feed.xml", feed.Atom},
+		{"rss.xml", feed.RSS},
+		{"feed.json", feed.JSON},
+	}
+
+	for _, format := range formats {
+		opts := feed.Options{
+			Title:   b.opts.Title,
+			BaseURL: b.opts.BaseURL,
+			FeedURL: b.absoluteURL(escapePath(dir + format.name)),
+			Tag:     tag,
+			MemoURL: func(id string) string { return b.absoluteURL("/memos/" + id + ".html") },
+		}
+		data, err := format.build(feed.Select(memos, opts), opts)
+		if err != nil {
+			return err
+		}
+		if err := b.writeFile(dir+format.name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 复制公开备忘录中引用的附件，加密的附件以解密后的内容输出
+func (b *Builder) copyAttachments(memos []*store.Memo) error {
+	copied := make(map[string]bool)
+
+	for _, memo := range memos {
+		for _, name := range store.ReferencedAttachments(memo.Content) {
+			if copied[name] {
+				continue
+			}
+
+			in, err := b.store.OpenAttachment(name)
+			if err != nil {
+				// 引用的附件不存在时跳过，不影响其他页面
+				continue
+			}
+			dst := filepath.Join(b.opts.OutDir, "static", filepath.FromSlash(name))
+			err = copyFile(in, dst)
+			in.Close()
+			b.written[path.Join("static", name)] = true
+			if err != nil {
+				return fmt.Errorf("复制附件 %s 失败: %w", name, err)
+			}
+			copied[name] = true
+			b.report.Attachments++
+		}
+	}
+	return nil
+}
+
+func copyFile(in io.Reader, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// 站点地图相关结构
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// 生成 sitemap.xml
+func (b *Builder) buildSitemap() error {
+	data, err := xml.MarshalIndent(sitemapURLSet{URLs: b.sitemap}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化站点地图失败: %w", err)
+	}
+	return b.writeFile("/sitemap.xml", append([]byte(xml.Header), data...))
+}
+
+// 创建带站点信息的页面数据
+func (b *Builder) page(title string) *pageData {
+	return &pageData{
+		Site:  siteData{Title: b.opts.Title, BaseURL: b.opts.BaseURL, BuiltAt: b.builtAt},
+		Title: title,
+	}
+}
+
+// 渲染页面并写入输出目录，同时记录到站点地图
+func (b *Builder) writePage(pagePath, tmpl string, data *pageData, lastMod time.Time) error {
+	var buf strings.Builder
+	if err := b.templates[tmpl].Execute(&buf, data); err != nil {
+		return fmt.Errorf("渲染页面 %s 失败: %w", pagePath, err)
+	}
+
+	filePath := pagePath
+	if strings.HasSuffix(filePath, "/") {
+		filePath += "index.html"
+	}
+	if err := b.writeFile(filePath, []byte(buf.String())); err != nil {
+		return err
+	}
+
+	entry := sitemapURL{Loc: b.absoluteURL(escapePath(pagePath))}
+	if !lastMod.IsZero() {
+		entry.LastMod = lastMod.UTC().Format(time.RFC3339)
+	}
+	b.sitemap = append(b.sitemap, entry)
+	b.report.Pages++
+	return nil
+}
+
+// 将内容写入输出目录下的相对路径
+func (b *Builder) writeFile(relPath string, data []byte) error {
+	fullPath := filepath.Join(b.opts.OutDir, filepath.FromSlash(strings.TrimPrefix(relPath, "/")))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %w", relPath, err)
+	}
+	b.written[strings.TrimPrefix(relPath, "/")] = true
+	return nil
+}
+
+// 删除上次生成、这次没有生成的文件（如改为私有或已删除的备忘录的页面和附件），
+// 再记录这次生成的文件。输出目录中不是生成的文件保持不变
+func (b *Builder) removeStale() error {
+	manifestPath := filepath.Join(b.opts.OutDir, manifestFile)
+	previous, err := os.ReadFile(manifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取文件列表失败: %w", err)
+	}
+	for _, rel := range strings.Split(string(previous), "\n") {
+		if rel == "" || b.written[rel] || !fs.ValidPath(rel) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.opts.OutDir, filepath.FromSlash(rel))); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("删除旧文件 %s 失败: %w", rel, err)
+		}
+		b.report.Removed++
+		// 删除变空的目录
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if os.Remove(filepath.Join(b.opts.OutDir, filepath.FromSlash(dir))) != nil {
+				break
+			}
+		}
+	}
+
+	files := make([]string, 0, len(b.written))
+	for rel := range b.written {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	if err := os.WriteFile(manifestPath, []byte(strings.Join(files, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("写入文件列表失败: %w", err)
+	}
+	return nil
+}
+
+// 站内地址加上站点路径前缀
+func (b *Builder) url(p string) string {
+	return b.basePath + escapePath(p)
+}
+
+// 标签页的地址，没有生成标签页的标签指向标签索引
+func (b *Builder) tagURL(tag string) string {
+	if !safeTag(tag) {
+		return b.url("/tags/")
+	}
+	return b.url("/tags/" + tag + "/")
+}
+
+// 返回站内地址对应的绝对地址
+func (b *Builder) absoluteURL(p string) string {
+	return b.opts.BaseURL + p
+}
+
+// 对路径的每一段进行转义
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// 加载模板，模板目录中存在同名文件时优先使用
+func (b *Builder) loadTemplates() error {
+	layout, err := b.readTemplate("layout.html")
+	if err != nil {
+		return err
+	}
+
+	funcs := template.FuncMap{
+		"url":          b.url,
+		"memoURL":      func(id string) string { return b.url("/memos/" + id + ".html") },
+		"tagURL":       b.tagURL,
+		"date":         func(t time.Time) string { return t.Format("2006-01-02") },
+		"datetime":     func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+		"highlightCSS": func() template.CSS { return template.CSS(render.StyleSheet()) },
+	}
+
+	for _, name := range pageTemplates {
+		page, err := b.readTemplate(name)
+		if err != nil {
+			return err
+		}
+		t, err := template.New(name).Funcs(funcs).Parse(string(layout))
+		if err != nil {
+			return fmt.Errorf("解析模板 layout.html 失败: %w", err)
+		}
+		if _, err := t.Parse(string(page)); err != nil {
+			return fmt.Errorf("解析模板 %s 失败: %w", name, err)
+		}
+		b.templates[name] = t
+	}
+	return nil
+}
+
+func (b *Builder) readTemplate(name string) ([]byte, error) {
+	if b.opts.TemplateDir != "" {
+		data, err := os.ReadFile(filepath.Join(b.opts.TemplateDir, name))
+		if err == nil {
+			return data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("读取模板 %s 失败: %w", name, err)
+		}
+	}
+	data, err := Templates.ReadFile("templates/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("读取内置模板 %s 失败: %w", name, err)
+	}
+	return data, nil
+}
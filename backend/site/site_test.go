@@ -0,0 +1,203 @@
+package site
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// go test ./site -update 重新生成 testdata/golden
+var update = flag.Bool("update", false, "更新 testdata/golden 中的期望输出")
+
+const goldenDir = "testdata/golden"
+
+// 创建包含公开、私有备忘录和附件的存储，时间固定以便比较输出
+func newTestSiteStore(t *testing.T) *store.MemoStore {
+	dir := t.TempDir()
+	memoStore, err := store.NewMemoStore(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	now := time.Date(2023, 12, 30, 8, 0, 0, 0, time.UTC)
+	memoStore.SetClock(func() time.Time { return now })
+
+	for _, id := range []string{"a.png", "unused.png"} {
+		if err := memoStore.CreateAttachment(&store.Attachment{ID: id, Data: []byte("PNG " + id)}); err != nil {
+			t.Fatalf("创建附件失败: %v", err)
+		}
+	}
+	memos := []struct {
+		memo *store.Memo
+		at   time.Time
+	}{
+		{&store.Memo{Content: "年末 ![图](/static/a.png)", Tags: []string{"生活"}, Visibility: store.VisibilityPublic}, now},
+		{&store.Memo{Content: "私有的内容", Tags: []string{"生活"}}, now.Add(time.Hour)},
+		{&store.Memo{Title: "读书笔记", Content: "第一章", Tags: []string{"读书/小说"}, Visibility: store.VisibilityPublic}, time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)},
+		{&store.Memo{Content: "冲突的标签", Tags: []string{"读书", "读书/feed.xml", "index.html"}, Visibility: store.VisibilityPublic}, time.Date(2024, 2, 1, 21, 0, 0, 0, time.UTC)},
+	}
+	for _, m := range memos {
+		now = m.at
+		if err := memoStore.CreateMemo(m.memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+	}
+	return memoStore
+}
+
+// 生成站点，out 为空时输出到新的临时目录
+func buildTestSite(t *testing.T, memoStore *store.MemoStore, templateDir, out string) (string, *Report) {
+	if out == "" {
+		out = filepath.Join(t.TempDir(), "public")
+	}
+	builder, err := NewBuilder(memoStore, Options{
+		OutDir:      out,
+		BaseURL:     "https://example.com/notes/",
+		Title:       "测试站点",
+		TemplateDir: templateDir,
+		PageSize:    2,
+	})
+	if err != nil {
+		t.Fatalf("创建Builder失败: %v", err)
+	}
+	builder.builtAt = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	report, err := builder.Build()
+	if err != nil {
+		t.Fatalf("生成站点失败: %v", err)
+	}
+	return out, report
+}
+
+// 列出目录中的全部文件，返回以 / 分隔的相对路径
+func listFiles(t *testing.T, dir string) []string {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("列出文件失败: %v", err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// 比较输出目录与 testdata 中的期望输出，-update 时改为更新期望输出
+func checkGolden(t *testing.T, out, goldenDir string) {
+	t.Helper()
+	files := listFiles(t, out)
+	if *update {
+		os.RemoveAll(goldenDir)
+		for _, name := range files {
+			data, _ := os.ReadFile(filepath.Join(out, name))
+			target := filepath.Join(goldenDir, filepath.FromSlash(name))
+			os.MkdirAll(filepath.Dir(target), 0755)
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				t.Fatalf("更新期望输出失败: %v", err)
+			}
+		}
+		return
+	}
+
+	if golden := listFiles(t, goldenDir); !reflect.DeepEqual(files, golden) {
+		t.Fatalf("生成的文件不正确:\n期望 %v\n实际 %v", golden, files)
+	}
+	for _, name := range files {
+		got, _ := os.ReadFile(filepath.Join(out, name))
+		expected, _ := os.ReadFile(filepath.Join(goldenDir, filepath.FromSlash(name)))
+		if string(got) != string(expected) {
+			t.Errorf("%s 与期望输出不同（用 -update 更新）:\n%s", name, got)
+		}
+	}
+}
+
+func TestBuildGolden(t *testing.T) {
+	out, report := buildTestSite(t, newTestSiteStore(t), "", "")
+
+	want := Report{Memos: 3, Pages: 15, Attachments: 1, SkippedTags: []string{"index.html", "读书/feed.xml"}}
+	if !reflect.DeepEqual(*report, want) {
+		t.Errorf("生成报告不正确: 期望 %+v, 实际 %+v", want, *report)
+	}
+	checkGolden(t, out, goldenDir)
+}
+
+func TestRebuildAfterUnpublish(t *testing.T) {
+	memoStore := newTestSiteStore(t)
+	out, _ := buildTestSite(t, memoStore, "", "")
+	// 输出目录中不是生成的文件不受影响
+	if err := os.WriteFile(filepath.Join(out, "CNAME"), []byte("example.com"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	// 带附件的备忘录改为私有、读书笔记被删除后重新生成到同一目录
+	memos, _ := memoStore.ListMemos()
+	for _, memo := range memos {
+		switch memo.Title {
+		case "读书笔记":
+			if err := memoStore.DeleteMemo(memo.ID); err != nil {
+				t.Fatalf("删除备忘录失败: %v", err)
+			}
+		case "":
+			if strings.Contains(memo.Content, "a.png") {
+				if err := memoStore.UpdateMemo(memo.ID, &store.Memo{Visibility: store.VisibilityPrivate}); err != nil {
+					t.Fatalf("更新备忘录失败: %v", err)
+				}
+			}
+		}
+	}
+	_, report := buildTestSite(t, memoStore, "", out)
+	if report.Memos != 1 || report.Removed == 0 {
+		t.Errorf("重新生成的报告不正确: %+v", *report)
+	}
+	if _, err := os.Stat(filepath.Join(out, "CNAME")); err != nil {
+		t.Errorf("不应删除不是生成的文件: %v", err)
+	}
+	os.Remove(filepath.Join(out, "CNAME"))
+	checkGolden(t, out, "testdata/golden-unpublished")
+}
+
+func TestBuildTemplateOverride(t *testing.T) {
+	templateDir := t.TempDir()
+	override := `{{define "content"}}<p class="custom">{{with .Memo}}{{.ID}}{{end}}</p>{{end}}`
+	if err := os.WriteFile(filepath.Join(templateDir, "memo.html"), []byte(override), 0644); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+
+	out, _ := buildTestSite(t, newTestSiteStore(t), templateDir, "")
+	memoPage, err := os.ReadFile(filepath.Join(out, "memos", "2024-01-15-1.html"))
+	if err != nil {
+		t.Fatalf("读取备忘录页面失败: %v", err)
+	}
+	if !strings.Contains(string(memoPage), `<p class="custom">2024-01-15-1</p>`) || strings.Contains(string(memoPage), "第一章") {
+		t.Errorf("备忘录页面应使用自定义模板:\n%s", memoPage)
+	}
+	// 其他模板和布局仍使用内置版本
+	if !strings.Contains(string(memoPage), "<title>读书笔记 - 测试站点</title>") {
+		t.Errorf("未覆盖的布局应使用内置模板:\n%s", memoPage)
+	}
+	index, _ := os.ReadFile(filepath.Join(out, "index.html"))
+	if !strings.Contains(string(index), "第一章") {
+		t.Errorf("首页应使用内置模板:\n%s", index)
+	}
+
+	if err := os.WriteFile(filepath.Join(templateDir, "tags.html"), []byte(`{{define "content"}}{{.Missing}}{{end}}`), 0644); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+	builder, err := NewBuilder(newTestSiteStore(t), Options{OutDir: t.TempDir(), BaseURL: "https://example.com", TemplateDir: templateDir})
+	if err != nil {
+		t.Fatalf("创建Builder失败: %v", err)
+	}
+	if _, err := builder.Build(); err == nil {
+		t.Errorf("模板执行出错时应返回错误")
+	}
+}
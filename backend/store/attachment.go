@@ -0,0 +1,29 @@
+package store
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 匹配正文中对附件的引用，附件统一通过 /static/ 访问
+var attachmentRefPattern = regexp.MustCompile(`/static/([^\s()"'<>?#]+)`)
+
+// ReferencedAttachments 返回正文中引用的附件ID（去重，保持出现顺序）
+func ReferencedAttachments(content string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, match := range attachmentRefPattern.FindAllStringSubmatch(content, -1) {
+		id := match[1]
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+		// 拒绝可能跳出附件目录的引用
+		if seen[id] || strings.Contains(id, "..") {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
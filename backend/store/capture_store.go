@@ -0,0 +1,265 @@
+package store
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 收集入口兼容的格式
+const (
+	CaptureFlomo = "flomo" // flomo 的 API 记录（incoming webhook），密钥在地址中
+	CaptureMemos = "memos" // usememos 的创建备忘录接口，密钥作为访问令牌
+)
+
+// 收集入口相关的错误
+var (
+	ErrCaptureNotFound     = errors.New("收集入口不存在")
+	ErrInvalidCapture      = errors.New("收集入口无效")
+	ErrCaptureUnauthorized = errors.New("密钥无效")
+)
+
+// CaptureEndpoint 收集入口：让已有的快捷指令、浏览器插件等工具按 flomo 或 usememos 的格式写入备忘录。
+// 每个入口有自己的密钥，可以单独撤销
+type CaptureEndpoint struct {
+	ID         string     `json:"id" yaml:"id"`
+	Name       string     `json:"name" yaml:"name"`
+	Format     string     `json:"format" yaml:"format"`
+	Secret     string     `json:"secret,omitempty" yaml:"secret"`
+	Tags       []string   `json:"tags,omitempty" yaml:"tags,omitempty"` // 添加到每条收集到的备忘录上
+	CreatedAt  time.Time  `json:"createdAt" yaml:"created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" yaml:"last_used_at,omitempty"`
+	Count      int        `json:"count" yaml:"count"` // 收集到的备忘录数
+}
+
+// CaptureStore 管理收集入口，持久化在数据目录的 captures.yaml 中
+type CaptureStore struct {
+	path      string
+	mutex     sync.Mutex
+	endpoints map[string]*CaptureEndpoint
+}
+
+// NewCaptureStore 创建收集入口存储并加载已有的入口
+func NewCaptureStore(dataDir string) (*CaptureStore, error) {
+	s := &CaptureStore{
+		path:      filepath.Join(dataDir, "captures.yaml"),
+		endpoints: make(map[string]*CaptureEndpoint),
+	}
+
+	var endpoints []*CaptureEndpoint
+	if err := ReadYAMLFile(s.path, &endpoints); err != nil {
+		return nil, fmt.Errorf("读取收集入口文件失败: %w", err)
+	}
+	for _, endpoint := range endpoints {
+		s.endpoints[endpoint.ID] = endpoint
+	}
+	return s, nil
+}
+
+// List 列出全部收集入口，按创建时间排序
+func (s *CaptureStore) List() []*CaptureEndpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	endpoints := make([]*CaptureEndpoint, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		copied := *endpoint
+		endpoints = append(endpoints, &copied)
+	}
+	sortCaptureEndpoints(endpoints)
+	return endpoints
+}
+
+// Get 获取收集入口
+func (s *CaptureStore) Get(id string) (*CaptureEndpoint, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return nil, ErrCaptureNotFound
+	}
+	copied := *endpoint
+	return &copied, nil
+}
+
+// Create 创建收集入口，ID 和密钥由存储生成
+func (s *CaptureStore) Create(endpoint *CaptureEndpoint) (*CaptureEndpoint, error) {
+	created := &CaptureEndpoint{
+		Name:   strings.TrimSpace(endpoint.Name),
+		Format: endpoint.Format,
+		Tags:   endpoint.Tags,
+	}
+	if err := validateCapture(created); err != nil {
+		return nil, err
+	}
+
+	id, err := RandomID(6)
+	if err != nil {
+		return nil, fmt.Errorf("生成收集入口ID失败: %w", err)
+	}
+	secret, err := RandomToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	created.ID = id
+	created.Secret = secret
+	created.CreatedAt = time.Now().Truncate(time.Second)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.endpoints[id] = created
+	if err := s.save(); err != nil {
+		delete(s.endpoints, id)
+		return nil, err
+	}
+	copied := *created
+	return &copied, nil
+}
+
+// Update 更新收集入口的名称和标签，格式和密钥不变
+func (s *CaptureStore) Update(id string, updates *CaptureEndpoint) (*CaptureEndpoint, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.endpoints[id]
+	if !ok {
+		return nil, ErrCaptureNotFound
+	}
+
+	updated := *old
+	updated.Name = strings.TrimSpace(updates.Name)
+	updated.Tags = updates.Tags
+	if err := validateCapture(&updated); err != nil {
+		return nil, err
+	}
+
+	s.endpoints[id] = &updated
+	if err := s.save(); err != nil {
+		s.endpoints[id] = old
+		return nil, err
+	}
+	copied := updated
+	return &copied, nil
+}
+
+// RotateSecret 重新生成密钥，旧的密钥立即失效
+func (s *CaptureStore) RotateSecret(id string) (*CaptureEndpoint, error) {
+	secret, err := RandomToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.endpoints[id]
+	if !ok {
+		return nil, ErrCaptureNotFound
+	}
+	updated := *old
+	updated.Secret = secret
+
+	s.endpoints[id] = &updated
+	if err := s.save(); err != nil {
+		s.endpoints[id] = old
+		return nil, err
+	}
+	copied := updated
+	return &copied, nil
+}
+
+// Delete 删除收集入口，已收集的备忘录不受影响
+func (s *CaptureStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return ErrCaptureNotFound
+	}
+	delete(s.endpoints, id)
+	if err := s.save(); err != nil {
+		s.endpoints[id] = endpoint
+		return err
+	}
+	return nil
+}
+
+// Authenticate 查找指定格式下密钥匹配的收集入口，没有时返回 ErrCaptureUnauthorized
+func (s *CaptureStore) Authenticate(format, secret string) (*CaptureEndpoint, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if secret == "" {
+		return nil, ErrCaptureUnauthorized
+	}
+	for _, endpoint := range s.endpoints {
+		if endpoint.Format == format && subtle.ConstantTimeCompare([]byte(endpoint.Secret), []byte(secret)) == 1 {
+			copied := *endpoint
+			return &copied, nil
+		}
+	}
+	return nil, ErrCaptureUnauthorized
+}
+
+// RecordUse 记录一次收集
+func (s *CaptureStore) RecordUse(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return ErrCaptureNotFound
+	}
+	now := time.Now().Truncate(time.Second)
+	endpoint.LastUsedAt = &now
+	endpoint.Count++
+	return s.save()
+}
+
+// 检查名称和格式，并去掉标签前的 #
+func validateCapture(endpoint *CaptureEndpoint) error {
+	if endpoint.Name == "" {
+		return fmt.Errorf("%w: 名称不能为空", ErrInvalidCapture)
+	}
+	if endpoint.Format != CaptureFlomo && endpoint.Format != CaptureMemos {
+		return fmt.Errorf("%w: 格式只能是 %s 或 %s", ErrInvalidCapture, CaptureFlomo, CaptureMemos)
+	}
+	tags := make([]string, 0, len(endpoint.Tags))
+	for _, tag := range endpoint.Tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if err := ValidateTag(tag); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+		}
+		tags = append(tags, tag)
+	}
+	endpoint.Tags = tags
+	return nil
+}
+
+// 将收集入口写入文件，调用方需持有锁
+func (s *CaptureStore) save() error {
+	endpoints := make([]*CaptureEndpoint, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sortCaptureEndpoints(endpoints)
+
+	return WriteYAMLFile(s.path, endpoints)
+}
+
+func sortCaptureEndpoints(endpoints []*CaptureEndpoint) {
+	sort.Slice(endpoints, func(i, j int) bool {
+		if !endpoints[i].CreatedAt.Equal(endpoints[j].CreatedAt) {
+			return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+		}
+		return endpoints[i].ID < endpoints[j].ID
+	})
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCaptureStore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-capture-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	captures, err := NewCaptureStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建CaptureStore失败: %v", err)
+	}
+
+	if _, err := captures.Create(&CaptureEndpoint{Name: "手机", Format: "evernote"}); !errors.Is(err, ErrInvalidCapture) {
+		t.Errorf("未知的格式应返回 ErrInvalidCapture, 实际 %v", err)
+	}
+	if _, err := captures.Create(&CaptureEndpoint{Name: " ", Format: CaptureFlomo}); !errors.Is(err, ErrInvalidCapture) {
+		t.Errorf("名称为空应返回 ErrInvalidCapture, 实际 %v", err)
+	}
+	phone, err := captures.Create(&CaptureEndpoint{Name: "快捷指令", Format: CaptureFlomo, Tags: []string{"#inbox"}})
+	if err != nil {
+		t.Fatalf("创建收集入口失败: %v", err)
+	}
+	if phone.Secret == "" || phone.Tags[0] != "inbox" {
+		t.Errorf("应生成密钥并去掉标签前的 #: %+v", phone)
+	}
+	browser, err := captures.Create(&CaptureEndpoint{Name: "浏览器插件", Format: CaptureMemos})
+	if err != nil {
+		t.Fatalf("创建收集入口失败: %v", err)
+	}
+
+	// 密钥只在对应的格式下有效
+	if found, err := captures.Authenticate(CaptureFlomo, phone.Secret); err != nil || found.ID != phone.ID {
+		t.Errorf("应找到收集入口: %v", err)
+	}
+	if _, err := captures.Authenticate(CaptureMemos, phone.Secret); !errors.Is(err, ErrCaptureUnauthorized) {
+		t.Errorf("其他格式的密钥应返回 ErrCaptureUnauthorized, 实际 %v", err)
+	}
+	if _, err := captures.Authenticate(CaptureFlomo, ""); !errors.Is(err, ErrCaptureUnauthorized) {
+		t.Errorf("空密钥应返回 ErrCaptureUnauthorized, 实际 %v", err)
+	}
+
+	rotated, err := captures.RotateSecret(browser.ID)
+	if err != nil {
+		t.Fatalf("重新生成密钥失败: %v", err)
+	}
+	if _, err := captures.Authenticate(CaptureMemos, browser.Secret); !errors.Is(err, ErrCaptureUnauthorized) {
+		t.Errorf("旧的密钥应失效, 实际 %v", err)
+	}
+	if err := captures.RecordUse(phone.ID); err != nil {
+		t.Fatalf("记录收集失败: %v", err)
+	}
+
+	// 重新加载后内容保持不变
+	reloaded, err := NewCaptureStore(tempDir)
+	if err != nil {
+		t.Fatalf("重新加载收集入口失败: %v", err)
+	}
+	list := reloaded.List()
+	if len(list) != 2 {
+		t.Fatalf("重新加载的收集入口不正确: %+v", list)
+	}
+	if _, err := reloaded.Authenticate(CaptureMemos, rotated.Secret); err != nil {
+		t.Errorf("新的密钥应有效: %v", err)
+	}
+	if got, _ := reloaded.Get(phone.ID); got.Count != 1 || got.LastUsedAt == nil {
+		t.Errorf("收集次数未保存: %+v", got)
+	}
+
+	if err := reloaded.Delete(phone.ID); err != nil {
+		t.Fatalf("删除收集入口失败: %v", err)
+	}
+	if _, err := reloaded.Authenticate(CaptureFlomo, phone.Secret); !errors.Is(err, ErrCaptureUnauthorized) {
+		t.Errorf("删除后密钥应失效, 实际 %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 变更日志的文件名
+const changeLogFileName = "changes.jsonl"
+
+// 变更类型
+const (
+	ChangeUpsert = "upsert" // 创建或修改
+	ChangeDelete = "delete" // 删除，作为墓碑一直保留
+)
+
+// Change 变更日志中的一条记录。Seq 单调递增，同时作为备忘录的版本号
+type Change struct {
+	Seq      uint64    `json:"seq"`
+	MemoID   string    `json:"memoId"`
+	Op       string    `json:"op"`
+	Time     time.Time `json:"time"`
+	ClientID string    `json:"clientId,omitempty"` // 通过同步新建时客户端的ID，之后的记录沿用
+}
+
+// 保存在数据目录 changes.jsonl 中的变更日志，每行一条记录，只追加。
+// 内存中只保留每个备忘录最新的一条记录，加载时记录过多会压缩文件。
+// 没有自己的锁，读取需持有 MemoStore 的读锁，修改需持有写锁
+type changeLog struct {
+	path    string
+	seq     uint64
+	latest  map[string]Change
+	clients map[string]string // 客户端ID到备忘录ID，重复推送同一新建时返回已创建的备忘录
+}
+
+// 加载变更日志，并与数据目录中的备忘录对照，补记服务器未运行期间新增和删除的备忘录
+func openChangeLog(dataDir string, now time.Time) (*changeLog, error) {
+	l := &changeLog{
+		path:    filepath.Join(dataDir, changeLogFileName),
+		latest:  make(map[string]Change),
+		clients: make(map[string]string),
+	}
+
+	lines := 0
+	data, err := os.ReadFile(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取变更日志失败: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var change Change
+		// 写入中断留下的不完整记录直接忽略
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil || change.MemoID == "" {
+			continue
+		}
+		lines++
+		if change.Seq > l.seq {
+			l.seq = change.Seq
+		}
+		if change.ClientID != "" {
+			l.clients[change.ClientID] = change.MemoID
+		}
+		if latest, ok := l.latest[change.MemoID]; !ok || change.Seq >= latest.Seq {
+			if change.ClientID == "" {
+				change.ClientID = latest.ClientID
+			}
+			l.latest[change.MemoID] = change
+		}
+	}
+	if lines > 2*len(l.latest)+100 {
+		if err := l.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 按修改时间从旧到新补记，使序号大致与时间一致
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据目录失败: %w", err)
+	}
+	type file struct {
+		id      string
+		modTime time.Time
+	}
+	present := make(map[string]bool)
+	var added []file
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".md")
+		present[id] = true
+		if l.latest[id].Op != ChangeUpsert {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			added = append(added, file{id, info.ModTime()})
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].modTime.Before(added[j].modTime) })
+	for _, f := range added {
+		if _, err := l.record(f.id, ChangeUpsert, now); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range l.liveIDs() {
+		if !present[id] {
+			if _, err := l.record(id, ChangeDelete, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l, nil
+}
+
+// 记录一次变更并追加到文件，返回新的序号
+func (l *changeLog) record(id, op string, now time.Time) (uint64, error) {
+	l.seq++
+	change := Change{Seq: l.seq, MemoID: id, Op: op, Time: now.UTC().Truncate(time.Second), ClientID: l.latest[id].ClientID}
+	l.latest[id] = change
+	return change.Seq, l.append(change)
+}
+
+// 把客户端ID记在备忘录最新的记录上，不增加序号。加载时序号相同的后一条记录生效
+func (l *changeLog) recordClient(clientID, id string) error {
+	l.clients[clientID] = id
+	change := l.latest[id]
+	change.ClientID = clientID
+	l.latest[id] = change
+	return l.append(change)
+}
+
+// 返回客户端ID对应的备忘录ID
+func (l *changeLog) clientMemo(clientID string) (string, bool) {
+	id, ok := l.clients[clientID]
+	return id, ok
+}
+
+func (l *changeLog) append(change Change) error {
+	line, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("写入变更日志失败: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("写入变更日志失败: %w", err)
+	}
+	return f.Close()
+}
+
+// 返回备忘录当前的版本号，不存在或已删除时为 0
+func (l *changeLog) version(id string) uint64 {
+	change := l.latest[id]
+	if change.Op != ChangeUpsert {
+		return 0
+	}
+	return change.Seq
+}
+
+// 返回序号大于 cursor 的变更，按序号排序，同一备忘录只有最新的一条
+func (l *changeLog) since(cursor uint64) []Change {
+	var changes []Change
+	for _, change := range l.latest {
+		if change.Seq > cursor {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Seq < changes[j].Seq })
+	return changes
+}
+
+// 没有被删除的备忘录ID
+func (l *changeLog) liveIDs() []string {
+	var ids []string
+	for id, change := range l.latest {
+		if change.Op == ChangeUpsert {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// 只保留每个备忘录最新的记录重写文件。墓碑也保留，任何旧的游标都能得到完整的结果
+func (l *changeLog) compact() error {
+	var buf bytes.Buffer
+	for _, change := range l.since(0) {
+		line, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := writeFileAtomic(l.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("压缩变更日志失败: %w", err)
+	}
+	return nil
+}
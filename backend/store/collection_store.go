@@ -0,0 +1,190 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 智能合集相关的错误
+var (
+	ErrCollectionNotFound = errors.New("合集不存在")
+	ErrInvalidCollection  = errors.New("合集无效")
+)
+
+// Collection 智能合集：保存的命名查询，查询在每次访问时重新求值
+type Collection struct {
+	ID          string    `json:"id" yaml:"id"`
+	Name        string    `json:"name" yaml:"name"`
+	Query       string    `json:"query" yaml:"query"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" yaml:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" yaml:"updated_at"`
+}
+
+// CollectionStore 管理智能合集，持久化在数据目录的 collections.yaml 中。
+// 查询语法由调用方校验，存储只保证名称不为空且不重复
+type CollectionStore struct {
+	path        string
+	mutex       sync.Mutex
+	collections map[string]*Collection
+}
+
+// NewCollectionStore 创建合集存储并加载已有的合集
+func NewCollectionStore(dataDir string) (*CollectionStore, error) {
+	s := &CollectionStore{
+		path:        filepath.Join(dataDir, "collections.yaml"),
+		collections: make(map[string]*Collection),
+	}
+
+	var collections []*Collection
+	if err := ReadYAMLFile(s.path, &collections); err != nil {
+		return nil, fmt.Errorf("读取合集文件失败: %w", err)
+	}
+	for _, collection := range collections {
+		s.collections[collection.ID] = collection
+	}
+	return s, nil
+}
+
+// List 列出全部合集，按名称排序
+func (s *CollectionStore) List() []*Collection {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	collections := make([]*Collection, 0, len(s.collections))
+	for _, collection := range s.collections {
+		copied := *collection
+		collections = append(collections, &copied)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i].Name != collections[j].Name {
+			return collections[i].Name < collections[j].Name
+		}
+		return collections[i].ID < collections[j].ID
+	})
+	return collections
+}
+
+// Get 获取合集
+func (s *CollectionStore) Get(id string) (*Collection, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	collection, ok := s.collections[id]
+	if !ok {
+		return nil, ErrCollectionNotFound
+	}
+	copied := *collection
+	return &copied, nil
+}
+
+// Create 创建合集，ID 和时间由存储生成
+func (s *CollectionStore) Create(collection *Collection) (*Collection, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	created := &Collection{
+		Name:        strings.TrimSpace(collection.Name),
+		Query:       strings.TrimSpace(collection.Query),
+		Description: collection.Description,
+	}
+	if err := s.validateLocked(created); err != nil {
+		return nil, err
+	}
+
+	id, err := RandomID(6)
+	if err != nil {
+		return nil, fmt.Errorf("生成合集ID失败: %w", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	created.ID = id
+	created.CreatedAt = now
+	created.UpdatedAt = now
+
+	s.collections[id] = created
+	if err := s.save(); err != nil {
+		delete(s.collections, id)
+		return nil, err
+	}
+	copied := *created
+	return &copied, nil
+}
+
+// Update 更新合集的名称、查询和描述
+func (s *CollectionStore) Update(id string, updates *Collection) (*Collection, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.collections[id]
+	if !ok {
+		return nil, ErrCollectionNotFound
+	}
+
+	updated := *old
+	updated.Name = strings.TrimSpace(updates.Name)
+	updated.Query = strings.TrimSpace(updates.Query)
+	updated.Description = updates.Description
+	if err := s.validateLocked(&updated); err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = time.Now().Truncate(time.Second)
+
+	s.collections[id] = &updated
+	if err := s.save(); err != nil {
+		s.collections[id] = old
+		return nil, err
+	}
+	copied := updated
+	return &copied, nil
+}
+
+// Delete 删除合集，不影响其中的备忘录
+func (s *CollectionStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	collection, ok := s.collections[id]
+	if !ok {
+		return ErrCollectionNotFound
+	}
+	delete(s.collections, id)
+	if err := s.save(); err != nil {
+		s.collections[id] = collection
+		return err
+	}
+	return nil
+}
+
+// 检查名称不为空且不与其他合集重复，调用方需持有锁
+func (s *CollectionStore) validateLocked(collection *Collection) error {
+	if collection.Name == "" {
+		return fmt.Errorf("%w: 名称不能为空", ErrInvalidCollection)
+	}
+	for _, other := range s.collections {
+		if other.ID != collection.ID && strings.EqualFold(other.Name, collection.Name) {
+			return fmt.Errorf("%w: 名称已被合集 %s 使用", ErrInvalidCollection, other.ID)
+		}
+	}
+	return nil
+}
+
+// 将合集写入文件，调用方需持有锁
+func (s *CollectionStore) save() error {
+	collections := make([]*Collection, 0, len(s.collections))
+	for _, collection := range s.collections {
+		collections = append(collections, collection)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		if !collections[i].CreatedAt.Equal(collections[j].CreatedAt) {
+			return collections[i].CreatedAt.Before(collections[j].CreatedAt)
+		}
+		return collections[i].ID < collections[j].ID
+	})
+
+	return WriteYAMLFile(s.path, collections)
+}
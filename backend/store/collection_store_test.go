@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCollectionStore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-collection-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	collections, err := NewCollectionStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建CollectionStore失败: %v", err)
+	}
+
+	work, err := collections.Create(&Collection{Name: " 工作 ", Query: "tag:work -tag:done"})
+	if err != nil {
+		t.Fatalf("创建合集失败: %v", err)
+	}
+	if work.ID == "" || work.Name != "工作" {
+		t.Errorf("创建的合集不正确: %+v", work)
+	}
+	if _, err := collections.Create(&Collection{Name: "工作", Query: "is:pinned"}); !errors.Is(err, ErrInvalidCollection) {
+		t.Errorf("重名的合集应返回 ErrInvalidCollection, 实际 %v", err)
+	}
+	if _, err := collections.Create(&Collection{Name: "", Query: "is:pinned"}); !errors.Is(err, ErrInvalidCollection) {
+		t.Errorf("名称为空的合集应返回 ErrInvalidCollection, 实际 %v", err)
+	}
+	if _, err := collections.Create(&Collection{Name: "阅读", Query: "tag:读书"}); err != nil {
+		t.Fatalf("创建合集失败: %v", err)
+	}
+
+	updated, err := collections.Update(work.ID, &Collection{Name: "工作", Query: "tag:work", Description: "全部工作"})
+	if err != nil {
+		t.Fatalf("更新合集失败: %v", err)
+	}
+	if updated.Query != "tag:work" || !updated.CreatedAt.Equal(work.CreatedAt) {
+		t.Errorf("更新后的合集不正确: %+v", updated)
+	}
+
+	// 重新加载后内容保持不变
+	reloaded, err := NewCollectionStore(tempDir)
+	if err != nil {
+		t.Fatalf("重新加载合集失败: %v", err)
+	}
+	list := reloaded.List()
+	if len(list) != 2 || list[0].Name != "工作" || list[1].Name != "阅读" {
+		t.Fatalf("重新加载的合集不正确: %+v", list)
+	}
+	if list[0].Description != "全部工作" {
+		t.Errorf("合集描述未保存: %+v", list[0])
+	}
+
+	if err := reloaded.Delete(work.ID); err != nil {
+		t.Fatalf("删除合集失败: %v", err)
+	}
+	if _, err := reloaded.Get(work.ID); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("删除后应返回 ErrCollectionNotFound, 实际 %v", err)
+	}
+	if err := reloaded.Delete(work.ID); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("重复删除应返回 ErrCollectionNotFound, 实际 %v", err)
+	}
+}
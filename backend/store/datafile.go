@@ -0,0 +1,67 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RandomID 生成 n 个随机字节的十六进制ID，用于合集、收集入口、webhook 等配置项
+func RandomID(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RandomToken 生成 n 个随机字节的令牌，用 URL 安全的 base64 编码，用于分享链接和密钥
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// 写入临时文件后重命名，避免写入中断留下损坏的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// ReadYAMLFile 读取数据目录中的 YAML 配置文件到 v，文件不存在时不修改 v
+func ReadYAMLFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// WriteYAMLFile 把 v 序列化为 YAML，用 WriteDataFile 原子地写入数据目录
+func WriteYAMLFile(path string, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %w", filepath.Base(path), err)
+	}
+	return WriteDataFile(path, data)
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// 每个数据目录一把锁，由同一目录下的各个存储共用
+var (
+	dirLocksMutex sync.Mutex
+	dirLocks      = make(map[string]*sync.RWMutex)
+)
+
+// DirLock 返回数据目录的写入锁。
+// 写入 MemoStore 之外的文件（标签、分享、合集、收集入口、webhook 等）时持有读锁，
+// Freeze 持有写锁，快照期间这些文件都不会改变
+func DirLock(dataDir string) *sync.RWMutex {
+	if abs, err := filepath.Abs(dataDir); err == nil {
+		dataDir = abs
+	}
+	dataDir = filepath.Clean(dataDir)
+
+	dirLocksMutex.Lock()
+	defer dirLocksMutex.Unlock()
+	lock, ok := dirLocks[dataDir]
+	if !ok {
+		lock = &sync.RWMutex{}
+		dirLocks[dataDir] = lock
+	}
+	return lock
+}
+
+// WriteDataFile 原子地写入数据目录根下的文件，与 Freeze 互斥。
+// 调用方不能在持有 DirLock 时再获取 MemoStore 的锁
+func WriteDataFile(path string, data []byte) error {
+	lock := DirLock(filepath.Dir(path))
+	lock.RLock()
+	defer lock.RUnlock()
+	return writeFileAtomic(path, data)
+}
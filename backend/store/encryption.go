@@ -0,0 +1,607 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"gopkg.in/yaml.v3"
+)
+
+// 加密模式
+const (
+	EncryptionAll     = "all"     // 加密全部备忘录和附件
+	EncryptionPrivate = "private" // 只加密带 private 标签的备忘录
+	EncryptionNone    = "none"    // 解密全部文件，仅在关闭加密的过程中使用
+)
+
+// PrivateTag 加密模式为 private 时需要加密的备忘录标签
+const PrivateTag = "private"
+
+// 密钥文件的名称
+const keyringFileName = "encryption.yaml"
+
+// 加密文件以该魔数开头，之后是 8 字节的密钥ID、24 字节的 nonce 和密文。
+// 以 NUL 开头，不会与以 --- 开头的备忘录文件混淆
+var encryptedMagic = []byte("\x00RBENC1\n")
+
+const (
+	keyIDSize     = 8
+	encryptHeader = 8 + keyIDSize + chacha20poly1305.NonceSizeX
+)
+
+var (
+	// ErrWrongSecret 口令或密钥文件不正确
+	ErrWrongSecret = errors.New("口令或密钥文件不正确")
+	// ErrEncryptionLocked 文件已加密但没有解锁密钥
+	ErrEncryptionLocked = errors.New("数据已加密，需要提供口令或密钥文件")
+	// ErrInvalidEncryptionMode 未知的加密模式
+	ErrInvalidEncryptionMode = errors.New("加密模式只能是 all 或 private")
+)
+
+// Argon2id 参数，保存在密钥文件中，以后调整默认值不影响已有的数据
+type kdfParams struct {
+	Name    string `yaml:"name"`
+	Salt    string `yaml:"salt"`
+	Time    uint32 `yaml:"time"`
+	Memory  uint32 `yaml:"memory"` // KiB
+	Threads uint8  `yaml:"threads"`
+}
+
+var defaultKDF = kdfParams{Name: "argon2id", Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// 用主密钥加密保存的数据密钥
+type wrappedKey struct {
+	ID        string    `yaml:"id"`
+	CreatedAt time.Time `yaml:"created_at"`
+	Key       string    `yaml:"key"`
+}
+
+type keyringFile struct {
+	Version int          `yaml:"version"`
+	Mode    string       `yaml:"mode"`
+	KDF     kdfParams    `yaml:"kdf"`
+	Active  string       `yaml:"active"`
+	Keys    []wrappedKey `yaml:"keys"`
+}
+
+// Keyring 加密存储的密钥。
+// 口令或密钥文件经 Argon2id 派生出主密钥，主密钥只用于加密保存在 encryption.yaml 中的数据密钥；
+// 文件用当前的数据密钥以 XChaCha20-Poly1305 加密，并记录所用密钥的ID，
+// 因此更换口令只需重新加密数据密钥，轮换数据密钥后旧文件仍可读取，直到被重新加密
+type Keyring struct {
+	path  string
+	mutex sync.RWMutex
+	file  keyringFile
+	kek   []byte
+	keys  map[string][]byte
+}
+
+// EncryptionConfigured 判断数据目录是否启用了加密存储
+func EncryptionConfigured(dataDir string) bool {
+	_, err := os.Stat(filepath.Join(dataDir, keyringFileName))
+	return err == nil
+}
+
+// CreateKeyring 为数据目录启用加密，生成新的数据密钥
+func CreateKeyring(dataDir string, secret []byte, mode string) (*Keyring, error) {
+	if mode != EncryptionAll && mode != EncryptionPrivate {
+		return nil, ErrInvalidEncryptionMode
+	}
+	if EncryptionConfigured(dataDir) {
+		return nil, fmt.Errorf("数据目录已启用加密")
+	}
+	k := &Keyring{
+		path: filepath.Join(dataDir, keyringFileName),
+		file: keyringFile{Version: 1, Mode: mode},
+		keys: make(map[string][]byte),
+	}
+	if err := k.setSecret(secret); err != nil {
+		return nil, err
+	}
+	if _, err := k.addKey(); err != nil {
+		return nil, err
+	}
+	if err := k.save(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// UnlockKeyring 用口令或密钥文件的内容解锁数据目录的密钥
+func UnlockKeyring(dataDir string, secret []byte) (*Keyring, error) {
+	path := filepath.Join(dataDir, keyringFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	k := &Keyring{path: path, keys: make(map[string][]byte)}
+	if err := yaml.Unmarshal(data, &k.file); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+	}
+	if k.file.KDF.Name != defaultKDF.Name {
+		return nil, fmt.Errorf("不支持的密钥派生算法: %s", k.file.KDF.Name)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(k.file.KDF.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+	}
+	k.kek = deriveKey(secret, salt, k.file.KDF)
+	for _, wrapped := range k.file.Keys {
+		sealed, err := base64.StdEncoding.DecodeString(wrapped.Key)
+		if err != nil {
+			return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+		}
+		key, err := open(k.kek, sealed, []byte(wrapped.ID))
+		if err != nil {
+			return nil, ErrWrongSecret
+		}
+		k.keys[wrapped.ID] = key
+	}
+	if _, ok := k.keys[k.file.Active]; !ok {
+		return nil, fmt.Errorf("密钥文件中缺少当前密钥 %s", k.file.Active)
+	}
+	return k, nil
+}
+
+// Mode 返回加密模式
+func (k *Keyring) Mode() string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.file.Mode
+}
+
+// SetMode 修改加密模式。修改后需调用 MemoStore.ApplyEncryption 重新处理已有的文件
+func (k *Keyring) SetMode(mode string) error {
+	if mode != EncryptionAll && mode != EncryptionPrivate && mode != EncryptionNone {
+		return ErrInvalidEncryptionMode
+	}
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.file.Mode = mode
+	return k.save()
+}
+
+// ActiveKey 返回当前用于加密的密钥ID
+func (k *Keyring) ActiveKey() string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.file.Active
+}
+
+// Rotate 生成新的数据密钥并用于之后的加密，返回新密钥的ID。
+// 旧密钥仍保留用于读取，调用 MemoStore.ApplyEncryption 重新加密全部文件后可以用 RetireKeys 删除
+func (k *Keyring) Rotate() (string, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	id, err := k.addKey()
+	if err != nil {
+		return "", err
+	}
+	return id, k.save()
+}
+
+// RetireKeys 删除当前密钥以外的数据密钥，返回删除的数量
+func (k *Keyring) RetireKeys() (int, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	kept := k.file.Keys[:0]
+	for _, wrapped := range k.file.Keys {
+		if wrapped.ID == k.file.Active {
+			kept = append(kept, wrapped)
+		} else {
+			delete(k.keys, wrapped.ID)
+		}
+	}
+	removed := len(k.file.Keys) - len(kept)
+	k.file.Keys = kept
+	return removed, k.save()
+}
+
+// ChangeSecret 更换口令或密钥文件，只重新加密数据密钥
+func (k *Keyring) ChangeSecret(secret []byte) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.setSecret(secret); err != nil {
+		return err
+	}
+	for i, wrapped := range k.file.Keys {
+		sealed, err := seal(k.kek, k.keys[wrapped.ID], []byte(wrapped.ID))
+		if err != nil {
+			return err
+		}
+		k.file.Keys[i].Key = base64.StdEncoding.EncodeToString(sealed)
+	}
+	return k.save()
+}
+
+// Remove 删除密钥文件，关闭加密。调用前需确保所有文件都已解密
+func (k *Keyring) Remove() error {
+	lock := DirLock(filepath.Dir(k.path))
+	lock.RLock()
+	defer lock.RUnlock()
+	return os.Remove(k.path)
+}
+
+// 生成新的盐并派生主密钥
+func (k *Keyring) setSecret(secret []byte) error {
+	if len(secret) == 0 {
+		return errors.New("口令不能为空")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	params := defaultKDF
+	params.Salt = base64.StdEncoding.EncodeToString(salt)
+	k.file.KDF = params
+	k.kek = deriveKey(secret, salt, params)
+	return nil
+}
+
+// 生成新的数据密钥并设为当前密钥
+func (k *Keyring) addKey() (string, error) {
+	idBytes := make([]byte, keyIDSize)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(idBytes)
+	sealed, err := seal(k.kek, key, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	k.keys[id] = key
+	k.file.Keys = append(k.file.Keys, wrappedKey{ID: id, CreatedAt: time.Now().Truncate(time.Second), Key: base64.StdEncoding.EncodeToString(sealed)})
+	k.file.Active = id
+	return id, nil
+}
+
+func (k *Keyring) save() error {
+	return WriteYAMLFile(k.path, k.file)
+}
+
+// Encrypt 用当前密钥加密
+func (k *Keyring) Encrypt(plain []byte) ([]byte, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	id, _ := hex.DecodeString(k.file.Active)
+	header := make([]byte, encryptHeader)
+	copy(header, encryptedMagic)
+	copy(header[len(encryptedMagic):], id)
+	nonce := header[len(encryptedMagic)+keyIDSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(k.keys[k.file.Active])
+	if err != nil {
+		return nil, err
+	}
+	// 文件头作为附加数据，防止篡改密钥ID
+	return aead.Seal(header, nonce, plain, header), nil
+}
+
+// Decrypt 解密 Encrypt 生成的数据
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) || len(data) < encryptHeader {
+		return nil, errors.New("不是加密的文件")
+	}
+	k.mutex.RLock()
+	key, ok := k.keys[encryptedKeyID(data)]
+	k.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("缺少密钥 %s", encryptedKeyID(data))
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	header := data[:encryptHeader]
+	plain, err := aead.Open(nil, header[len(encryptedMagic)+keyIDSize:], data[encryptHeader:], header)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败，文件可能已损坏: %w", err)
+	}
+	return plain, nil
+}
+
+// IsEncrypted 判断文件内容是否为加密格式
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic)
+}
+
+func encryptedKeyID(data []byte) string {
+	return hex.EncodeToString(data[len(encryptedMagic) : len(encryptedMagic)+keyIDSize])
+}
+
+func deriveKey(secret, salt []byte, params kdfParams) []byte {
+	return argon2.IDKey(secret, salt, params.Time, params.Memory, params.Threads, chacha20poly1305.KeySize)
+}
+
+// 用主密钥加密数据密钥，nonce 放在密文之前
+func seal(key, plain, additional []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, additional), nil
+}
+
+func open(key, sealed, additional []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("密文过短")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+}
+
+// SetKeyring 启用加密存储。之后写入的文件按密钥的加密模式加密，读取时自动解密
+func (s *MemoStore) SetKeyring(k *Keyring) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keyring = k
+}
+
+// Keyring 返回加密存储的密钥，未启用时为 nil
+func (s *MemoStore) Keyring() *Keyring {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.keyring
+}
+
+// 读取备忘录或附件文件，加密的文件自动解密
+func (s *MemoStore) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsEncrypted(data) {
+		return data, err
+	}
+	if s.keyring == nil {
+		return nil, ErrEncryptionLocked
+	}
+	return s.keyring.Decrypt(data)
+}
+
+// 判断带有这些标签的备忘录是否需要加密
+func (s *MemoStore) encryptsMemo(tags []string) bool {
+	if s.keyring == nil {
+		return false
+	}
+	switch s.keyring.Mode() {
+	case EncryptionAll:
+		return true
+	case EncryptionPrivate:
+		for _, tag := range tags {
+			if tag == PrivateTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 判断附件是否需要加密，只有 all 模式加密附件
+func (s *MemoStore) encryptsAttachments() bool {
+	return s.keyring != nil && s.keyring.Mode() == EncryptionAll
+}
+
+// 按加密模式编码备忘录文件内容
+func (s *MemoStore) encodeMemo(data []byte, tags []string) ([]byte, error) {
+	if !s.encryptsMemo(tags) {
+		return data, nil
+	}
+	return s.keyring.Encrypt(data)
+}
+
+// 按加密模式编码附件内容
+func (s *MemoStore) encodeAttachment(data []byte) ([]byte, error) {
+	if !s.encryptsAttachments() {
+		return data, nil
+	}
+	return s.keyring.Encrypt(data)
+}
+
+// EncryptionReport ApplyEncryption 的结果
+type EncryptionReport struct {
+	Encrypted int // 新加密的文件数
+	Decrypted int // 解密的文件数
+	Rekeyed   int // 改用当前密钥重新加密的文件数
+	Unchanged int
+}
+
+// 需要重新编码的文件，memo 为 false 时是附件
+type encodeTarget struct {
+	path string
+	memo bool
+}
+
+// ApplyEncryption 按当前的加密模式和密钥重新处理数据目录中的全部备忘录（包括回收站）和附件：
+// 需要加密的文件用当前密钥加密，不需要加密的文件解密，保留文件的修改时间。
+// 用于启用、关闭加密，修改加密模式和轮换密钥之后
+func (s *MemoStore) ApplyEncryption() (*EncryptionReport, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	report := &EncryptionReport{}
+	var files []encodeTarget
+	for _, dir := range []string{s.getMemosDir(), s.getTrashDir()} {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+		for _, p := range matches {
+			files = append(files, encodeTarget{path: p, memo: true})
+		}
+	}
+	err := filepath.WalkDir(s.getStaticDir(), func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) == ".tmp" {
+			return err
+		}
+		files = append(files, encodeTarget{path: p})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取附件目录失败: %w", err)
+	}
+
+	for _, f := range files {
+		if err := s.reencodeFile(f.path, f.memo, report); err != nil {
+			return report, fmt.Errorf("处理 %s 失败: %w", f.path, err)
+		}
+	}
+	return report, nil
+}
+
+func (s *MemoStore) reencodeFile(path string, memo bool, report *EncryptionReport) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plain := raw
+	encrypted := IsEncrypted(raw)
+	if encrypted {
+		if plain, err = s.readFile(path); err != nil {
+			return err
+		}
+	}
+
+	want := s.encryptsAttachments()
+	if memo {
+		parsed, err := parseMemoFile(plain, strings.TrimSuffix(filepath.Base(path), ".md"))
+		if err != nil {
+			return err
+		}
+		want = s.encryptsMemo(parsed.Tags)
+	}
+
+	var data []byte
+	switch {
+	case want && encrypted && encryptedKeyID(raw) == s.keyring.ActiveKey(), !want && !encrypted:
+		report.Unchanged++
+		return nil
+	case want:
+		if encrypted {
+			report.Rekeyed++
+		} else {
+			report.Encrypted++
+		}
+		if data, err = s.keyring.Encrypt(plain); err != nil {
+			return err
+		}
+	default:
+		report.Decrypted++
+		data = plain
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	return os.Chtimes(path, info.ModTime(), info.ModTime())
+}
+
+// AttachmentReader 读取附件的内容，加密的附件读取时已解密
+type AttachmentReader interface {
+	io.ReadSeekCloser
+	Stat() (os.FileInfo, error)
+}
+
+// 解密后的附件，内容保存在内存中
+type decryptedAttachment struct {
+	*bytes.Reader
+	info os.FileInfo
+}
+
+func (a *decryptedAttachment) Close() error               { return nil }
+func (a *decryptedAttachment) Stat() (os.FileInfo, error) { return a.info, nil }
+
+// 解密后的附件大小与文件不同
+type decryptedInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (i decryptedInfo) Size() int64 { return i.size }
+
+// 打开附件文件，加密的附件解密到内存中
+func (s *MemoStore) openAttachmentFile(path string) (AttachmentReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, len(encryptedMagic))
+	n, _ := io.ReadFull(f, head)
+	if !IsEncrypted(head[:n]) {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+
+	info, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := s.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptedAttachment{Reader: bytes.NewReader(plain), info: decryptedInfo{FileInfo: info, size: int64(len(plain))}}, nil
+}
+
+// AttachmentFS 以 http.FileSystem 的形式提供附件，加密的附件读取时解密。不列出目录
+func (s *MemoStore) AttachmentFS() http.FileSystem {
+	return attachmentFS{s}
+}
+
+type attachmentFS struct {
+	store *MemoStore
+}
+
+func (fsys attachmentFS) Open(name string) (http.File, error) {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return nil, os.ErrNotExist
+	}
+	f, err := fsys.store.OpenAttachment(name)
+	if err != nil {
+		if !errors.Is(err, ErrEncryptionLocked) {
+			err = os.ErrNotExist
+		}
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return attachmentFile{f}, nil
+}
+
+type attachmentFile struct {
+	AttachmentReader
+}
+
+func (attachmentFile) Readdir(int) ([]os.FileInfo, error) { return nil, nil }
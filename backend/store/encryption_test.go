@@ -0,0 +1,201 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-encryption-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	plain := &Memo{Content: "启用加密之前的备忘录", Visibility: "private"}
+	if err := store.CreateMemo(plain); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if err := store.CreateAttachment(&Attachment{ID: "photo.png", Data: []byte("png data")}); err != nil {
+		t.Fatalf("创建附件失败: %v", err)
+	}
+
+	keyring, err := CreateKeyring(tempDir, []byte("correct horse"), EncryptionAll)
+	if err != nil {
+		t.Fatalf("启用加密失败: %v", err)
+	}
+	store.SetKeyring(keyring)
+	report, err := store.ApplyEncryption()
+	if err != nil {
+		t.Fatalf("加密已有文件失败: %v", err)
+	}
+	if report.Encrypted != 2 {
+		t.Errorf("应加密 2 个文件, 实际 %+v", report)
+	}
+
+	memo := &Memo{Content: "加密后创建的备忘录 #日记", Visibility: "private"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	for _, id := range []string{plain.ID, memo.ID} {
+		data, _ := os.ReadFile(store.getMemoPath(id))
+		if !IsEncrypted(data) || bytes.Contains(data, []byte("备忘录")) {
+			t.Errorf("备忘录 %s 未加密", id)
+		}
+	}
+	raw, _ := os.ReadFile(filepath.Join(store.GetStaticDir(), "photo.png"))
+	if !IsEncrypted(raw) {
+		t.Errorf("附件未加密")
+	}
+
+	// 读取时自动解密
+	got, err := store.GetMemo(memo.ID)
+	if err != nil {
+		t.Fatalf("读取加密的备忘录失败: %v", err)
+	}
+	if got.Content != memo.Content {
+		t.Errorf("解密后的内容不正确: %q", got.Content)
+	}
+	f, err := store.OpenAttachment("photo.png")
+	if err != nil {
+		t.Fatalf("打开加密的附件失败: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	info, _ := f.Stat()
+	f.Close()
+	if string(data) != "png data" || info.Size() != int64(len(data)) {
+		t.Errorf("解密后的附件不正确: %q, 大小 %d", data, info.Size())
+	}
+
+	// 没有密钥时无法读取
+	locked, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	if _, err := locked.GetMemo(memo.ID); !errors.Is(err, ErrEncryptionLocked) {
+		t.Errorf("未解锁时应返回 ErrEncryptionLocked, 实际 %v", err)
+	}
+	if _, err := UnlockKeyring(tempDir, []byte("wrong")); !errors.Is(err, ErrWrongSecret) {
+		t.Errorf("错误的口令应返回 ErrWrongSecret, 实际 %v", err)
+	}
+
+	// 轮换密钥后全部文件改用新密钥，删除旧密钥后仍可读取
+	oldKey := keyring.ActiveKey()
+	if _, err := keyring.Rotate(); err != nil {
+		t.Fatalf("轮换密钥失败: %v", err)
+	}
+	if report, err = store.ApplyEncryption(); err != nil {
+		t.Fatalf("重新加密失败: %v", err)
+	}
+	if report.Rekeyed != 3 {
+		t.Errorf("应更换 3 个文件的密钥, 实际 %+v", report)
+	}
+	if removed, err := keyring.RetireKeys(); err != nil || removed != 1 {
+		t.Errorf("应删除 1 个旧密钥, 实际 %d, %v", removed, err)
+	}
+	if err := keyring.ChangeSecret([]byte("battery staple")); err != nil {
+		t.Fatalf("更换口令失败: %v", err)
+	}
+
+	unlocked, err := UnlockKeyring(tempDir, []byte("battery staple"))
+	if err != nil {
+		t.Fatalf("用新口令解锁失败: %v", err)
+	}
+	if unlocked.ActiveKey() == oldKey {
+		t.Errorf("轮换后当前密钥未改变")
+	}
+	locked.SetKeyring(unlocked)
+	if got, err := locked.GetMemo(plain.ID); err != nil || got.Content != plain.Content {
+		t.Errorf("轮换密钥后读取失败: %v", err)
+	}
+
+	// 关闭加密后文件恢复为明文，修改时间不变
+	before, _ := os.Stat(store.getMemoPath(plain.ID))
+	if err := unlocked.SetMode(EncryptionNone); err != nil {
+		t.Fatalf("修改加密模式失败: %v", err)
+	}
+	if report, err = locked.ApplyEncryption(); err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if report.Decrypted != 3 {
+		t.Errorf("应解密 3 个文件, 实际 %+v", report)
+	}
+	data, _ = os.ReadFile(store.getMemoPath(plain.ID))
+	if IsEncrypted(data) || !bytes.Contains(data, []byte(plain.Content)) {
+		t.Errorf("解密后的文件不是明文: %q", data)
+	}
+	after, _ := os.Stat(store.getMemoPath(plain.ID))
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("加密和解密不应改变修改时间")
+	}
+}
+
+func TestEncryptPrivateMemos(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-encryption-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	keyring, err := CreateKeyring(tempDir, []byte("secret"), EncryptionPrivate)
+	if err != nil {
+		t.Fatalf("启用加密失败: %v", err)
+	}
+	store.SetKeyring(keyring)
+
+	public := &Memo{Content: "普通的备忘录", Visibility: "public"}
+	secret := &Memo{Content: "不想被看到的内容 #private", Visibility: "private"}
+	for _, memo := range []*Memo{public, secret} {
+		if err := store.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+	}
+	if err := store.CreateAttachment(&Attachment{ID: "note.txt", Data: []byte("附件")}); err != nil {
+		t.Fatalf("创建附件失败: %v", err)
+	}
+
+	isEncrypted := func(path string) bool {
+		data, _ := os.ReadFile(path)
+		return IsEncrypted(data)
+	}
+	if isEncrypted(store.getMemoPath(public.ID)) {
+		t.Errorf("没有 private 标签的备忘录不应加密")
+	}
+	if !isEncrypted(store.getMemoPath(secret.ID)) {
+		t.Errorf("带 private 标签的备忘录应加密")
+	}
+	if isEncrypted(filepath.Join(store.GetStaticDir(), "note.txt")) {
+		t.Errorf("private 模式不应加密附件")
+	}
+
+	// 去掉标签后以明文保存，加上标签后加密
+	if err := store.UpdateMemo(secret.ID, &Memo{Content: "可以公开了", Visibility: "private"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	if isEncrypted(store.getMemoPath(secret.ID)) {
+		t.Errorf("去掉 private 标签后不应加密")
+	}
+	if err := store.UpdateMemo(public.ID, &Memo{Content: "改为私密 #private", Visibility: "public"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	if !isEncrypted(store.getMemoPath(public.ID)) {
+		t.Errorf("加上 private 标签后应加密")
+	}
+
+	memos, err := store.ListMemos()
+	if err != nil || len(memos) != 2 {
+		t.Fatalf("列出备忘录失败: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventMemoCreated = "memo.created"
+	EventMemoUpdated = "memo.updated"
+	EventMemoDeleted = "memo.deleted"
+	EventTagsChanged = "tags.changed"
+)
+
+// 事件来源
+const (
+	EventSourceApp  = "app"  // 通过 MemoStore 做出的修改
+	EventSourceDisk = "disk" // 扫描数据目录发现的修改，如在编辑器中修改了文件
+)
+
+// 保留最近的事件数量，断线重连时从中补发
+const eventBacklog = 1000
+
+// 订阅者的缓冲区，写满时断开，客户端重连后从 Last-Event-ID 补发
+const subscriberBuffer = 256
+
+// 扫描数据目录时标签文件使用的键，与备忘录ID区分
+const tagsFileKey = "tags.yaml"
+
+// Event 备忘录或标签的变更事件
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	MemoID  string    `json:"memoId,omitempty"`
+	Version uint64    `json:"version,omitempty"` // 备忘录事件对应的变更序号，见 ChangesSince
+	Tags    []string  `json:"tags,omitempty"`    // 标签事件涉及的标签，为空表示需要重新加载全部标签
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+}
+
+// EventHub 发布事件并保留最近的事件。
+// 事件ID由进程启动时间和序号组成，重启后旧的ID不会被误认为可以续传
+type EventHub struct {
+	mutex       sync.Mutex
+	epoch       string
+	seq         uint64
+	recent      []Event
+	subscribers map[*Subscription]bool
+	touched     map[string]bool // 上次扫描数据目录后 MemoStore 修改过的文件
+	now         func() time.Time
+}
+
+// Subscription 事件订阅，C 被关闭表示订阅已断开
+type Subscription struct {
+	C   <-chan Event
+	ch  chan Event
+	hub *EventHub
+}
+
+func newEventHub(now func() time.Time) *EventHub {
+	return &EventHub{
+		epoch:       strconv.FormatInt(now().UnixMilli(), 36),
+		subscribers: make(map[*Subscription]bool),
+		touched:     make(map[string]bool),
+		now:         now,
+	}
+}
+
+// LastID 返回最近一个事件的ID，还没有事件时为空
+func (h *EventHub) LastID() string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.lastIDLocked()
+}
+
+func (h *EventHub) lastIDLocked() string {
+	if h.seq == 0 {
+		return ""
+	}
+	return h.formatID(h.seq)
+}
+
+func (h *EventHub) formatID(seq uint64) string {
+	return h.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// 解析本进程发出的事件ID
+func (h *EventHub) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// Subscribe 订阅之后的事件。lastID 不为空时同时返回该事件之后的事件；
+// complete 为 false 表示无法补全中间的事件（服务器已重启或事件太旧），客户端需要重新加载全部数据
+func (h *EventHub) Subscribe(lastID string) (sub *Subscription, missed []Event, complete bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	complete = true
+	if lastID != "" && lastID != h.lastIDLocked() {
+		seq, ok := h.parseID(lastID)
+		oldest := h.seq - uint64(len(h.recent))
+		if !ok || seq > h.seq || seq < oldest {
+			complete = false
+		} else {
+			missed = append(missed, h.recent[len(h.recent)-int(h.seq-seq):]...)
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, hub: h}
+	h.subscribers[sub] = true
+	return sub, missed, complete
+}
+
+// Close 取消订阅
+func (sub *Subscription) Close() {
+	sub.hub.mutex.Lock()
+	defer sub.hub.mutex.Unlock()
+	if sub.hub.subscribers[sub] {
+		delete(sub.hub.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// 发布事件。key 是被修改的文件，扫描数据目录时不再把它当作外部修改
+func (h *EventHub) publish(event Event, key string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if key != "" {
+		h.touched[key] = true
+	}
+	h.seq++
+	event.ID = h.formatID(h.seq)
+	if event.Source == "" {
+		event.Source = EventSourceApp
+	}
+	event.Time = h.now()
+
+	h.recent = append(h.recent, event)
+	if len(h.recent) > eventBacklog {
+		h.recent = append(h.recent[:0], h.recent[len(h.recent)-eventBacklog:]...)
+	}
+	for sub := range h.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			// 订阅者处理不过来时断开，客户端重连后补发
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// 取出上次扫描后修改过的文件
+func (h *EventHub) takeTouched() map[string]bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	touched := h.touched
+	h.touched = make(map[string]bool)
+	return touched
+}
+
+// Events 返回备忘录和标签的变更事件
+func (s *MemoStore) Events() *EventHub {
+	return s.events
+}
+
+// 记录变更并发布备忘录事件，调用方需持有写锁
+func (s *MemoStore) memoChanged(eventType, id string) {
+	s.recordMemoChange(eventType, id, EventSourceApp)
+}
+
+func (s *MemoStore) recordMemoChange(eventType, id, source string) {
+	op := ChangeUpsert
+	if eventType == EventMemoDeleted {
+		op = ChangeDelete
+	}
+	// 写入变更日志失败时内存中的记录仍然有效，重启后按数据目录补记
+	version, _ := s.changes.record(id, op, s.now())
+
+	key := id
+	if source != EventSourceApp {
+		key = ""
+	}
+	s.events.publish(Event{Type: eventType, MemoID: id, Version: version, Source: source}, key)
+}
+
+// 发布标签事件
+func (s *MemoStore) tagsChanged(tags ...string) {
+	s.events.publish(Event{Type: EventTagsChanged, Tags: tags}, tagsFileKey)
+}
+
+// 文件的修改时间和大小，用于发现数据目录中的修改
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// WatchDisk 定期扫描数据目录，把不是通过 MemoStore 做出的修改（编辑器、同步工具等）作为事件发布，
+// 直到 ctx 结束
+func (s *MemoStore) WatchDisk(ctx context.Context, interval time.Duration) {
+	s.mutex.Lock()
+	known, err := s.scanFiles()
+	s.events.takeTouched()
+	s.mutex.Unlock()
+	if err != nil {
+		known = make(map[string]fileState)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			known = s.checkDisk(known)
+		}
+	}
+}
+
+// 与上次扫描的结果比较并发布外部修改，返回这次扫描的结果
+func (s *MemoStore) checkDisk(known map[string]fileState) map[string]fileState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, err := s.scanFiles()
+	if err != nil {
+		return known
+	}
+	touched := s.events.takeTouched()
+
+	keys := make([]string, 0, len(current)+len(known))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range known {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if touched[key] {
+			continue
+		}
+		before, existed := known[key]
+		after, exists := current[key]
+		eventType := EventMemoUpdated
+		switch {
+		case !existed:
+			eventType = EventMemoCreated
+		case !exists:
+			eventType = EventMemoDeleted
+		case before == after:
+			continue
+		}
+
+		if key == tagsFileKey {
+			if err := s.tags.load(); err != nil {
+				continue
+			}
+			s.events.publish(Event{Type: EventTagsChanged, Source: EventSourceDisk}, "")
+			continue
+		}
+		s.recordMemoChange(eventType, key, EventSourceDisk)
+	}
+	return current
+}
+
+// 读取备忘录文件和标签文件的状态，调用方需持有锁
+func (s *MemoStore) scanFiles() (map[string]fileState, error) {
+	entries, err := os.ReadDir(s.getMemosDir())
+	if err != nil {
+		return nil, fmt.Errorf("读取数据目录失败: %w", err)
+	}
+	files := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (filepath.Ext(name) != ".md" && name != tagsFileKey) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSuffix(name, ".md")
+		if name == tagsFileKey {
+			key = tagsFileKey
+		}
+		files[key] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return files, nil
+}
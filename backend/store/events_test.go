@@ -0,0 +1,136 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// 读取订阅中已有的事件
+func drainEvents(sub *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case event := <-sub.C:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestStoreEvents(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-events-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	sub, missed, complete := store.Events().Subscribe("")
+	defer sub.Close()
+	if len(missed) != 0 || !complete {
+		t.Fatalf("新的订阅不应有补发的事件")
+	}
+
+	memo := &Memo{Content: "第一条 #想法"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if err := store.UpdateMemo(memo.ID, &Memo{Content: "修改后"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	if err := store.TagRegistry().Set(&TagMeta{Name: "想法", Color: "#ff0000"}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	if err := store.TrashMemo(memo.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+
+	events := drainEvents(sub)
+	want := []string{EventMemoCreated, EventMemoUpdated, EventTagsChanged, EventMemoDeleted}
+	if len(events) != len(want) {
+		t.Fatalf("应收到 %d 个事件, 实际 %+v", len(want), events)
+	}
+	for i, event := range events {
+		if event.Type != want[i] || event.Source != EventSourceApp {
+			t.Errorf("第 %d 个事件应为 %s, 实际 %+v", i, want[i], event)
+		}
+	}
+	if events[0].MemoID != memo.ID || events[2].Tags[0] != "想法" {
+		t.Errorf("事件内容不正确: %+v", events)
+	}
+
+	// 从第一个事件之后续传
+	resumed, missed, complete := store.Events().Subscribe(events[0].ID)
+	resumed.Close()
+	if !complete || len(missed) != 3 || missed[0].ID != events[1].ID {
+		t.Errorf("续传应补发之后的 3 个事件, 实际 %+v", missed)
+	}
+	// 其他进程的事件ID无法续传
+	other, _, complete := store.Events().Subscribe("other-1")
+	other.Close()
+	if complete {
+		t.Errorf("未知的事件ID不应续传")
+	}
+}
+
+func TestWatchDisk(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-events-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	existing := &Memo{Content: "已有的备忘录"}
+	if err := store.CreateMemo(existing); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	known, _ := store.scanFiles()
+	store.Events().takeTouched()
+
+	sub, _, _ := store.Events().Subscribe("")
+	defer sub.Close()
+
+	// 通过 MemoStore 的修改不重复发布
+	created := &Memo{Content: "通过接口创建"}
+	if err := store.CreateMemo(created); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	// 在数据目录中直接修改的文件
+	external := "---\nid: 2024-01-01-1\ncreated_at: 2024-01-01T00:00:00Z\nupdated_at: 2024-01-01T00:00:00Z\n---\n外部添加"
+	if err := os.WriteFile(store.getMemoPath("2024-01-01-1"), []byte(external), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(store.getMemoPath(existing.ID), later, later)
+	os.WriteFile(store.TagRegistry().path, []byte("外部:\n  color: '#00ff00'\n"), 0644)
+
+	store.checkDisk(known)
+	events := drainEvents(sub)
+	if len(events) != 4 || events[0].MemoID != created.ID || events[0].Source != EventSourceApp {
+		t.Fatalf("事件不正确: %+v", events)
+	}
+	got := map[string]Event{}
+	for _, event := range events[1:] {
+		if event.Source != EventSourceDisk {
+			t.Errorf("外部修改的来源应为 disk: %+v", event)
+		}
+		got[event.Type+" "+event.MemoID] = event
+	}
+	for _, key := range []string{EventMemoCreated + " 2024-01-01-1", EventMemoUpdated + " " + existing.ID, EventTagsChanged + " "} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("缺少事件 %s: %+v", key, events)
+		}
+	}
+	if store.TagRegistry().Get("外部") == nil {
+		t.Errorf("标签文件修改后应重新加载")
+	}
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// 标签来源
+const (
+	TagSourceFrontMatter = "frontmatter" // 来自YAML头部的 tags 字段
+	TagSourceContent     = "content"     // 来自正文中的 #标签
+)
+
+// HashtagPolicy 决定正文标签与头部标签如何合并
+type HashtagPolicy string
+
+const (
+	HashtagPolicyMerge       HashtagPolicy = "merge"       // 合并正文标签和头部标签（默认）
+	HashtagPolicyContent     HashtagPolicy = "content"     // 正文中有标签时只使用正文标签
+	HashtagPolicyFrontMatter HashtagPolicy = "frontmatter" // 只使用头部标签，不解析正文
+)
+
+// ParseHashtagPolicy 解析标签合并策略
+func ParseHashtagPolicy(s string) (HashtagPolicy, error) {
+	switch p := HashtagPolicy(s); p {
+	case HashtagPolicyMerge, HashtagPolicyContent, HashtagPolicyFrontMatter:
+		return p, nil
+	case "":
+		return HashtagPolicyMerge, nil
+	}
+	return "", fmt.Errorf("无效的标签合并策略: %s", s)
+}
+
+// 匹配 #标签 和 #父标签/子标签，# 前必须是行首、空白或标点
+var hashtagPattern = regexp.MustCompile(`(?:^|[\s(（\[【,，。;；:：!！?？"“'‘])#([\p{L}\p{N}_\-]+(?:/[\p{L}\p{N}_\-]+)*)`)
+
+// 纯数字的 #123 通常是编号而不是标签
+var numericPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// 只用于解析正文结构，GFM 扩展保证自动链接中的 # 不被识别为标签，也用于查找 [[链接]]
+var hashtagParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// hashtagSpan 表示正文中一个 #标签 的位置，Start/End 为标签名（不含 #）在正文中的字节偏移
+type hashtagSpan struct {
+	Tag        string
+	Start, End int
+}
+
+// 收集正文中的普通文本片段，跳过代码块、行内代码、链接和HTML，源文中相邻的片段合并为一段
+func textRuns(source []byte) []text.Segment {
+	doc := hashtagParser.Parse(text.NewReader(source))
+
+	var runs []text.Segment
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock,
+			*ast.CodeSpan, *ast.Link, *ast.AutoLink, *ast.Image, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			seg := node.Segment
+			if last := len(runs) - 1; last >= 0 && runs[last].Stop == seg.Start {
+				runs[last].Stop = seg.Stop
+			} else {
+				runs = append(runs, seg)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return runs
+}
+
+// 找出正文中所有 #标签 的位置，忽略代码块、行内代码和链接中的内容
+func findHashtags(content string) []hashtagSpan {
+	source := []byte(content)
+	runs := textRuns(source)
+
+	var spans []hashtagSpan
+	for _, run := range runs {
+		value := string(run.Value(source))
+		for _, loc := range hashtagPattern.FindAllStringSubmatchIndex(value, -1) {
+			start, end := loc[2], loc[3]
+			// 去掉首尾的连字符和下划线
+			for start < end && strings.ContainsRune("-_", rune(value[start])) {
+				start++
+			}
+			for end > start && strings.ContainsRune("-_", rune(value[end-1])) {
+				end--
+			}
+			tag := value[start:end]
+			if tag == "" || numericPattern.MatchString(tag) {
+				continue
+			}
+			spans = append(spans, hashtagSpan{Tag: tag, Start: run.Start + start, End: run.Start + end})
+		}
+	}
+	return spans
+}
+
+// ExtractHashtags 提取正文中的 #标签，忽略代码块、行内代码和链接中的内容
+func ExtractHashtags(content string) []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, span := range findHashtags(content) {
+		if !seen[span.Tag] {
+			seen[span.Tag] = true
+			tags = append(tags, span.Tag)
+		}
+	}
+	return tags
+}
+
+// 按映射改写正文中的 #标签，返回改写后的正文
+func rewriteHashtags(content string, rename func(string) (string, bool)) string {
+	spans := findHashtags(content)
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		newTag, ok := rename(span.Tag)
+		if !ok {
+			continue
+		}
+		b.WriteString(content[last:span.Start])
+		b.WriteString(newTag)
+		last = span.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// 按策略合并头部标签和正文标签
+func mergeTags(policy HashtagPolicy, explicit, hashtags []string) []string {
+	switch policy {
+	case HashtagPolicyFrontMatter:
+		return explicit
+	case HashtagPolicyContent:
+		if len(hashtags) > 0 {
+			return hashtags
+		}
+		return explicit
+	}
+
+	merged := make([]string, 0, len(explicit)+len(hashtags))
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, explicit...), hashtags...) {
+		if !seen[tag] {
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+func tagSet(tags []string) map[string]bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return set
+}
+
+// 头部显式设置的标签：正文中没有的标签，加上保存时记录的同时出现在正文中的标签
+func explicitTags(memo *Memo, hashtags []string) []string {
+	fromContent := tagSet(hashtags)
+	recorded := tagSet(memo.explicitTags)
+	explicit := []string{}
+	for _, tag := range memo.Tags {
+		if !fromContent[tag] || recorded[tag] {
+			explicit = append(explicit, tag)
+		}
+	}
+	return explicit
+}
+
+// 显式标签中同时出现在正文里的部分，无法从正文推断来源，需要单独保存
+func overlappingTags(explicit, hashtags []string) []string {
+	fromContent := tagSet(hashtags)
+	var overlap []string
+	for _, tag := range explicit {
+		if fromContent[tag] {
+			overlap = append(overlap, tag)
+			delete(fromContent, tag)
+		}
+	}
+	return overlap
+}
+
+// TagSources 返回备忘录中每个标签的来源，头部和正文中都有的标签两个来源都包含
+func TagSources(memo *Memo) map[string][]string {
+	hashtags := ExtractHashtags(memo.Content)
+	fromContent := tagSet(hashtags)
+	explicit := tagSet(explicitTags(memo, hashtags))
+	sources := make(map[string][]string, len(memo.Tags))
+	for _, tag := range memo.Tags {
+		if explicit[tag] {
+			sources[tag] = append(sources[tag], TagSourceFrontMatter)
+		}
+		if fromContent[tag] {
+			sources[tag] = append(sources[tag], TagSourceContent)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"普通标签", "今天 #读书 很开心 #life", []string{"读书", "life"}},
+		{"行首标签", "#工作\n第二行 #工作 重复", []string{"工作"}},
+		{"层级标签", "记录 #项目/ramblog/后端 进展", []string{"项目/ramblog/后端"}},
+		{"标题不是标签", "# 标题\n\n## 二级标题", []string{}},
+		{"中文标点后", "想法：#灵感，还有（#草稿）", []string{"灵感", "草稿"}},
+		{"单词中间的井号", "C#语言 和 issue#12", []string{}},
+		{"纯数字", "见 #123", []string{}},
+		{"行内代码", "用 `#include` 引入 #c", []string{"c"}},
+		{"代码块", "```\n#不是标签\n```\n\n#是标签", []string{"是标签"}},
+		{"缩进代码块", "    #不是标签\n\n正文 #是标签", []string{"是标签"}},
+		{"链接文本和地址", "[#不是标签](https://example.com/#anchor) #是标签", []string{"是标签"}},
+		{"自动链接", "见 https://example.com/#anchor 和 #是标签", []string{"是标签"}},
+		{"强调中的标签", "**#重要** 事项", []string{"重要"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ExtractHashtags(c.content)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("提取标签不正确: 期望 %v, 实际 %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestMemoStoreHashtags(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-hashtag-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	// 默认策略合并头部标签和正文标签
+	memo := &Memo{Tags: []string{"手动"}, Content: "正文 #自动"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if want := []string{"手动", "自动"}; !reflect.DeepEqual(memo.Tags, want) {
+		t.Errorf("合并后的标签不正确: 期望 %v, 实际 %v", want, memo.Tags)
+	}
+
+	sources := TagSources(memo)
+	if !reflect.DeepEqual(sources["手动"], []string{TagSourceFrontMatter}) || !reflect.DeepEqual(sources["自动"], []string{TagSourceContent}) {
+		t.Errorf("标签来源不正确: %v", sources)
+	}
+
+	// 正文中删除的标签在更新后应当被移除，显式标签保留
+	if err := store.UpdateMemo(memo.ID, &Memo{Content: "改写后 #新标签"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	updated, err := store.GetMemo(memo.ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if want := []string{"手动", "新标签"}; !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("更新后的标签不正确: 期望 %v, 实际 %v", want, updated.Tags)
+	}
+
+	// frontmatter 策略不解析正文
+	store.SetHashtagPolicy(HashtagPolicyFrontMatter)
+	memo2 := &Memo{Tags: []string{"手动"}, Content: "正文 #自动"}
+	if err := store.CreateMemo(memo2); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if want := []string{"手动"}; !reflect.DeepEqual(memo2.Tags, want) {
+		t.Errorf("frontmatter 策略下的标签不正确: 期望 %v, 实际 %v", want, memo2.Tags)
+	}
+
+	// content 策略在正文有标签时只使用正文标签
+	store.SetHashtagPolicy(HashtagPolicyContent)
+	memo3 := &Memo{Tags: []string{"手动"}, Content: "正文 #自动"}
+	if err := store.CreateMemo(memo3); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if want := []string{"自动"}; !reflect.DeepEqual(memo3.Tags, want) {
+		t.Errorf("content 策略下的标签不正确: 期望 %v, 实际 %v", want, memo3.Tags)
+	}
+}
+
+func TestExplicitTagInContent(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-hashtag-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	// 头部和正文中都有的标签，来源两者都包含
+	memo := &Memo{Tags: []string{"读书", "手动"}, Content: "正文 #读书 #自动"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	reloaded, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("重新打开MemoStore失败: %v", err)
+	}
+	saved, _ := reloaded.GetMemo(memo.ID)
+	if got := TagSources(saved)["读书"]; !reflect.DeepEqual(got, []string{TagSourceFrontMatter, TagSourceContent}) {
+		t.Errorf("标签来源不正确: %v", got)
+	}
+
+	// 从正文中删除后，头部设置的标签仍然保留
+	if err := reloaded.UpdateMemo(memo.ID, &Memo{Content: "改写后 #自动"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	updated, _ := reloaded.GetMemo(memo.ID)
+	if want := []string{"读书", "手动", "自动"}; !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("更新后的标签不正确: 期望 %v, 实际 %v", want, updated.Tags)
+	}
+
+	// 显式标签改名后与正文标签重合，仍然记为头部设置
+	if _, err := reloaded.RenameTag("手动", "自动"); err != nil {
+		t.Fatalf("标签改名失败: %v", err)
+	}
+	if err := reloaded.UpdateMemo(memo.ID, &Memo{Content: "没有标签"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	updated, _ = reloaded.GetMemo(memo.ID)
+	if want := []string{"读书", "自动"}; !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("改名后的显式标签应保留: 期望 %v, 实际 %v", want, updated.Tags)
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"time"
+)
+
+// ImportMemos 保存从其他工具导入的备忘录。保留原有的创建和更新时间，按创建日期生成
+// YYYY-MM-DD-N 格式的ID，并把文件修改时间设为更新时间，使列表仍按原来的时间排序。
+// dryRun 为 true 时只分配ID，不写入文件，也不占用序号
+func (s *MemoStore) ImportMemos(memos []*Memo, dryRun bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if dryRun {
+		saved := maps.Clone(s.maxNumberCache)
+		defer func() { s.maxNumberCache = saved }()
+	}
+
+	// 先全部校验，避免写入一半后出错
+	for _, memo := range memos {
+		if err := validateVisibility(memo.Visibility); err != nil {
+			return err
+		}
+	}
+
+	for _, memo := range memos {
+		if memo.CreatedAt.IsZero() {
+			memo.CreatedAt = s.now()
+		}
+		memo.CreatedAt = memo.CreatedAt.Truncate(time.Second)
+		memo.UpdatedAt = memo.UpdatedAt.Truncate(time.Second)
+		if memo.UpdatedAt.Before(memo.CreatedAt) {
+			memo.UpdatedAt = memo.CreatedAt
+		}
+
+		s.applyHashtags(memo, memo.Tags)
+		memo.Pinned = flag(memo.IsPinned())
+		memo.Archived = flag(memo.IsArchived())
+		memo.Parent = nil
+
+		id, err := s.generateIDAt(memo.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("生成备忘录ID失败: %w", err)
+		}
+		memo.ID = id
+		if dryRun {
+			continue
+		}
+
+		if err := s.saveMemoToFile(memo); err != nil {
+			return err
+		}
+		os.Chtimes(s.getMemoPath(id), memo.UpdatedAt, memo.UpdatedAt)
+	}
+	return nil
+}
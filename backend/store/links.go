@@ -0,0 +1,177 @@
+package store
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 匹配 [[目标]] 和 [[目标|显示文字]]
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n|]+)(?:\|([^\[\]\n]*))?\]\]`)
+
+// WikiLink 表示正文中的一个 [[链接]]
+type WikiLink struct {
+	Target string `json:"target"`          // 备忘录ID或标题
+	Label  string `json:"label,omitempty"` // | 后的显示文字
+	Line   int    `json:"line"`            // 所在行号，从1开始
+}
+
+// ExtractWikiLinks 提取正文中的 [[链接]]，忽略代码和普通链接中的内容
+func ExtractWikiLinks(content string) []WikiLink {
+	source := []byte(content)
+	var links []WikiLink
+	for _, run := range textRuns(source) {
+		value := string(run.Value(source))
+		for _, loc := range wikiLinkPattern.FindAllStringSubmatchIndex(value, -1) {
+			target := strings.TrimSpace(value[loc[2]:loc[3]])
+			if target == "" {
+				continue
+			}
+			link := WikiLink{Target: target, Line: strings.Count(content[:run.Start+loc[0]], "\n") + 1}
+			if loc[4] >= 0 {
+				link.Label = strings.TrimSpace(value[loc[4]:loc[5]])
+			}
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// GraphNode 链接图中的备忘录
+type GraphNode struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	Links     int      `json:"links"`     // 指向其他备忘录的链接数
+	Backlinks int      `json:"backlinks"` // 被其他备忘录链接的次数
+}
+
+// GraphEdge 链接图中的一条边，从 Source 指向 Target
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// MissingLink 指向不存在的备忘录的链接
+type MissingLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Line   int    `json:"line"`
+}
+
+// Backlink 链接到某条备忘录的另一条备忘录
+type Backlink struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Line    int    `json:"line"`    // 链接所在行号
+	Context string `json:"context"` // 链接所在的行
+}
+
+// LinkGraph 备忘录之间的链接关系
+type LinkGraph struct {
+	Nodes   []*GraphNode   `json:"nodes"`
+	Edges   []*GraphEdge   `json:"edges"`
+	Missing []*MissingLink `json:"missing"`
+
+	backlinks map[string][]*Backlink
+}
+
+// BuildLinkGraph 根据备忘录构建链接图。链接目标先按ID匹配，再按标题匹配（忽略大小写），
+// 多条备忘录标题相同时取ID最小的一条
+func BuildLinkGraph(memos []*Memo) *LinkGraph {
+	sorted := append([]*Memo(nil), memos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	graph := &LinkGraph{
+		Nodes:     []*GraphNode{},
+		Edges:     []*GraphEdge{},
+		Missing:   []*MissingLink{},
+		backlinks: make(map[string][]*Backlink),
+	}
+	nodes := make(map[string]*GraphNode)
+	titles := make(map[string]string)
+	for _, memo := range sorted {
+		node := &GraphNode{ID: memo.ID, Title: memo.Title, Tags: memo.Tags}
+		if node.Tags == nil {
+			node.Tags = []string{}
+		}
+		nodes[memo.ID] = node
+		graph.Nodes = append(graph.Nodes, node)
+
+		title := strings.ToLower(strings.TrimSpace(memo.Title))
+		if _, exists := titles[title]; title != "" && !exists {
+			titles[title] = memo.ID
+		}
+	}
+
+	for _, memo := range sorted {
+		lines := strings.Split(memo.Content, "\n")
+		linked := make(map[string]bool)
+		for _, link := range ExtractWikiLinks(memo.Content) {
+			target := link.Target
+			if _, ok := nodes[target]; !ok {
+				id, ok := titles[strings.ToLower(target)]
+				if !ok {
+					graph.Missing = append(graph.Missing, &MissingLink{Source: memo.ID, Target: link.Target, Line: link.Line})
+					continue
+				}
+				target = id
+			}
+			if target == memo.ID || linked[target] {
+				continue
+			}
+			linked[target] = true
+
+			graph.Edges = append(graph.Edges, &GraphEdge{Source: memo.ID, Target: target})
+			nodes[memo.ID].Links++
+			nodes[target].Backlinks++
+			graph.backlinks[target] = append(graph.backlinks[target], &Backlink{
+				ID:      memo.ID,
+				Title:   memo.Title,
+				Line:    link.Line,
+				Context: strings.TrimSpace(lines[link.Line-1]),
+			})
+		}
+	}
+	return graph
+}
+
+// Backlinks 返回链接到指定备忘录的其他备忘录
+func (g *LinkGraph) Backlinks(id string) []*Backlink {
+	if links, ok := g.backlinks[id]; ok {
+		return links
+	}
+	return []*Backlink{}
+}
+
+// 缓存的链接图及构建时变更日志的序号，备忘录没有变化时不再重新读取
+type linkGraphCache struct {
+	mutex sync.Mutex
+	seq   uint64
+	graph *LinkGraph
+}
+
+// LinkGraph 返回当前所有备忘录的链接图。
+// 链接图在备忘录变化（包括扫描到的外部修改）前一直缓存，多次调用返回同一个对象，调用方不能修改
+func (s *MemoStore) LinkGraph() (*LinkGraph, error) {
+	s.mutex.RLock()
+	seq := s.changes.seq
+	s.mutex.RUnlock()
+
+	cache := &s.linkGraph
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.graph != nil && cache.seq == seq {
+		return cache.graph, nil
+	}
+
+	// 读取的备忘录不会比 seq 旧；读取期间有新的修改时，下次调用因序号不同而重新构建
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+	cache.graph = BuildLinkGraph(memos)
+	cache.seq = seq
+	return cache.graph, nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractWikiLinks(t *testing.T) {
+	content := "见 [[2024-03-02-1]] 和 [[读书笔记|笔记]]\n`[[代码]]` 不算\n\n```\n[[代码块]]\n```\n第七行 [[ 空格 ]]"
+	want := []WikiLink{
+		{Target: "2024-03-02-1", Line: 1},
+		{Target: "读书笔记", Label: "笔记", Line: 1},
+		{Target: "空格", Line: 7},
+	}
+	if got := ExtractWikiLinks(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("提取链接不正确: 期望 %+v, 实际 %+v", want, got)
+	}
+}
+
+func TestBuildLinkGraph(t *testing.T) {
+	now := time.Now()
+	memos := []*Memo{
+		{ID: "2024-03-01-1", Title: "读书笔记", Content: "开始读书", CreatedAt: now},
+		{ID: "2024-03-02-1", Content: "续 [[读书笔记]]\n又见 [[2024-03-01-1]] 和 [[不存在]]", CreatedAt: now},
+		{ID: "2024-03-03-1", Content: "参考 [[2024-03-02-1]] 和自己 [[2024-03-03-1]]", CreatedAt: now},
+	}
+
+	graph := BuildLinkGraph(memos)
+	wantEdges := []*GraphEdge{
+		{Source: "2024-03-02-1", Target: "2024-03-01-1"},
+		{Source: "2024-03-03-1", Target: "2024-03-02-1"},
+	}
+	if !reflect.DeepEqual(graph.Edges, wantEdges) {
+		t.Errorf("链接图的边不正确: %+v", graph.Edges)
+	}
+	if len(graph.Missing) != 1 || graph.Missing[0].Target != "不存在" || graph.Missing[0].Line != 2 {
+		t.Errorf("缺失链接不正确: %+v", graph.Missing)
+	}
+	if graph.Nodes[0].Backlinks != 1 || graph.Nodes[1].Links != 1 {
+		t.Errorf("节点链接计数不正确: %+v %+v", graph.Nodes[0], graph.Nodes[1])
+	}
+
+	backlinks := graph.Backlinks("2024-03-01-1")
+	if len(backlinks) != 1 || backlinks[0].ID != "2024-03-02-1" || backlinks[0].Context != "续 [[读书笔记]]" {
+		t.Errorf("反向链接不正确: %+v", backlinks)
+	}
+	if len(graph.Backlinks("2024-03-03-1")) != 0 {
+		t.Error("自身链接不应计入反向链接")
+	}
+}
+
+func TestLinkGraphCache(t *testing.T) {
+	memoStore, err := NewMemoStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	target := &Memo{Title: "目标", Content: "正文"}
+	if err := memoStore.CreateMemo(target); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+
+	first, err := memoStore.LinkGraph()
+	if err != nil {
+		t.Fatalf("构建链接图失败: %v", err)
+	}
+	if second, _ := memoStore.LinkGraph(); second != first {
+		t.Error("备忘录没有变化时应复用缓存的链接图")
+	}
+
+	source := &Memo{Content: "见 [[目标]]"}
+	if err := memoStore.CreateMemo(source); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	graph, _ := memoStore.LinkGraph()
+	if graph == first || len(graph.Backlinks(target.ID)) != 1 {
+		t.Errorf("创建备忘录后链接图应更新: %+v", graph.Edges)
+	}
+
+	if err := memoStore.UpdateMemo(source.ID, &Memo{Content: "不再链接"}); err != nil {
+		t.Fatalf("更新备忘录失败: %v", err)
+	}
+	if graph, _ := memoStore.LinkGraph(); len(graph.Backlinks(target.ID)) != 0 {
+		t.Errorf("更新备忘录后链接图应更新: %+v", graph.Edges)
+	}
+
+	if err := memoStore.DeleteMemo(target.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	if graph, _ := memoStore.LinkGraph(); len(graph.Nodes) != 1 {
+		t.Errorf("删除备忘录后链接图应更新: %+v", graph.Nodes)
+	}
+}
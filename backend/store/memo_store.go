@@ -23,6 +23,14 @@ type MemoStore struct {
 	dataDir        string
 	mutex          sync.RWMutex
 	maxNumberCache map[string]int // 日期到最大序号的映射
+	hashtagPolicy  HashtagPolicy  // 正文标签的合并策略
+	tags           *TagRegistry   // 标签元数据和别名
+	keyring        *Keyring       // 加密存储的密钥，为空时不加密
+	shares         *ShareStore    // 删除或移入回收站时撤销分享，为空时不处理
+	events         *EventHub      // 变更事件
+	changes        *changeLog     // 变更日志，用于增量同步
+	linkGraph      linkGraphCache // 缓存的链接图
+	now            func() time.Time
 }
 
 // NewMemoStore 创建一个新的备忘录存储
@@ -34,6 +42,8 @@ func NewMemoStore(dataDir string) (*MemoStore, error) {
 	store := &MemoStore{
 		dataDir:        dataDir,
 		maxNumberCache: make(map[string]int),
+		hashtagPolicy:  HashtagPolicyMerge,
+		now:            time.Now,
 	}
 
 	staticDir := store.GetStaticDir()
@@ -41,6 +51,20 @@ func NewMemoStore(dataDir string) (*MemoStore, error) {
 		return nil, fmt.Errorf("无法创建static目录: %w", err)
 	}
 
+	tags, err := NewTagRegistry(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	store.tags = tags
+	store.events = newEventHub(time.Now)
+	tags.changed = func(name string) { store.tagsChanged(name) }
+
+	changes, err := openChangeLog(dataDir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	store.changes = changes
+
 	// 初始化时扫描一次目录，构建日期到最大序号的映射
 	if err := store.initMaxNumberCache(); err != nil {
 		return nil, fmt.Errorf("初始化序号缓存失败: %w", err)
@@ -49,10 +73,54 @@ func NewMemoStore(dataDir string) (*MemoStore, error) {
 	return store, nil
 }
 
+// SetHashtagPolicy 设置正文 #标签 与头部标签的合并策略
+func (s *MemoStore) SetHashtagPolicy(policy HashtagPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.hashtagPolicy = policy
+}
+
+// SetClock 设置获取当前时间的函数，用于测试
+func (s *MemoStore) SetClock(now func() time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.now = now
+}
+
+// TagRegistry 返回标签元数据注册表
+func (s *MemoStore) TagRegistry() *TagRegistry {
+	return s.tags
+}
+
+// 根据正文中的 #标签 和显式设置的标签计算备忘录的标签，别名统一替换为标签名
+func (s *MemoStore) applyHashtags(memo *Memo, explicit []string) {
+	canonical := func(tag string) (string, bool) {
+		name := s.tags.Canonical(tag)
+		return name, name != tag
+	}
+	normalized := make([]string, 0, len(explicit))
+	for _, tag := range explicit {
+		name, _ := canonical(tag)
+		normalized = append(normalized, name)
+	}
+
+	if s.hashtagPolicy == HashtagPolicyFrontMatter {
+		memo.Tags = mergeTags(HashtagPolicyMerge, normalized, nil)
+		memo.explicitTags = nil
+		return
+	}
+	memo.Content = rewriteHashtags(memo.Content, canonical)
+	hashtags := ExtractHashtags(memo.Content)
+	memo.Tags = mergeTags(s.hashtagPolicy, normalized, hashtags)
+	memo.explicitTags = overlappingTags(normalized, hashtags)
+}
+
 // 初始化日期到最大序号的映射
 func (s *MemoStore) initMaxNumberCache() error {
 	// 读取目录中的所有文件
-	entries, err := os.ReadDir(s.getMemosDir())
+	entries, err := s.readIDEntries()
 	if err != nil {
 		return fmt.Errorf("读取memos目录失败: %w", err)
 	}
@@ -98,6 +166,23 @@ func (s *MemoStore) getStaticDir() string {
 	return filepath.Join(s.dataDir, "static")
 }
 
+// DataDir 返回数据目录的路径
+func (s *MemoStore) DataDir() string {
+	return s.dataDir
+}
+
+// Freeze 在禁止写入的情况下调用 fn，用于获取数据目录的一致快照。
+// 持有 MemoStore 的读锁和数据目录的写锁（见 DirLock），备忘录和其他存储的写入都会等待 fn 返回，
+// 读取不受影响；fn 中不能再调用 MemoStore 或其他存储的写入方法
+func (s *MemoStore) Freeze(fn func(dataDir string) error) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	lock := DirLock(s.dataDir)
+	lock.Lock()
+	defer lock.Unlock()
+	return fn(s.dataDir)
+}
+
 // GetStaticDir 返回静态文件目录的路径
 func (s *MemoStore) GetStaticDir() string {
 	return s.getStaticDir()
@@ -110,8 +195,12 @@ func (s *MemoStore) getAttachmentPath(id string) string {
 
 // 生成新的备忘录ID，格式为 YYYY-MM-DD-Number
 func (s *MemoStore) generateID() (string, error) {
-	now := time.Now()
-	dateStr := now.Format("2006-01-02")
+	return s.generateIDAt(s.now())
+}
+
+// 按指定时间的日期生成备忘录ID
+func (s *MemoStore) generateIDAt(t time.Time) (string, error) {
+	dateStr := t.Format("2006-01-02")
 
 	// 获取当前日期的最大序号，如果不存在则为0
 	maxNumber := s.maxNumberCache[dateStr]
@@ -128,7 +217,7 @@ func (s *MemoStore) generateID() (string, error) {
 	// 检查文件是否已存在（以防万一）
 	if _, err := os.Stat(s.getMemoPath(newID)); err == nil {
 		// 文件已存在，递归调用生成新ID
-		return s.generateID()
+		return s.generateIDAt(t)
 	}
 
 	return newID, nil
@@ -139,6 +228,18 @@ func (s *MemoStore) CreateMemo(memo *Memo) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.createMemoLocked(memo)
+}
+
+// 创建备忘录，调用方需持有写锁
+func (s *MemoStore) createMemoLocked(memo *Memo) error {
+	memo.Parent = parentRef(memo.ParentID())
+	if memo.Parent != nil {
+		if err := s.validateParentLocked("", *memo.Parent); err != nil {
+			return err
+		}
+	}
+
 	// 生成新的ID
 	id, err := s.generateID()
 	if err != nil {
@@ -146,8 +247,18 @@ func (s *MemoStore) CreateMemo(memo *Memo) error {
 	}
 	memo.ID = id
 
+	if err := validateVisibility(memo.Visibility); err != nil {
+		return err
+	}
+
+	// 解析正文中的 #标签
+	s.applyHashtags(memo, memo.Tags)
+
+	memo.Pinned = flag(memo.IsPinned())
+	memo.Archived = flag(memo.IsArchived())
+
 	// 设置时间戳
-	now := time.Now().Truncate(time.Second)
+	now := s.now().Truncate(time.Second)
 	memo.CreatedAt = now
 	memo.UpdatedAt = now
 
@@ -168,25 +279,57 @@ func (s *MemoStore) UpdateMemo(id string, updates *Memo) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.updateMemoLocked(id, updates)
+}
+
+// 更新备忘录，调用方需持有写锁
+func (s *MemoStore) updateMemoLocked(id string, updates *Memo) error {
 	// 先读取现有的memo
 	memo, err := s.readMemoFromFile(id)
 	if err != nil {
 		return err
 	}
 
+	// 取出显式设置的标签，正文中的标签由新的正文重新提取
+	explicit := memo.Tags
+	if s.hashtagPolicy != HashtagPolicyFrontMatter {
+		explicit = explicitTags(memo, ExtractHashtags(memo.Content))
+	}
+
 	// 应用更新
 	if updates.Title != "" {
 		memo.Title = updates.Title
 	}
 	if updates.Tags != nil {
-		memo.Tags = updates.Tags
+		explicit = updates.Tags
 	}
 	if updates.Content != "" {
 		memo.Content = updates.Content
 	}
+	s.applyHashtags(memo, explicit)
+	if updates.Visibility != "" {
+		if err := validateVisibility(updates.Visibility); err != nil {
+			return err
+		}
+		memo.Visibility = updates.Visibility
+	}
+	if updates.Pinned != nil {
+		memo.Pinned = flag(*updates.Pinned)
+	}
+	if updates.Archived != nil {
+		memo.Archived = flag(*updates.Archived)
+	}
+	if updates.Parent != nil && *updates.Parent != memo.ParentID() {
+		if *updates.Parent != "" {
+			if err := s.validateParentLocked(id, *updates.Parent); err != nil {
+				return err
+			}
+		}
+		memo.Parent = parentRef(*updates.Parent)
+	}
 
 	// 更新时间戳
-	memo.UpdatedAt = time.Now().Truncate(time.Second)
+	memo.UpdatedAt = s.now().Truncate(time.Second)
 
 	// 保存更新后的memo
 	return s.saveMemoToFile(memo)
@@ -197,11 +340,48 @@ func (s *MemoStore) DeleteMemo(id string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.deleteMemoLocked(id)
+}
+
+// SetShareStore 设置分享存储，删除备忘录或移入回收站时撤销它的分享
+func (s *MemoStore) SetShareStore(shares *ShareStore) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.shares = shares
+}
+
+// 撤销备忘录的分享，调用方需持有写锁。先于删除文件执行，撤销失败时不删除
+func (s *MemoStore) revokeSharesLocked(id string) error {
+	if s.shares == nil {
+		return nil
+	}
+	if _, err := s.shares.RevokeMemoShares(id); err != nil {
+		return fmt.Errorf("撤销分享失败: %w", err)
+	}
+	return nil
+}
+
+// 删除备忘录，调用方需持有写锁
+func (s *MemoStore) deleteMemoLocked(id string) error {
 	memoPath := s.getMemoPath(id)
 	if _, err := os.Stat(memoPath); os.IsNotExist(err) {
 		return fmt.Errorf("备忘录不存在: %s", id)
 	}
 
+	if err := s.revokeSharesLocked(id); err != nil {
+		return err
+	}
+
+	// 回复改为挂到被删除备忘录的父备忘录下，保持对话完整
+	if err := s.reparentRepliesLocked(id); err != nil {
+		return err
+	}
+
+	if err := os.Remove(memoPath); err != nil {
+		return err
+	}
+	s.memoChanged(EventMemoDeleted, id)
+
 	// 从ID中提取日期
 	parts := strings.Split(id, "-")
 	if len(parts) >= 4 {
@@ -209,12 +389,34 @@ func (s *MemoStore) DeleteMemo(id string) error {
 		numStr := parts[3]
 		num, err := strconv.Atoi(numStr)
 		if err == nil && num == s.maxNumberCache[dateStr] {
-			// 如果删除的是当天最大序号的memo，需要重新扫描该日期的所有memo
+			// 如果删除的是当天最大序号的memo，需要在文件删除后重新扫描该日期的所有memo
 			s.updateMaxNumberForDate(dateStr)
 		}
 	}
 
-	return os.Remove(memoPath)
+	return nil
+}
+
+// 校验可见性取值，空值视为私有
+func validateVisibility(visibility string) error {
+	switch visibility {
+	case "", VisibilityPrivate, VisibilityPublic:
+		return nil
+	}
+	return fmt.Errorf("无效的可见性: %s", visibility)
+}
+
+// 读取备忘录目录和回收站中的文件，回收站中的备忘录仍然占用序号，以便恢复
+func (s *MemoStore) readIDEntries() ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(s.getMemosDir())
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := os.ReadDir(s.getTrashDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return append(entries, trashed...), nil
 }
 
 // 更新指定日期的最大序号
@@ -224,7 +426,7 @@ func (s *MemoStore) updateMaxNumberForDate(dateStr string) {
 	re := regexp.MustCompile(pattern)
 
 	// 读取目录中的所有文件
-	entries, err := os.ReadDir(s.getMemosDir())
+	entries, err := s.readIDEntries()
 	if err != nil {
 		// 出错时重置为0
 		s.maxNumberCache[dateStr] = 0
@@ -260,6 +462,11 @@ func (s *MemoStore) ListMemos() ([]*Memo, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	return s.listMemosLocked()
+}
+
+// 列出所有备忘录，调用方需持有锁
+func (s *MemoStore) listMemosLocked() ([]*Memo, error) {
 	memos := []*Memo{}
 
 	// 存储文件信息
@@ -274,8 +481,16 @@ func (s *MemoStore) ListMemos() ([]*Memo, error) {
 			return err
 		}
 
-		// 跳过目录和非 Markdown 文件
-		if d.IsDir() || filepath.Ext(path) != ".md" {
+		// 备忘录只保存在数据目录的第一层，跳过 static、trash 等子目录
+		if d.IsDir() {
+			if path != s.getMemosDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// 跳过非 Markdown 文件
+		if filepath.Ext(path) != ".md" {
 			return nil
 		}
 
@@ -317,6 +532,9 @@ func (s *MemoStore) ListMemos() ([]*Memo, error) {
 }
 
 func (s *MemoStore) CreateAttachment(attachment *Attachment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	attachmentPath := s.getAttachmentPath(attachment.ID)
 	// 检查文件是否存在
 	if _, err := os.Stat(attachmentPath); err == nil {
@@ -324,7 +542,11 @@ func (s *MemoStore) CreateAttachment(attachment *Attachment) error {
 	} else if !os.IsNotExist(err) {
 		return fmt.Errorf("检查附件文件失败: %w", err)
 	}
-	if err := os.WriteFile(attachmentPath, attachment.Data, 0644); err != nil {
+	data, err := s.encodeAttachment(attachment.Data)
+	if err != nil {
+		return fmt.Errorf("加密附件失败: %w", err)
+	}
+	if err := os.WriteFile(attachmentPath, data, 0644); err != nil {
 		return fmt.Errorf("写入附件文件失败: %w", err)
 	}
 	return nil
@@ -340,7 +562,7 @@ func (s *MemoStore) readMemoFromFile(id string) (*Memo, error) {
 	}
 
 	// 读取文件内容
-	data, err := os.ReadFile(memoPath)
+	data, err := s.readFile(memoPath)
 	if err != nil {
 		return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
 	}
@@ -349,6 +571,21 @@ func (s *MemoStore) readMemoFromFile(id string) (*Memo, error) {
 	return parseMemoFile(data, id)
 }
 
+// ReadMemoFile 返回备忘录文件的原始内容，用于导出时保留文件原样。加密的文件返回解密后的内容
+func (s *MemoStore) ReadMemoFile(id string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	data, err := s.readFile(s.getMemoPath(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("备忘录不存在: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
+	}
+	return data, nil
+}
+
 // 将memo保存到文件
 func (s *MemoStore) saveMemoToFile(memo *Memo) error {
 	// 创建文件内容
@@ -357,11 +594,21 @@ func (s *MemoStore) saveMemoToFile(memo *Memo) error {
 		return fmt.Errorf("格式化备忘录失败: %w", err)
 	}
 
+	content, err = s.encodeMemo(content, memo.Tags)
+	if err != nil {
+		return fmt.Errorf("加密备忘录失败: %w", err)
+	}
+
 	// 写入文件
 	memoPath := s.getMemoPath(memo.ID)
+	eventType := EventMemoUpdated
+	if _, err := os.Stat(memoPath); os.IsNotExist(err) {
+		eventType = EventMemoCreated
+	}
 	if err := os.WriteFile(memoPath, content, 0644); err != nil {
 		return fmt.Errorf("写入备忘录文件失败: %w", err)
 	}
+	s.memoChanged(eventType, memo.ID)
 
 	return nil
 }
@@ -418,6 +665,15 @@ func parseMemoFile(data []byte, id string) (*Memo, error) {
 		CreatedAt: metadata.CreatedAt,
 		UpdatedAt: metadata.UpdatedAt,
 		Content:   strings.TrimSpace(content.String()),
+
+		Visibility: metadata.Visibility,
+		Parent:     parentRef(metadata.Parent),
+		Pinned:     flag(metadata.Pinned),
+		Archived:   flag(metadata.Archived),
+
+		Review: metadata.Review,
+
+		explicitTags: metadata.ExplicitTags,
 	}, nil
 }
 
@@ -430,6 +686,15 @@ func formatMemoFile(memo *Memo) ([]byte, error) {
 		Tags:      memo.Tags,
 		CreatedAt: memo.CreatedAt,
 		UpdatedAt: memo.UpdatedAt,
+
+		Visibility: memo.Visibility,
+		Parent:     memo.ParentID(),
+		Pinned:     memo.IsPinned(),
+		Archived:   memo.IsArchived(),
+
+		Review: memo.Review,
+
+		ExplicitTags: memo.explicitTags,
 	}
 
 	// 序列化元数据为YAML
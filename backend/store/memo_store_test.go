@@ -2,7 +2,6 @@ package store
 
 import (
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -111,8 +110,9 @@ func TestMemoStore(t *testing.T) {
 	}
 
 	// 记录更新前的时间
-	beforeUpdate := time.Now()
-	time.Sleep(10 * time.Millisecond) // 确保时间戳有差异
+	// 时间戳精确到秒，等待超过一秒以确保时间戳有差异
+	beforeUpdate := time.Now().Truncate(time.Second)
+	time.Sleep(time.Second)
 
 	if err := store.UpdateMemo(memo.ID, updates); err != nil {
 		t.Fatalf("更新备忘录失败: %v", err)
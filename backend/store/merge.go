@@ -0,0 +1,104 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNothingToMerge 表示除 target 外没有其他要合并的备忘录
+var ErrNothingToMerge = errors.New("没有要合并的备忘录")
+
+// 合并时不同正文之间的分隔
+const mergeSeparator = "\n\n---\n\n"
+
+// 比较正文时忽略空白的差异
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// MergeMemos 将 others 的正文和标签合并到 target 中，其余备忘录移入回收站。
+// 正文按创建时间顺序拼接，忽略空白后相同的正文只保留一份；
+// 回复被合并备忘录的备忘录改为回复 target
+func (s *MemoStore) MergeMemos(target string, others []string) (*Memo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	merged, err := s.readMemoFromFile(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []*Memo
+	seen := map[string]bool{target: true}
+	for _, id := range others {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		memo, err := s.readMemoFromFile(id)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, memo)
+	}
+	if len(sources) == 0 {
+		return nil, ErrNothingToMerge
+	}
+
+	all := append([]*Memo{merged}, sources...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+
+	var contents []string
+	var explicit []string
+	seenContent := make(map[string]bool)
+	for _, memo := range all {
+		if normalized := normalizeContent(memo.Content); normalized != "" && !seenContent[normalized] {
+			seenContent[normalized] = true
+			contents = append(contents, memo.Content)
+		}
+		tags := memo.Tags
+		if s.hashtagPolicy != HashtagPolicyFrontMatter {
+			tags = explicitTags(memo, ExtractHashtags(memo.Content))
+		}
+		explicit = mergeTags(HashtagPolicyMerge, explicit, tags)
+		if merged.Title == "" {
+			merged.Title = memo.Title
+		}
+	}
+	merged.Content = strings.Join(contents, mergeSeparator)
+	s.applyHashtags(merged, explicit)
+	merged.UpdatedAt = s.now().Truncate(time.Second)
+
+	// 回复被合并备忘录的改为回复合并后的备忘录
+	changed := []*Memo{merged}
+	memos, err := s.listMemosLocked()
+	if err != nil {
+		return nil, err
+	}
+	trashed := make(map[string]bool, len(sources))
+	ids := make([]string, 0, len(sources))
+	for _, memo := range sources {
+		trashed[memo.ID] = true
+		ids = append(ids, memo.ID)
+	}
+	for _, memo := range memos {
+		if trashed[memo.ParentID()] && !trashed[memo.ID] && memo.ID != target {
+			memo.Parent = &target
+			memo.UpdatedAt = merged.UpdatedAt
+			changed = append(changed, memo)
+		}
+	}
+	if trashed[merged.ParentID()] {
+		merged.Parent = nil
+	}
+
+	if err := s.saveMemosAtomic(changed); err != nil {
+		return nil, err
+	}
+	if err := s.trashMemosLocked(ids); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
@@ -12,6 +12,15 @@ type Memo struct {
 	CreatedAt time.Time `json:"createdAt"` // 创建时间
 	UpdatedAt time.Time `json:"updatedAt"` // 更新时间
 	Content   string    `json:"content"`   // 内容（Markdown格式）
+
+	Visibility string  `json:"visibility,omitempty"` // 可见性，public 或 private（默认）
+	Parent     *string `json:"parent,omitempty"`     // 回复的备忘录ID，更新时为空表示不修改，为 "" 表示不再作为回复
+	Pinned     *bool   `json:"isPinned,omitempty"`   // 是否置顶，更新时为空表示不修改
+	Archived   *bool   `json:"isArchived,omitempty"` // 是否归档，更新时为空表示不修改
+
+	Review *ReviewState `json:"review,omitempty"` // 间隔回顾的状态
+
+	explicitTags []string // 显式设置、同时又出现在正文中的标签，用于区分标签来源
 }
 
 // MemoMetadata 表示备忘录的元数据（存储在YAML头部）
@@ -21,10 +30,65 @@ type MemoMetadata struct {
 	Tags      []string  `yaml:"tags"`
 	CreatedAt time.Time `yaml:"created_at"`
 	UpdatedAt time.Time `yaml:"updated_at"`
-} 
+
+	Visibility string `yaml:"visibility,omitempty"`
+	Parent     string `yaml:"parent,omitempty"`
+	Pinned     bool   `yaml:"pinned,omitempty"`
+	Archived   bool   `yaml:"archived,omitempty"`
+
+	Review *ReviewState `yaml:"review,omitempty"`
+
+	// 显式设置、同时又出现在正文中的标签。其余标签在正文中出现的来自正文，否则来自头部
+	ExplicitTags []string `yaml:"explicit_tags,omitempty"`
+}
+
+// 备忘录可见性
+const (
+	VisibilityPrivate = "private" // 仅自己可见（默认）
+	VisibilityPublic  = "public"  // 出现在订阅源等公开输出中
+)
+
+// IsPublic 判断备忘录是否公开
+func (m *Memo) IsPublic() bool {
+	return m.Visibility == VisibilityPublic
+}
+
+// ParentID 返回回复的备忘录ID，不是回复时为空
+func (m *Memo) ParentID() string {
+	if m.Parent == nil {
+		return ""
+	}
+	return *m.Parent
+}
+
+// IsPinned 判断备忘录是否置顶
+func (m *Memo) IsPinned() bool {
+	return m.Pinned != nil && *m.Pinned
+}
+
+// IsArchived 判断备忘录是否归档
+func (m *Memo) IsArchived() bool {
+	return m.Archived != nil && *m.Archived
+}
+
+// 布尔值为 true 时返回指针，否则返回 nil，使未设置的字段在 JSON 中省略
+func flag(b bool) *bool {
+	if !b {
+		return nil
+	}
+	return &b
+}
+
+// 空的父备忘录ID保存为 nil，与 flag 相同
+func parentRef(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
 
 // Attachment 表示附件
 type Attachment struct {
 	ID   string `json:"id"`
 	Data []byte `json:"data"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 备忘录ID只能由字母、数字、-、_ 组成，避免恢复时写到数据目录之外
+var validIDPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z_-]*$`)
+
+// 匹配 YYYY-MM-DD-N 格式的ID
+var datedIDPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(\d+)$`)
+
+// MemoIDs 列出全部备忘录的ID，按ID排序
+func (s *MemoStore) MemoIDs() ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries, err := os.ReadDir(s.getMemosDir())
+	if err != nil {
+		return nil, fmt.Errorf("读取memos目录失败: %w", err)
+	}
+	ids := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".md"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// RestoreMemoFile 把备份中的备忘录文件按原样写回数据目录，文件修改时间设为 modTime。
+// 已存在同ID的备忘录时，overwrite 为 false 返回 ErrMemoExists
+func (s *MemoStore) RestoreMemoFile(id string, data []byte, modTime time.Time, overwrite bool) error {
+	if !validIDPattern.MatchString(id) {
+		return fmt.Errorf("无效的备忘录ID: %s", id)
+	}
+	memo, err := parseMemoFile(data, id)
+	if err != nil {
+		return fmt.Errorf("备忘录 %s 格式无效: %w", id, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	memoPath := s.getMemoPath(id)
+	eventType := EventMemoCreated
+	if _, err := os.Stat(memoPath); err == nil {
+		if !overwrite {
+			return fmt.Errorf("%w: %s", ErrMemoExists, id)
+		}
+		eventType = EventMemoUpdated
+	}
+	data, err = s.encodeMemo(data, memo.Tags)
+	if err != nil {
+		return fmt.Errorf("加密备忘录失败: %w", err)
+	}
+	if err := writeFileAtomic(memoPath, data); err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(memoPath, modTime, modTime)
+	}
+	s.memoChanged(eventType, id)
+
+	// 恢复的ID占用序号
+	if matches := datedIDPattern.FindStringSubmatch(id); matches != nil {
+		if num, err := strconv.Atoi(matches[2]); err == nil && num > s.maxNumberCache[matches[1]] {
+			s.maxNumberCache[matches[1]] = num
+		}
+	}
+	return nil
+}
+
+// RestoreMemoAsNew 以新的ID保存备份中的备忘录，新ID按原创建日期生成，返回新ID
+func (s *MemoStore) RestoreMemoAsNew(oldID string, data []byte, modTime time.Time) (string, error) {
+	memo, err := parseMemoFile(data, oldID)
+	if err != nil {
+		return "", fmt.Errorf("备忘录 %s 格式无效: %w", oldID, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	created := memo.CreatedAt
+	if created.IsZero() {
+		created = s.now()
+	}
+	id, err := s.generateIDAt(created)
+	if err != nil {
+		return "", fmt.Errorf("生成备忘录ID失败: %w", err)
+	}
+	memo.ID = id
+	if err := s.saveMemoToFile(memo); err != nil {
+		return "", err
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(s.getMemoPath(id), modTime, modTime)
+	}
+	return id, nil
+}
+
+// AttachmentExists 判断 static 目录中是否存在附件，name 可以包含子目录
+func (s *MemoStore) AttachmentExists(name string) bool {
+	path, err := s.attachmentPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// OpenAttachment 打开 static 目录中的附件，加密的附件读取时自动解密
+func (s *MemoStore) OpenAttachment(name string) (AttachmentReader, error) {
+	path, err := s.attachmentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAttachmentFile(path)
+}
+
+// RestoreAttachment 从 r 写入附件，已存在同名附件时 overwrite 为 false 返回错误
+func (s *MemoStore) RestoreAttachment(name string, r io.Reader, modTime time.Time, overwrite bool) error {
+	path, err := s.attachmentPath(name)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("附件已存在: %s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建附件目录失败: %w", err)
+	}
+
+	if s.encryptsAttachments() {
+		plain, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("读取附件失败: %w", err)
+		}
+		data, err := s.encodeAttachment(plain)
+		if err != nil {
+			return fmt.Errorf("加密附件失败: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("写入附件文件失败: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入附件文件失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入附件文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入附件文件失败: %w", err)
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(path, modTime, modTime)
+	}
+	return nil
+}
+
+// 返回附件路径，拒绝跳出 static 目录的名称
+func (s *MemoStore) attachmentPath(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == "." || strings.HasPrefix(clean, "..") {
+		return "", fmt.Errorf("无效的附件名称: %s", name)
+	}
+	return filepath.Join(s.getStaticDir(), clean), nil
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 回顾相关的错误
+var (
+	ErrNoMemo        = errors.New("没有符合条件的备忘录")
+	ErrInvalidReview = errors.New("无效的回顾结果")
+)
+
+// ReviewState 间隔回顾的状态，保存在头部的 review 字段
+type ReviewState struct {
+	Interval     int       `json:"interval" yaml:"interval"`          // 当前间隔天数
+	Count        int       `json:"count" yaml:"count"`                // 已回顾次数
+	LastReviewed time.Time `json:"lastReviewed" yaml:"last_reviewed"` // 上次回顾时间
+	Due          time.Time `json:"due" yaml:"due"`                    // 下次回顾时间
+}
+
+// 回顾结果
+const (
+	ReviewAgain = "again" // 已经记不清，间隔重置为1天
+	ReviewGood  = "good"  // 间隔翻倍（默认）
+	ReviewEasy  = "easy"  // 间隔变为4倍
+)
+
+// 回顾间隔的上限（天）
+const maxReviewInterval = 365
+
+// 新写的备忘录至少过这么久才进入回顾队列
+const reviewMinAge = 24 * time.Hour
+
+// NextReview 根据回顾结果计算下一次回顾的状态，间隔依次为 1、2、4、8... 天
+func NextReview(state *ReviewState, result string, now time.Time) (*ReviewState, error) {
+	interval := 0
+	count := 0
+	if state != nil {
+		interval = state.Interval
+		count = state.Count
+	}
+
+	switch result {
+	case ReviewAgain:
+		interval = 1
+	case ReviewGood, "":
+		interval = max(1, interval*2)
+	case ReviewEasy:
+		interval = max(4, interval*4)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReview, result)
+	}
+	interval = min(interval, maxReviewInterval)
+
+	now = now.Truncate(time.Second)
+	return &ReviewState{
+		Interval:     interval,
+		Count:        count + 1,
+		LastReviewed: now,
+		Due:          now.AddDate(0, 0, interval),
+	}, nil
+}
+
+// ResurfaceFilter 随机回顾的筛选条件
+type ResurfaceFilter struct {
+	Tag       string        // 标签，包含子标签
+	OlderThan time.Duration // 只保留创建时间早于这么久之前的备忘录
+	NewerThan time.Duration // 只保留创建时间晚于这么久之前的备忘录
+}
+
+// HasTagOrChild 判断标签列表中是否有该标签或其子标签，如 读书 匹配 读书/小说
+func HasTagOrChild(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterResurface 按标签和创建时间筛选备忘录
+func FilterResurface(memos []*Memo, filter ResurfaceFilter, now time.Time) []*Memo {
+	result := []*Memo{}
+	for _, memo := range memos {
+		age := now.Sub(memo.CreatedAt)
+		switch {
+		case filter.Tag != "" && !HasTagOrChild(memo.Tags, filter.Tag),
+			filter.OlderThan > 0 && age < filter.OlderThan,
+			filter.NewerThan > 0 && age > filter.NewerThan:
+			continue
+		}
+		result = append(result, memo)
+	}
+	return result
+}
+
+// OnThisDay 返回往年同月同日创建的备忘录，按年份从近到远排序。
+// 非闰年的2月28日也包含2月29日的备忘录。date 为零值时使用当前日期
+func (s *MemoStore) OnThisDay(date time.Time) ([]*Memo, error) {
+	if date.IsZero() {
+		date = s.now()
+	}
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+
+	leapDay := date.Month() == time.February && date.Day() == 28 &&
+		time.Date(date.Year(), time.February, 29, 0, 0, 0, 0, time.UTC).Day() != 29
+
+	result := []*Memo{}
+	for _, memo := range memos {
+		created := memo.CreatedAt.In(date.Location())
+		if created.Year() >= date.Year() || created.Month() != date.Month() {
+			continue
+		}
+		if created.Day() == date.Day() || (leapDay && created.Day() == 29) {
+			result = append(result, memo)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// RandomMemo 从符合条件的备忘录中随机选一条
+func (s *MemoStore) RandomMemo(filter ResurfaceFilter) (*Memo, error) {
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := FilterResurface(memos, filter, s.now())
+	if len(candidates) == 0 {
+		return nil, ErrNoMemo
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// ReviewQueue 返回待回顾的备忘录：先是到期的（按到期时间排序），
+// 再是从未回顾过的（从旧到新），最多 limit 条
+func (s *MemoStore) ReviewQueue(limit int) ([]*Memo, error) {
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	var due, fresh []*Memo
+	for _, memo := range memos {
+		switch {
+		case memo.Review != nil:
+			if !memo.Review.Due.After(now) {
+				due = append(due, memo)
+			}
+		case now.Sub(memo.CreatedAt) >= reviewMinAge:
+			fresh = append(fresh, memo)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Review.Due.Before(due[j].Review.Due) })
+	sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].CreatedAt.Before(fresh[j].CreatedAt) })
+
+	queue := append(due, fresh...)
+	if limit > 0 && len(queue) > limit {
+		queue = queue[:limit]
+	}
+	if queue == nil {
+		queue = []*Memo{}
+	}
+	return queue, nil
+}
+
+// ReviewMemo 记录一次回顾并安排下一次。回顾不算编辑，不修改 updated_at，
+// 也保留文件的修改时间，以免备忘录在列表中被顶到最前
+func (s *MemoStore) ReviewMemo(id, result string) (*Memo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	memo, err := s.readMemoFromFile(id)
+	if err != nil {
+		return nil, err
+	}
+	state, err := NextReview(memo.Review, result, s.now())
+	if err != nil {
+		return nil, err
+	}
+	memo.Review = state
+
+	path := s.getMemoPath(id)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
+	}
+	if err := s.saveMemoToFile(memo); err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		return nil, fmt.Errorf("恢复文件修改时间失败: %w", err)
+	}
+	return memo, nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestResurface(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-resurface-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	// 在不同日期创建备忘录
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	old := &Memo{Tags: []string{"读书/小说"}, Content: "去年的今天"}
+	if err := store.CreateMemo(old); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	now = time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	recent := &Memo{Content: "今天"}
+	if err := store.CreateMemo(recent); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+
+	memos, err := store.OnThisDay(time.Time{})
+	if err != nil {
+		t.Fatalf("获取那年今日失败: %v", err)
+	}
+	if len(memos) != 1 || memos[0].ID != old.ID {
+		t.Errorf("那年今日的备忘录不正确: %+v", memos)
+	}
+
+	random, err := store.RandomMemo(ResurfaceFilter{Tag: "读书", OlderThan: 30 * 24 * time.Hour})
+	if err != nil || random.ID != old.ID {
+		t.Errorf("随机备忘录不正确: %+v, %v", random, err)
+	}
+	if _, err := store.RandomMemo(ResurfaceFilter{Tag: "读书", NewerThan: time.Hour}); err != ErrNoMemo {
+		t.Errorf("没有符合条件的备忘录时应返回 ErrNoMemo: %v", err)
+	}
+
+	// 刚写的备忘录不在回顾队列中
+	queue, err := store.ReviewQueue(0)
+	if err != nil {
+		t.Fatalf("获取回顾队列失败: %v", err)
+	}
+	if len(queue) != 1 || queue[0].ID != old.ID {
+		t.Fatalf("回顾队列不正确: %+v", queue)
+	}
+
+	// 连续回顾时间隔依次增加，again 重置间隔
+	wantIntervals := []struct {
+		result   string
+		interval int
+	}{{ReviewGood, 1}, {ReviewGood, 2}, {ReviewGood, 4}, {ReviewEasy, 16}, {ReviewAgain, 1}}
+	for _, want := range wantIntervals {
+		memo, err := store.ReviewMemo(old.ID, want.result)
+		if err != nil {
+			t.Fatalf("回顾失败: %v", err)
+		}
+		if memo.Review.Interval != want.interval || !memo.Review.Due.Equal(now.AddDate(0, 0, want.interval)) {
+			t.Errorf("%s 后的回顾状态不正确: %+v", want.result, memo.Review)
+		}
+		now = memo.Review.Due
+	}
+
+	// 回顾状态保存在头部
+	reloaded, err := store.GetMemo(old.ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if reloaded.Review == nil || reloaded.Review.Count != 5 || !reloaded.UpdatedAt.Equal(old.UpdatedAt) {
+		t.Errorf("回顾状态未保存或修改了更新时间: %+v", reloaded)
+	}
+	if _, err := store.ReviewMemo(old.ID, "maybe"); err == nil {
+		t.Error("无效的回顾结果应当失败")
+	}
+}
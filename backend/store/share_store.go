@@ -0,0 +1,236 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 分享相关的错误
+var (
+	ErrShareNotFound = errors.New("分享不存在")
+	ErrShareExpired  = errors.New("分享已过期")
+)
+
+// Share 表示一条私有备忘录的分享链接
+type Share struct {
+	Token        string     `json:"token" yaml:"token"`
+	MemoID       string     `json:"memoId" yaml:"memo_id"`
+	CreatedAt    time.Time  `json:"createdAt" yaml:"created_at"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty" yaml:"expires_at,omitempty"`
+	PasswordHash string     `json:"-" yaml:"password_hash,omitempty"`
+	Views        int        `json:"views" yaml:"views"`
+}
+
+// HasPassword 判断分享是否设置了密码
+func (s *Share) HasPassword() bool {
+	return s.PasswordHash != ""
+}
+
+// Expired 判断分享在指定时间是否已过期
+func (s *Share) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// CheckPassword 校验访问密码
+func (s *Share) CheckPassword(password string) bool {
+	if !s.HasPassword() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.PasswordHash), []byte(password)) == nil
+}
+
+// 访问次数的写入间隔，访问分享页面时不必每次都重写文件
+const viewSaveDelay = 30 * time.Second
+
+// ShareStore 管理分享链接，持久化在数据目录的 shares.yaml 中
+type ShareStore struct {
+	path   string
+	mutex  sync.Mutex
+	shares map[string]*Share
+
+	viewSaveDelay time.Duration
+	viewTimer     *time.Timer // 等待写入访问次数，为 nil 表示没有未写入的访问
+}
+
+// NewShareStore 创建分享存储并加载已有的分享
+func NewShareStore(dataDir string) (*ShareStore, error) {
+	s := &ShareStore{
+		path:          filepath.Join(dataDir, "shares.yaml"),
+		shares:        make(map[string]*Share),
+		viewSaveDelay: viewSaveDelay,
+	}
+
+	var shares []*Share
+	if err := ReadYAMLFile(s.path, &shares); err != nil {
+		return nil, fmt.Errorf("读取分享文件失败: %w", err)
+	}
+	for _, share := range shares {
+		s.shares[share.Token] = share
+	}
+	return s, nil
+}
+
+// CreateShare 为备忘录创建分享，expiresAt 和 password 均可为空
+func (s *ShareStore) CreateShare(memoID string, expiresAt *time.Time, password string) (*Share, error) {
+	token, err := RandomToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("生成分享令牌失败: %w", err)
+	}
+
+	share := &Share{
+		Token:     token,
+		MemoID:    memoID,
+		CreatedAt: time.Now().Truncate(time.Second),
+		ExpiresAt: expiresAt,
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("处理分享密码失败: %w", err)
+		}
+		share.PasswordHash = string(hash)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.shares[token] = share
+	if err := s.save(); err != nil {
+		delete(s.shares, token)
+		return nil, err
+	}
+	copied := *share
+	return &copied, nil
+}
+
+// GetShare 获取有效的分享，过期的分享返回 ErrShareExpired
+func (s *ShareStore) GetShare(token string) (*Share, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	share, ok := s.shares[token]
+	if !ok {
+		return nil, ErrShareNotFound
+	}
+	if share.Expired(time.Now()) {
+		return nil, ErrShareExpired
+	}
+	copied := *share
+	return &copied, nil
+}
+
+// RecordView 记录一次分享访问。访问次数延迟写入文件，一段时间内的多次访问只写入一次
+func (s *ShareStore) RecordView(token string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	share, ok := s.shares[token]
+	if !ok {
+		return ErrShareNotFound
+	}
+	share.Views++
+	if s.viewTimer == nil {
+		s.viewTimer = time.AfterFunc(s.viewSaveDelay, func() {
+			if err := s.Flush(); err != nil {
+				log.Printf("写入分享访问次数失败: %v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// Flush 立即写入尚未保存的访问次数
+func (s *ShareStore) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.viewTimer == nil {
+		return nil
+	}
+	return s.save()
+}
+
+// ListShares 列出分享，memoID 为空时列出全部，按创建时间从新到旧排序
+func (s *ShareStore) ListShares(memoID string) []*Share {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	shares := []*Share{}
+	for _, share := range s.shares {
+		if memoID != "" && share.MemoID != memoID {
+			continue
+		}
+		copied := *share
+		shares = append(shares, &copied)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.After(shares[j].CreatedAt)
+	})
+	return shares
+}
+
+// RevokeShare 撤销分享
+func (s *ShareStore) RevokeShare(token string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	share, ok := s.shares[token]
+	if !ok {
+		return ErrShareNotFound
+	}
+	delete(s.shares, token)
+	if err := s.save(); err != nil {
+		s.shares[token] = share
+		return err
+	}
+	return nil
+}
+
+// RevokeMemoShares 撤销备忘录的全部分享，返回撤销的数量
+func (s *ShareStore) RevokeMemoShares(memoID string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	revoked := make(map[string]*Share)
+	for token, share := range s.shares {
+		if share.MemoID == memoID {
+			revoked[token] = share
+			delete(s.shares, token)
+		}
+	}
+	if len(revoked) == 0 {
+		return 0, nil
+	}
+	if err := s.save(); err != nil {
+		for token, share := range revoked {
+			s.shares[token] = share
+		}
+		return 0, err
+	}
+	return len(revoked), nil
+}
+
+// 将分享写入文件，调用方需持有锁。未写入的访问次数一并写入
+func (s *ShareStore) save() error {
+	if s.viewTimer != nil {
+		s.viewTimer.Stop()
+		s.viewTimer = nil
+	}
+
+	shares := make([]*Share, 0, len(s.shares))
+	for _, share := range s.shares {
+		shares = append(shares, share)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+	})
+
+	return WriteYAMLFile(s.path, shares)
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestShareStore(t *testing.T) (*ShareStore, *MemoStore) {
+	tempDir, err := os.MkdirTemp("", "memo-share-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	memoStore, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	shares, err := NewShareStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建ShareStore失败: %v", err)
+	}
+	memoStore.SetShareStore(shares)
+	return shares, memoStore
+}
+
+func TestShareStore(t *testing.T) {
+	shares, memoStore := newTestShareStore(t)
+
+	share, err := shares.CreateShare("2024-03-02-1", nil, "")
+	if err != nil {
+		t.Fatalf("创建分享失败: %v", err)
+	}
+	if len(share.Token) < 32 || share.HasPassword() || !share.CheckPassword("") {
+		t.Errorf("分享不正确: %+v", share)
+	}
+
+	locked, err := shares.CreateShare("2024-03-02-1", nil, "口令")
+	if err != nil {
+		t.Fatalf("创建带密码的分享失败: %v", err)
+	}
+	if locked.Token == share.Token {
+		t.Errorf("每个分享的令牌应不同")
+	}
+	if !locked.HasPassword() || locked.PasswordHash == "口令" {
+		t.Errorf("密码应以哈希保存: %+v", locked)
+	}
+	if locked.CheckPassword("错误") || !locked.CheckPassword("口令") {
+		t.Errorf("密码校验不正确")
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, err := shares.CreateShare("2024-03-02-1", &past, "")
+	if err != nil {
+		t.Fatalf("创建分享失败: %v", err)
+	}
+	if _, err := shares.GetShare(expired.Token); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("过期的分享应返回 ErrShareExpired, 实际 %v", err)
+	}
+	if _, err := shares.GetShare("不存在"); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("不存在的分享应返回 ErrShareNotFound, 实际 %v", err)
+	}
+	if list := shares.ListShares("2024-03-02-1"); len(list) != 3 {
+		t.Errorf("应列出 3 个分享, 实际 %d", len(list))
+	}
+
+	// 重新加载后密码哈希和有效期保留
+	reloaded, err := NewShareStore(memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("重新加载分享失败: %v", err)
+	}
+	got, err := reloaded.GetShare(locked.Token)
+	if err != nil || !got.CheckPassword("口令") {
+		t.Errorf("重新加载后的分享不正确: %+v, %v", got, err)
+	}
+	if _, err := reloaded.GetShare(expired.Token); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("重新加载后过期的分享应返回 ErrShareExpired, 实际 %v", err)
+	}
+
+	if err := shares.RevokeShare(share.Token); err != nil {
+		t.Fatalf("撤销分享失败: %v", err)
+	}
+	if _, err := shares.GetShare(share.Token); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("撤销后的分享应不存在, 实际 %v", err)
+	}
+	if err := shares.RevokeShare(share.Token); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("重复撤销应返回 ErrShareNotFound, 实际 %v", err)
+	}
+}
+
+func TestShareViewsSavedLater(t *testing.T) {
+	shares, memoStore := newTestShareStore(t)
+	shares.viewSaveDelay = 50 * time.Millisecond
+
+	share, err := shares.CreateShare("2024-03-02-1", nil, "")
+	if err != nil {
+		t.Fatalf("创建分享失败: %v", err)
+	}
+	info, _ := os.Stat(shares.path)
+	for i := 0; i < 3; i++ {
+		if err := shares.RecordView(share.Token); err != nil {
+			t.Fatalf("记录访问失败: %v", err)
+		}
+	}
+	if got, _ := shares.GetShare(share.Token); got.Views != 3 {
+		t.Errorf("访问次数应为 3, 实际 %d", got.Views)
+	}
+	if after, _ := os.Stat(shares.path); !os.SameFile(info, after) {
+		t.Errorf("记录访问时不应立即重写分享文件")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	reloaded, err := NewShareStore(memoStore.DataDir())
+	if err != nil {
+		t.Fatalf("重新加载分享失败: %v", err)
+	}
+	if got, _ := reloaded.GetShare(share.Token); got.Views != 3 {
+		t.Errorf("延迟写入后访问次数应为 3, 实际 %d", got.Views)
+	}
+
+	// Flush 立即写入
+	shares.viewSaveDelay = time.Hour
+	shares.RecordView(share.Token)
+	if err := shares.Flush(); err != nil {
+		t.Fatalf("写入访问次数失败: %v", err)
+	}
+	reloaded, _ = NewShareStore(memoStore.DataDir())
+	if got, _ := reloaded.GetShare(share.Token); got.Views != 4 {
+		t.Errorf("Flush 后访问次数应为 4, 实际 %d", got.Views)
+	}
+}
+
+func TestDeleteAndTrashRevokeShares(t *testing.T) {
+	shares, memoStore := newTestShareStore(t)
+
+	deleted := &Memo{Content: "将被删除"}
+	trashed := &Memo{Content: "将移入回收站"}
+	kept := &Memo{Content: "保留"}
+	for _, memo := range []*Memo{deleted, trashed, kept} {
+		if err := memoStore.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := shares.CreateShare(memo.ID, nil, ""); err != nil {
+				t.Fatalf("创建分享失败: %v", err)
+			}
+		}
+	}
+
+	if err := memoStore.DeleteMemo(deleted.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	if err := memoStore.TrashMemo(trashed.ID); err != nil {
+		t.Fatalf("移入回收站失败: %v", err)
+	}
+	if list := shares.ListShares(deleted.ID); len(list) != 0 {
+		t.Errorf("删除备忘录后应撤销分享: %+v", list)
+	}
+	if list := shares.ListShares(trashed.ID); len(list) != 0 {
+		t.Errorf("移入回收站后应撤销分享: %+v", list)
+	}
+	if list := shares.ListShares(kept.ID); len(list) != 2 {
+		t.Errorf("其他备忘录的分享不应受影响: %d", len(list))
+	}
+
+	// 从回收站恢复后旧的分享不会重新生效
+	if _, err := memoStore.RestoreMemo(trashed.ID); err != nil {
+		t.Fatalf("从回收站恢复失败: %v", err)
+	}
+	reloaded, _ := NewShareStore(memoStore.DataDir())
+	if list := reloaded.ListShares(trashed.ID); len(list) != 0 {
+		t.Errorf("恢复后旧的分享不应重新生效: %+v", list)
+	}
+}
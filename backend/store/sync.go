@@ -0,0 +1,220 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 客户端推送的修改类型
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// 冲突原因
+const (
+	ConflictModified = "modified" // 服务器上的版本与客户端的基础版本不同
+	ConflictDeleted  = "deleted"  // 备忘录已在服务器上删除
+)
+
+// ErrInvalidCursor 游标大于服务器上最新的序号，通常是数据目录被替换过，客户端需要从 0 开始重新同步
+var ErrInvalidCursor = errors.New("同步游标无效，需要从 0 开始重新同步")
+
+// MemoChange 一个备忘录自游标以来的最终状态。Seq 同时是备忘录的版本号，删除时 Memo 为空。
+// 备忘录文件无法读取（如 front matter 损坏或解密失败）时 Memo 为空，Error 说明原因
+type MemoChange struct {
+	Seq   uint64    `json:"seq"`
+	ID    string    `json:"id"`
+	Op    string    `json:"op"`
+	Time  time.Time `json:"time"`
+	Memo  *Memo     `json:"memo,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+// ChangeSet 一次拉取的结果，下次拉取时把 Cursor 传回
+type ChangeSet struct {
+	Changes []*MemoChange `json:"changes"`
+	Cursor  uint64        `json:"cursor"`
+	More    bool          `json:"more"` // 还有更多变更，需要用新的游标继续拉取
+}
+
+// ChangesSince 返回游标之后的变更，每个备忘录只返回最新的状态，包括删除的墓碑。
+// cursor 为 0 时返回全部备忘录；limit 不大于 0 时不限制数量。
+// 个别备忘录无法读取时只在对应的变更中记录错误，不影响其他变更的拉取
+func (s *MemoStore) ChangesSince(cursor uint64, limit int) (*ChangeSet, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if cursor > s.changes.seq {
+		return nil, ErrInvalidCursor
+	}
+
+	changes := s.changes.since(cursor)
+	set := &ChangeSet{Changes: []*MemoChange{}, Cursor: s.changes.seq}
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+		set.Cursor = changes[limit-1].Seq
+		set.More = true
+	}
+	for _, change := range changes {
+		entry := &MemoChange{Seq: change.Seq, ID: change.MemoID, Op: change.Op, Time: change.Time}
+		if change.Op == ChangeUpsert {
+			memo, err := s.readMemoFromFile(change.MemoID)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			entry.Memo = memo
+		}
+		set.Changes = append(set.Changes, entry)
+	}
+	return set, nil
+}
+
+// SyncChange 客户端离线期间做出的一项修改。
+// 修改和删除需要带上客户端所基于的版本号，即拉取时得到的 Seq；新建时用 ClientID 对应服务器分配的ID。
+// ClientID 需在所有客户端中唯一（如 UUID），重复推送同一 ClientID 的新建只会创建一次
+type SyncChange struct {
+	ClientID    string `json:"clientId,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Op          string `json:"op"`
+	BaseVersion uint64 `json:"baseVersion"`
+	Memo        *Memo  `json:"memo,omitempty"`
+}
+
+// SyncApplied 已应用的修改及新的版本号，删除后版本号为 0
+type SyncApplied struct {
+	ClientID string `json:"clientId,omitempty"`
+	ID       string `json:"id"`
+	Op       string `json:"op"`
+	Version  uint64 `json:"version"`
+}
+
+// SyncConflict 与服务器上的修改冲突，未应用。Memo 和 Version 是服务器上的当前状态，由客户端合并后重新推送
+type SyncConflict struct {
+	ClientID string `json:"clientId,omitempty"`
+	ID       string `json:"id"`
+	Op       string `json:"op"`
+	Reason   string `json:"reason"`
+	Version  uint64 `json:"version"`
+	Memo     *Memo  `json:"memo,omitempty"`
+}
+
+// SyncRejected 无效的修改，如缺少ID或可见性取值不对
+type SyncRejected struct {
+	ClientID string `json:"clientId,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Op       string `json:"op"`
+	Error    string `json:"error"`
+}
+
+// PushResult 推送的结果，每项修改只出现在其中一个列表里
+type PushResult struct {
+	Applied   []*SyncApplied  `json:"applied"`
+	Conflicts []*SyncConflict `json:"conflicts"`
+	Rejected  []*SyncRejected `json:"rejected"`
+}
+
+// PushChanges 按顺序应用客户端推送的一批修改。
+// 基础版本与服务器上的当前版本不同时不覆盖，作为冲突返回；删除已不存在的备忘录视为成功
+func (s *MemoStore) PushChanges(changes []*SyncChange) *PushResult {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := &PushResult{Applied: []*SyncApplied{}, Conflicts: []*SyncConflict{}, Rejected: []*SyncRejected{}}
+	for _, change := range changes {
+		if change == nil {
+			continue
+		}
+		applied, conflict, err := s.pushChangeLocked(change)
+		switch {
+		case err != nil:
+			result.Rejected = append(result.Rejected, &SyncRejected{ClientID: change.ClientID, ID: change.ID, Op: change.Op, Error: err.Error()})
+		case conflict != nil:
+			result.Conflicts = append(result.Conflicts, conflict)
+		default:
+			result.Applied = append(result.Applied, applied)
+		}
+	}
+	return result
+}
+
+func (s *MemoStore) pushChangeLocked(change *SyncChange) (*SyncApplied, *SyncConflict, error) {
+	applied := &SyncApplied{ClientID: change.ClientID, ID: change.ID, Op: change.Op}
+
+	if change.Op == SyncCreate {
+		// 客户端没有收到上次推送的结果而重试时，返回已经创建的备忘录，不重复创建
+		if id, ok := s.changes.clientMemo(change.ClientID); ok {
+			applied.ID = id
+			applied.Version = s.changes.version(id)
+			return applied, nil, nil
+		}
+		if change.Memo == nil {
+			return nil, nil, errors.New("缺少备忘录内容")
+		}
+		memo := syncedMemo(change.Memo)
+		if err := s.createMemoLocked(memo); err != nil {
+			return nil, nil, err
+		}
+		if change.ClientID != "" {
+			// 与变更记录一样，写入失败时内存中的对应关系仍然有效
+			s.changes.recordClient(change.ClientID, memo.ID)
+		}
+		applied.ID = memo.ID
+		applied.Version = s.changes.version(memo.ID)
+		return applied, nil, nil
+	}
+
+	if change.Op != SyncUpdate && change.Op != SyncDelete {
+		return nil, nil, fmt.Errorf("未知的操作: %s", change.Op)
+	}
+	if change.ID == "" {
+		return nil, nil, errors.New("缺少备忘录ID")
+	}
+
+	current := s.changes.version(change.ID)
+	if current == 0 {
+		if change.Op == SyncDelete {
+			return applied, nil, nil
+		}
+		return nil, &SyncConflict{ClientID: change.ClientID, ID: change.ID, Op: change.Op, Reason: ConflictDeleted}, nil
+	}
+	if current != change.BaseVersion {
+		conflict := &SyncConflict{ClientID: change.ClientID, ID: change.ID, Op: change.Op, Reason: ConflictModified, Version: current}
+		memo, err := s.readMemoFromFile(change.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		conflict.Memo = memo
+		return nil, conflict, nil
+	}
+
+	if change.Op == SyncDelete {
+		if err := s.deleteMemoLocked(change.ID); err != nil {
+			return nil, nil, err
+		}
+		return applied, nil, nil
+	}
+	if change.Memo == nil {
+		return nil, nil, errors.New("缺少备忘录内容")
+	}
+	if err := s.updateMemoLocked(change.ID, syncedMemo(change.Memo)); err != nil {
+		return nil, nil, err
+	}
+	applied.Version = s.changes.version(change.ID)
+	return applied, nil, nil
+}
+
+// 只保留客户端可以修改的字段，ID 和时间由服务器决定
+func syncedMemo(memo *Memo) *Memo {
+	return &Memo{
+		Title:      memo.Title,
+		Tags:       memo.Tags,
+		Content:    memo.Content,
+		Visibility: memo.Visibility,
+		Parent:     memo.Parent,
+		Pinned:     memo.Pinned,
+		Archived:   memo.Archived,
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSyncChanges(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-sync-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	first := &Memo{Content: "第一条"}
+	second := &Memo{Content: "第二条"}
+	for _, memo := range []*Memo{first, second} {
+		if err := store.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+	}
+
+	// 首次同步得到全部备忘录，分页拉取
+	page, err := store.ChangesSince(0, 1)
+	if err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if len(page.Changes) != 1 || !page.More || page.Changes[0].ID != first.ID {
+		t.Fatalf("第一页不正确: %+v", page)
+	}
+	all, err := store.ChangesSince(page.Cursor, 0)
+	if err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if len(all.Changes) != 1 || all.More || all.Changes[0].Memo.Content != "第二条" {
+		t.Fatalf("第二页不正确: %+v", all)
+	}
+	cursor := all.Cursor
+	firstVersion := page.Changes[0].Seq
+
+	// 删除留下墓碑，多次修改只返回最新的状态
+	if err := store.DeleteMemo(second.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	store.UpdateMemo(first.ID, &Memo{Content: "改一次"})
+	store.UpdateMemo(first.ID, &Memo{Content: "改两次"})
+	changes, err := store.ChangesSince(cursor, 0)
+	if err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if len(changes.Changes) != 2 {
+		t.Fatalf("应返回 2 项变更, 实际 %+v", changes.Changes)
+	}
+	if tomb := changes.Changes[0]; tomb.ID != second.ID || tomb.Op != ChangeDelete || tomb.Memo != nil {
+		t.Errorf("删除应返回墓碑: %+v", tomb)
+	}
+	if latest := changes.Changes[1]; latest.Memo.Content != "改两次" {
+		t.Errorf("应返回最新的内容: %+v", latest.Memo)
+	}
+	if _, err := store.ChangesSince(changes.Cursor+1, 0); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("超出范围的游标应返回 ErrInvalidCursor, 实际 %v", err)
+	}
+
+	// 推送：基于旧版本的修改产生冲突，不覆盖服务器上的内容
+	result := store.PushChanges([]*SyncChange{
+		{ID: first.ID, Op: SyncUpdate, BaseVersion: firstVersion, Memo: &Memo{Content: "离线修改"}},
+		{ID: second.ID, Op: SyncUpdate, BaseVersion: 2, Memo: &Memo{Content: "修改已删除的"}},
+		{ID: second.ID, Op: SyncDelete, BaseVersion: 2},
+		{ID: first.ID, Op: "move"},
+		{ClientID: "local-1", Op: SyncCreate, Memo: &Memo{Content: "离线时写的 #旅行"}},
+	})
+	if len(result.Applied) != 2 || len(result.Conflicts) != 2 || len(result.Rejected) != 1 {
+		t.Fatalf("推送结果不正确: %+v", result)
+	}
+	if deleted := result.Applied[0]; deleted.Op != SyncDelete || deleted.Version != 0 {
+		t.Errorf("删除已删除的备忘录应视为成功: %+v", deleted)
+	}
+	created := result.Applied[1]
+	if created.ClientID != "local-1" || created.ID == "" || created.Version == 0 {
+		t.Errorf("新建的备忘录结果不正确: %+v", created)
+	}
+	if memo, err := store.GetMemo(created.ID); err != nil || memo.Tags[0] != "旅行" {
+		t.Errorf("新建的备忘录未保存: %v", err)
+	}
+	conflict := result.Conflicts[0]
+	if conflict.Reason != ConflictModified || conflict.Memo.Content != "改两次" || conflict.Version != changes.Changes[1].Seq {
+		t.Errorf("冲突应返回服务器上的内容: %+v", conflict)
+	}
+	if result.Conflicts[1].Reason != ConflictDeleted {
+		t.Errorf("修改已删除的备忘录应返回 deleted 冲突: %+v", result.Conflicts[1])
+	}
+
+	// 基于最新版本重新推送后成功
+	result = store.PushChanges([]*SyncChange{
+		{ID: first.ID, Op: SyncUpdate, BaseVersion: conflict.Version, Memo: &Memo{Content: "合并后的内容"}},
+	})
+	if len(result.Applied) != 1 || result.Applied[0].Version <= conflict.Version {
+		t.Fatalf("重新推送失败: %+v", result)
+	}
+
+	// 重新加载后变更日志保持不变，并补记服务器未运行时新增的文件
+	os.WriteFile(store.getMemoPath("2020-01-01-1"), []byte("---\nid: 2020-01-01-1\n---\n外部添加"), 0644)
+	reloaded, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	again, err := reloaded.ChangesSince(result.Applied[0].Version, 0)
+	if err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if len(again.Changes) != 1 || again.Changes[0].ID != "2020-01-01-1" {
+		t.Errorf("重新加载后应只有外部添加的备忘录: %+v", again.Changes)
+	}
+
+	// 无法读取的备忘录只在对应的变更中报告错误，其他变更照常返回
+	os.WriteFile(reloaded.getMemoPath(first.ID), []byte("---\ntags: [\n---\n损坏"), 0644)
+	full, err := reloaded.ChangesSince(0, 0)
+	if err != nil {
+		t.Fatalf("个别备忘录损坏时拉取不应失败: %v", err)
+	}
+	for _, change := range full.Changes {
+		switch {
+		case change.ID == first.ID:
+			if change.Error == "" || change.Memo != nil {
+				t.Errorf("损坏的备忘录应带有错误: %+v", change)
+			}
+		case change.Op == ChangeUpsert && (change.Memo == nil || change.Error != ""):
+			t.Errorf("其他备忘录应正常返回: %+v", change)
+		}
+	}
+}
+
+// 没有收到响应而重新推送同一批修改时不重复创建
+func TestSyncPushRetry(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-sync-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	batch := []*SyncChange{
+		{ClientID: "client-a", Op: SyncCreate, Memo: &Memo{Content: "离线一"}},
+		{ClientID: "client-b", Op: SyncCreate, Memo: &Memo{Content: "离线二"}},
+		{Op: SyncCreate, Memo: &Memo{Content: "没有客户端ID"}},
+	}
+	first := store.PushChanges(batch)
+	if len(first.Applied) != 3 {
+		t.Fatalf("应全部应用: %+v", first)
+	}
+	ids := map[string]string{"client-a": first.Applied[0].ID, "client-b": first.Applied[1].ID}
+
+	countMemos := func() int {
+		memos, err := store.ListMemos()
+		if err != nil {
+			t.Fatalf("列出备忘录失败: %v", err)
+		}
+		return len(memos)
+	}
+	push := func(want int) []*SyncApplied {
+		t.Helper()
+		result := store.PushChanges(batch[:2])
+		if len(result.Applied) != 2 {
+			t.Fatalf("重新推送应全部应用: %+v", result)
+		}
+		for _, applied := range result.Applied {
+			if applied.ID != ids[applied.ClientID] {
+				t.Errorf("%s 应对应第一次创建的 %s, 实际 %s", applied.ClientID, ids[applied.ClientID], applied.ID)
+			}
+		}
+		if n := countMemos(); n != want {
+			t.Errorf("重新推送不应创建新的备忘录: %d 条", n)
+		}
+		return result.Applied
+	}
+
+	if retried := push(3); retried[0].Version != first.Applied[0].Version {
+		t.Errorf("版本号应不变: %d, %d", first.Applied[0].Version, retried[0].Version)
+	}
+
+	// 修改后重新推送返回当前版本；重启并压缩变更日志后对应关系仍然有效
+	for i := 0; i < 120; i++ {
+		if err := store.UpdateMemo(ids["client-a"], &Memo{Content: "服务器上修改"}); err != nil {
+			t.Fatalf("更新备忘录失败: %v", err)
+		}
+	}
+	store, err = NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("重新打开MemoStore失败: %v", err)
+	}
+	if retried := push(3); retried[0].Version != store.changes.version(ids["client-a"]) || retried[0].Version <= first.Applied[0].Version {
+		t.Errorf("应返回当前版本: %+v", retried[0])
+	}
+
+	// 删除后重新推送不会复活，版本号为 0
+	if err := store.DeleteMemo(ids["client-b"]); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	if retried := push(2); retried[1].Version != 0 {
+		t.Errorf("已删除的备忘录版本号应为 0: %+v", retried[1])
+	}
+}
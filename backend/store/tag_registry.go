@@ -0,0 +1,263 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TagMeta 标签的元数据，保存在数据目录的 tags.yaml 中
+type TagMeta struct {
+	Name        string   `json:"name" yaml:"-"`
+	Color       string   `json:"color,omitempty" yaml:"color,omitempty"`             // 颜色，如 #3b82f6
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"` // 描述
+	Icon        string   `json:"icon,omitempty" yaml:"icon,omitempty"`               // 图标，如 emoji
+	Aliases     []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`         // 别名，保存备忘录时替换为标签名
+}
+
+var colorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// TagRegistry 管理标签元数据
+type TagRegistry struct {
+	path    string
+	mutex   sync.RWMutex
+	tags    map[string]*TagMeta
+	aliases map[string]string // 别名到标签名的映射
+	changed func(name string) // 元数据修改后调用
+}
+
+// NewTagRegistry 创建标签注册表并加载已有的 tags.yaml
+func NewTagRegistry(dataDir string) (*TagRegistry, error) {
+	r := &TagRegistry{
+		path:    filepath.Join(dataDir, "tags.yaml"),
+		tags:    make(map[string]*TagMeta),
+		aliases: make(map[string]string),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// 从 tags.yaml 重新加载，文件不存在时清空
+func (r *TagRegistry) load() error {
+	tags := make(map[string]*TagMeta)
+	if err := ReadYAMLFile(r.path, &tags); err != nil {
+		return fmt.Errorf("读取标签文件失败: %w", err)
+	}
+	if tags == nil {
+		tags = make(map[string]*TagMeta)
+	}
+	for name, meta := range tags {
+		if meta == nil {
+			meta = &TagMeta{}
+			tags[name] = meta
+		}
+		meta.Name = name
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tags = tags
+	r.rebuildAliases()
+	return nil
+}
+
+// 重建别名索引，调用方需持有写锁
+func (r *TagRegistry) rebuildAliases() {
+	r.aliases = make(map[string]string)
+	for name, meta := range r.tags {
+		for _, alias := range meta.Aliases {
+			r.aliases[alias] = name
+		}
+	}
+}
+
+// Get 获取标签元数据，未登记时返回 nil
+func (r *TagRegistry) Get(name string) *TagMeta {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	meta, ok := r.tags[name]
+	if !ok {
+		return nil
+	}
+	copied := *meta
+	copied.Aliases = append([]string(nil), meta.Aliases...)
+	return &copied
+}
+
+// List 列出所有已登记的标签，按名称排序
+func (r *TagRegistry) List() []*TagMeta {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	list := make([]*TagMeta, 0, len(r.tags))
+	for _, meta := range r.tags {
+		copied := *meta
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Set 设置标签元数据
+func (r *TagRegistry) Set(meta *TagMeta) error {
+	if err := ValidateTag(meta.Name); err != nil {
+		return err
+	}
+	if meta.Color != "" && !colorPattern.MatchString(meta.Color) {
+		return fmt.Errorf("%w: 无效的颜色: %s", ErrInvalidTag, meta.Color)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// 别名不能与其他标签或其他标签的别名冲突
+	aliases := []string{}
+	seen := make(map[string]bool)
+	for _, alias := range meta.Aliases {
+		alias = strings.TrimPrefix(strings.TrimSpace(alias), "#")
+		if alias == "" || alias == meta.Name || seen[alias] {
+			continue
+		}
+		if err := ValidateTag(alias); err != nil {
+			return err
+		}
+		if _, ok := r.tags[alias]; ok {
+			return fmt.Errorf("%w: 别名 %s 已是一个标签", ErrInvalidTag, alias)
+		}
+		if owner, ok := r.aliases[alias]; ok && owner != meta.Name {
+			return fmt.Errorf("%w: 别名 %s 已属于标签 %s", ErrInvalidTag, alias, owner)
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+	if owner, ok := r.aliases[meta.Name]; ok {
+		return fmt.Errorf("%w: %s 已是标签 %s 的别名", ErrInvalidTag, meta.Name, owner)
+	}
+
+	copied := *meta
+	copied.Aliases = aliases
+	previous := r.tags[meta.Name]
+	r.tags[meta.Name] = &copied
+	if err := r.save(); err != nil {
+		if previous != nil {
+			r.tags[meta.Name] = previous
+		} else {
+			delete(r.tags, meta.Name)
+		}
+		return err
+	}
+	r.rebuildAliases()
+	r.notify(meta.Name)
+	return nil
+}
+
+// Delete 删除标签元数据
+func (r *TagRegistry) Delete(name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	meta, ok := r.tags[name]
+	if !ok {
+		return nil
+	}
+	delete(r.tags, name)
+	if err := r.save(); err != nil {
+		r.tags[name] = meta
+		return err
+	}
+	r.rebuildAliases()
+	r.notify(name)
+	return nil
+}
+
+func (r *TagRegistry) notify(name string) {
+	if r.changed != nil {
+		r.changed(name)
+	}
+}
+
+// Canonical 返回别名对应的标签名，不是别名时原样返回
+func (r *TagRegistry) Canonical(tag string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if name, ok := r.aliases[tag]; ok {
+		return name
+	}
+	return tag
+}
+
+// Annotate 为标签树中已登记的节点填充颜色、描述和图标
+func (r *TagRegistry) Annotate(nodes []*TagNode) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var walk func(nodes []*TagNode)
+	walk = func(nodes []*TagNode) {
+		for _, node := range nodes {
+			if meta, ok := r.tags[node.Path]; ok {
+				node.Color = meta.Color
+				node.Description = meta.Description
+				node.Icon = meta.Icon
+			}
+			walk(node.Children)
+		}
+	}
+	walk(nodes)
+}
+
+// 标签改名或合并后同步元数据：rename 返回新名称时把元数据移过去，
+// 目标已有元数据时只合并别名
+func (r *TagRegistry) rewrite(rename func(string) (string, bool)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	moved := make(map[string]string)
+	for name := range r.tags {
+		if newName, ok := rename(name); ok && newName != name {
+			moved[name] = newName
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	// 在副本上修改，写入成功后才替换，失败时注册表保持不变
+	tags := make(map[string]*TagMeta, len(r.tags))
+	for name, meta := range r.tags {
+		copied := *meta
+		copied.Aliases = append([]string(nil), meta.Aliases...)
+		tags[name] = &copied
+	}
+	for name, newName := range moved {
+		meta := tags[name]
+		delete(tags, name)
+		if target, exists := tags[newName]; exists {
+			target.Aliases = append(target.Aliases, meta.Aliases...)
+			continue
+		}
+		meta.Name = newName
+		tags[newName] = meta
+	}
+	if err := r.write(tags); err != nil {
+		return err
+	}
+	r.tags = tags
+	r.rebuildAliases()
+	return nil
+}
+
+// 写入 tags.yaml，调用方需持有写锁
+func (r *TagRegistry) save() error {
+	return r.write(r.tags)
+}
+
+func (r *TagRegistry) write(tags map[string]*TagMeta) error {
+	return WriteYAMLFile(r.path, tags)
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTagRegistryAliases(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-tag-registry-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	registry := store.TagRegistry()
+	if err := registry.Set(&TagMeta{Name: "javascript", Color: "#f7df1e", Aliases: []string{"js", "#JS"}}); err != nil {
+		t.Fatalf("设置标签失败: %v", err)
+	}
+	if err := registry.Set(&TagMeta{Name: "golang", Aliases: []string{"js"}}); err == nil {
+		t.Error("别名属于其他标签时应当失败")
+	}
+	if err := registry.Set(&TagMeta{Name: "go", Color: "blue"}); err == nil {
+		t.Error("无效的颜色应当失败")
+	}
+
+	// 保存时别名被替换为标签名，正文中的 #别名 一并改写
+	memo := &Memo{Tags: []string{"JS"}, Content: "学习 #js 和 `#js`"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if want := []string{"javascript"}; !reflect.DeepEqual(memo.Tags, want) {
+		t.Errorf("别名未被规范化: 期望 %v, 实际 %v", want, memo.Tags)
+	}
+	if memo.Content != "学习 #javascript 和 `#js`" {
+		t.Errorf("正文中的别名未被改写: %s", memo.Content)
+	}
+
+	// 重新加载后元数据仍然存在
+	reloaded, err := NewTagRegistry(tempDir)
+	if err != nil {
+		t.Fatalf("加载标签文件失败: %v", err)
+	}
+	meta := reloaded.Get("javascript")
+	if meta == nil || meta.Color != "#f7df1e" || !reflect.DeepEqual(meta.Aliases, []string{"js", "JS"}) {
+		t.Errorf("重新加载的标签元数据不正确: %+v", meta)
+	}
+
+	// 改名时元数据跟随标签移动
+	if _, err := store.RenameTag("javascript", "编程/js语言"); err != nil {
+		t.Fatalf("标签改名失败: %v", err)
+	}
+	if registry.Get("javascript") != nil || registry.Get("编程/js语言") == nil {
+		t.Error("改名后标签元数据未移动")
+	}
+	if got := registry.Canonical("js"); got != "编程/js语言" {
+		t.Errorf("改名后别名指向不正确: %s", got)
+	}
+}
@@ -0,0 +1,314 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagNode 表示层级标签树中的一个节点，a/b/c 形式的标签按 / 分层
+type TagNode struct {
+	Name        string     `json:"name"`       // 当前层级的名称，如 c
+	Path        string     `json:"path"`       // 完整标签，如 a/b/c
+	Count       int        `json:"count"`      // 直接使用该标签的备忘录数
+	TotalCount  int        `json:"totalCount"` // 使用该标签或其子标签的备忘录数
+	LastUsed    *time.Time `json:"lastUsed"`   // 使用该标签或其子标签的备忘录的最近更新时间
+	Sources     []string   `json:"sources"`    // 标签来源: frontmatter 和/或 content
+	Color       string     `json:"color,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+	Children    []*TagNode `json:"children"`
+
+	memos   map[string]bool
+	sources map[string]bool
+}
+
+// TagChange 描述一条备忘录在标签改名或合并中的变化
+type TagChange struct {
+	ID      string   `json:"id"`
+	OldTags []string `json:"oldTags"`
+	NewTags []string `json:"newTags"`
+}
+
+// TagRewriteResult 汇总标签改名或合并的结果
+type TagRewriteResult struct {
+	Changed int          `json:"changed"`
+	Memos   []*TagChange `json:"memos"`
+}
+
+// ErrInvalidTag 表示标签名或标签操作不合法
+var ErrInvalidTag = errors.New("无效的标签")
+
+// ValidateTag 校验标签名
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("%w: 标签不能为空", ErrInvalidTag)
+	}
+	for _, segment := range strings.Split(tag, "/") {
+		if strings.TrimSpace(segment) == "" {
+			return fmt.Errorf("%w: %s", ErrInvalidTag, tag)
+		}
+	}
+	if strings.ContainsAny(tag, " \t\r\n#") {
+		return fmt.Errorf("%w: 标签不能包含空白或 #: %s", ErrInvalidTag, tag)
+	}
+	return nil
+}
+
+// BuildTagTree 根据备忘录构建层级标签树，同层按名称排序
+func BuildTagTree(memos []*Memo) []*TagNode {
+	root := &TagNode{}
+	nodes := make(map[string]*TagNode)
+
+	for _, memo := range memos {
+		for tag, sources := range TagSources(memo) {
+			segments := strings.Split(tag, "/")
+			parent := root
+			for i := range segments {
+				path := strings.Join(segments[:i+1], "/")
+				node, ok := nodes[path]
+				if !ok {
+					node = &TagNode{
+						Name:     segments[i],
+						Path:     path,
+						Sources:  []string{},
+						Children: []*TagNode{},
+						memos:    make(map[string]bool),
+						sources:  make(map[string]bool),
+					}
+					nodes[path] = node
+					parent.Children = append(parent.Children, node)
+				}
+
+				node.memos[memo.ID] = true
+				if node.LastUsed == nil || memo.UpdatedAt.After(*node.LastUsed) {
+					updatedAt := memo.UpdatedAt
+					node.LastUsed = &updatedAt
+				}
+				if i == len(segments)-1 {
+					node.Count++
+					for _, source := range sources {
+						node.sources[source] = true
+					}
+				}
+				parent = node
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		node.TotalCount = len(node.memos)
+		for _, source := range []string{TagSourceFrontMatter, TagSourceContent} {
+			if node.sources[source] {
+				node.Sources = append(node.Sources, source)
+			}
+		}
+	}
+	sortTagNodes(root.Children)
+	return root.Children
+}
+
+func sortTagNodes(nodes []*TagNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	for _, node := range nodes {
+		sortTagNodes(node.Children)
+	}
+}
+
+// 按映射改写标签列表并去掉重复，返回改写后的列表和是否有改动
+func renameTags(tags []string, rename func(string) (string, bool)) ([]string, bool) {
+	renamed := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	modified := false
+	for _, tag := range tags {
+		if newTag, ok := rename(tag); ok {
+			tag = newTag
+			modified = true
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			renamed = append(renamed, tag)
+		}
+	}
+	return renamed, modified
+}
+
+// 判断 tag 是否为 prefix 本身或其子标签，是则返回替换前缀后的标签
+func replaceTagPrefix(tag, prefix, replacement string) (string, bool) {
+	if tag == prefix {
+		return replacement, true
+	}
+	if strings.HasPrefix(tag, prefix+"/") {
+		return replacement + strings.TrimPrefix(tag, prefix), true
+	}
+	return "", false
+}
+
+// RenameTag 将标签及其子标签改名，如 a/b 改为 x 时 a/b/c 变为 x/c
+func (s *MemoStore) RenameTag(from, to string) (*TagRewriteResult, error) {
+	if err := ValidateTag(from); err != nil {
+		return nil, err
+	}
+	if err := ValidateTag(to); err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: 新旧标签相同: %s", ErrInvalidTag, from)
+	}
+	if strings.HasPrefix(to, from+"/") {
+		return nil, fmt.Errorf("%w: 不能将标签改名为自己的子标签: %s", ErrInvalidTag, to)
+	}
+
+	return s.rewriteTags(func(tag string) (string, bool) {
+		return replaceTagPrefix(tag, from, to)
+	})
+}
+
+// MergeTags 将多个标签（及其子标签）合并到目标标签下
+func (s *MemoStore) MergeTags(sources []string, target string) (*TagRewriteResult, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%w: 未指定要合并的标签", ErrInvalidTag)
+	}
+	if err := ValidateTag(target); err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if err := ValidateTag(source); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(target, source+"/") {
+			return nil, fmt.Errorf("%w: 不能将标签合并到自己的子标签: %s", ErrInvalidTag, target)
+		}
+	}
+
+	return s.rewriteTags(func(tag string) (string, bool) {
+		for _, source := range sources {
+			if source == target {
+				continue
+			}
+			if renamed, ok := replaceTagPrefix(tag, source, target); ok {
+				return renamed, true
+			}
+		}
+		return "", false
+	})
+}
+
+// 按映射改写所有备忘录的标签（包括正文中的 #标签），全部写入成功或全部不变
+func (s *MemoStore) rewriteTags(rename func(string) (string, bool)) (*TagRewriteResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	memos, err := s.listMemosLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TagRewriteResult{Memos: []*TagChange{}}
+	var changed []*Memo
+	now := s.now().Truncate(time.Second)
+
+	for _, memo := range memos {
+		newTags, modified := renameTags(memo.Tags, rename)
+
+		content := memo.Content
+		if s.hashtagPolicy != HashtagPolicyFrontMatter {
+			content = rewriteHashtags(memo.Content, rename)
+		}
+		if !modified && content == memo.Content {
+			continue
+		}
+
+		result.Memos = append(result.Memos, &TagChange{ID: memo.ID, OldTags: memo.Tags, NewTags: newTags})
+		updated := *memo
+		updated.Tags = newTags
+		updated.Content = content
+		updated.UpdatedAt = now
+		if s.hashtagPolicy != HashtagPolicyFrontMatter {
+			// 改名后显式标签可能与正文标签重合，重新记录
+			explicit, _ := renameTags(explicitTags(memo, ExtractHashtags(memo.Content)), rename)
+			updated.explicitTags = overlappingTags(explicit, ExtractHashtags(content))
+		}
+		changed = append(changed, &updated)
+	}
+
+	// 标签元数据写入失败时恢复备忘录文件
+	if err := s.saveMemosAtomicThen(changed, func() error { return s.tags.rewrite(rename) }); err != nil {
+		return nil, err
+	}
+	s.tagsChanged()
+	result.Changed = len(changed)
+	return result, nil
+}
+
+// 批量保存备忘录：先全部写入临时文件，再逐个替换，替换失败时恢复已替换的文件
+func (s *MemoStore) saveMemosAtomic(memos []*Memo) error {
+	return s.saveMemosAtomicThen(memos, nil)
+}
+
+// 与 saveMemosAtomic 相同，全部替换后再调用 then，then 失败时同样恢复所有文件
+func (s *MemoStore) saveMemosAtomicThen(memos []*Memo, then func() error) error {
+	type pending struct {
+		path     string
+		tmp      string
+		original []byte
+	}
+	var files []pending
+
+	cleanup := func() {
+		for _, f := range files {
+			os.Remove(f.tmp)
+		}
+	}
+
+	for _, memo := range memos {
+		content, err := formatMemoFile(memo)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("格式化备忘录失败: %w", err)
+		}
+		if content, err = s.encodeMemo(content, memo.Tags); err != nil {
+			cleanup()
+			return fmt.Errorf("加密备忘录失败: %w", err)
+		}
+		path := s.getMemoPath(memo.ID)
+		original, err := os.ReadFile(path)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("读取备忘录文件失败: %w", err)
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, content, 0644); err != nil {
+			cleanup()
+			return fmt.Errorf("写入备忘录文件失败: %w", err)
+		}
+		files = append(files, pending{path: path, tmp: tmp, original: original})
+	}
+
+	// 恢复已经替换的文件
+	restore := func(done []pending) {
+		for _, f := range done {
+			os.WriteFile(f.path, f.original, 0644)
+		}
+	}
+	for i, f := range files {
+		if err := os.Rename(f.tmp, f.path); err != nil {
+			restore(files[:i])
+			cleanup()
+			return fmt.Errorf("替换备忘录文件失败: %w", err)
+		}
+	}
+	if then != nil {
+		if err := then(); err != nil {
+			restore(files)
+			return err
+		}
+	}
+	for _, memo := range memos {
+		s.memoChanged(EventMemoUpdated, memo.ID)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagTreeRenameMerge(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-tags-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	memos := []*Memo{
+		{Tags: []string{"工作/项目A"}, Content: "进展 #工作/项目A/后端"},
+		{Tags: []string{"工作"}, Content: "周报"},
+		{Tags: []string{"生活"}, Content: "`#工作` 不是标签"},
+	}
+	for _, memo := range memos {
+		if err := store.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+	}
+
+	all, err := store.ListMemos()
+	if err != nil {
+		t.Fatalf("列出备忘录失败: %v", err)
+	}
+	tree := BuildTagTree(all)
+	if len(tree) != 2 || tree[0].Name != "工作" || tree[1].Name != "生活" {
+		t.Fatalf("标签树顶层不正确: %+v", tree)
+	}
+	work := tree[0]
+	if work.Count != 1 || work.TotalCount != 2 {
+		t.Errorf("工作 标签计数不正确: count=%d total=%d", work.Count, work.TotalCount)
+	}
+	if len(work.Children) != 1 || work.Children[0].Path != "工作/项目A" || len(work.Children[0].Children) != 1 {
+		t.Fatalf("子标签结构不正确: %+v", work.Children)
+	}
+	if backend := work.Children[0].Children[0]; backend.Path != "工作/项目A/后端" || !reflect.DeepEqual(backend.Sources, []string{TagSourceContent}) {
+		t.Errorf("正文标签节点不正确: %+v", backend)
+	}
+	if work.LastUsed == nil {
+		t.Error("未记录标签的最近使用时间")
+	}
+
+	// 改名会同时改写子标签和正文中的 #标签
+	result, err := store.RenameTag("工作", "job")
+	if err != nil {
+		t.Fatalf("标签改名失败: %v", err)
+	}
+	if result.Changed != 2 {
+		t.Errorf("改名影响的备忘录数不正确: 期望 2, 实际 %d", result.Changed)
+	}
+	renamed, err := store.GetMemo(memos[0].ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if want := []string{"job/项目A", "job/项目A/后端"}; !reflect.DeepEqual(renamed.Tags, want) {
+		t.Errorf("改名后的标签不正确: 期望 %v, 实际 %v", want, renamed.Tags)
+	}
+	if renamed.Content != "进展 #job/项目A/后端" {
+		t.Errorf("正文中的标签未被改写: %s", renamed.Content)
+	}
+	untouched, err := store.GetMemo(memos[2].ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if untouched.Content != memos[2].Content {
+		t.Errorf("代码中的内容不应被改写: %s", untouched.Content)
+	}
+
+	// 合并时去除重复标签
+	if _, err := store.MergeTags([]string{"job/项目A", "生活"}, "job"); err != nil {
+		t.Fatalf("合并标签失败: %v", err)
+	}
+	merged, err := store.GetMemo(memos[2].ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if want := []string{"job"}; !reflect.DeepEqual(merged.Tags, want) {
+		t.Errorf("合并后的标签不正确: 期望 %v, 实际 %v", want, merged.Tags)
+	}
+
+	if _, err := store.RenameTag("job", "job/子标签"); err == nil {
+		t.Error("改名为自己的子标签应当失败")
+	}
+	if _, err := store.MergeTags([]string{"生活", "job"}, "job/子标签"); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("合并到来源的子标签应当失败: %v", err)
+	}
+}
+
+func TestRewriteTagsRollback(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-tags-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	memo := &Memo{Content: "正文 #旧"}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if err := store.TagRegistry().Set(&TagMeta{Name: "旧", Color: "#ff0000"}); err != nil {
+		t.Fatalf("设置标签元数据失败: %v", err)
+	}
+
+	// tags.yaml 无法写入时改名失败，备忘录和注册表都保持不变
+	registry := filepath.Join(tempDir, "tags.yaml")
+	os.Remove(registry)
+	if err := os.Mkdir(registry, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if _, err := store.RenameTag("旧", "新"); err == nil {
+		t.Fatal("标签元数据写入失败时改名应当失败")
+	}
+	unchanged, err := store.GetMemo(memo.ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if unchanged.Content != "正文 #旧" || !reflect.DeepEqual(unchanged.Tags, []string{"旧"}) {
+		t.Errorf("改名失败后备忘录应保持不变: %+v", unchanged)
+	}
+	if store.TagRegistry().Get("旧") == nil || store.TagRegistry().Get("新") != nil {
+		t.Error("改名失败后标签元数据应保持不变")
+	}
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 任务相关的错误
+var (
+	ErrTaskNotFound = errors.New("任务不存在")
+	ErrTaskChanged  = errors.New("任务内容已变化")
+)
+
+// 任务状态筛选
+const (
+	TaskStatusOpen = "open" // 未完成
+	TaskStatusDone = "done" // 已完成
+)
+
+// Task 表示备忘录中的一个 - [ ] 任务
+type Task struct {
+	MemoID string `json:"memoId"`
+	Line   int    `json:"line"` // 在正文中的行号，从1开始
+	Text   string `json:"text"` // 去掉复选框和 @due(...) 后的文字
+	Done   bool   `json:"done"`
+	Due    string `json:"due,omitempty"` // @due(2024-05-01) 中的日期
+}
+
+// 匹配任务列表项，分组依次为复选框之前的部分、勾选标记、复选框之后的文字
+var taskPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\]\s+(.*\S.*)$`)
+
+// 匹配 @due(2024-05-01)
+var duePattern = regexp.MustCompile(`@due\((\d{4}-\d{2}-\d{2})\)`)
+
+// 匹配代码块的开始和结束
+var fencePattern = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})")
+
+// 解析一行任务，不是任务时返回 nil
+func parseTaskLine(line string) *Task {
+	m := taskPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if m == nil {
+		return nil
+	}
+	task := &Task{Done: m[2] != " "}
+	text := m[3]
+	if due := duePattern.FindStringSubmatch(text); due != nil {
+		if _, err := time.Parse("2006-01-02", due[1]); err == nil {
+			task.Due = due[1]
+			text = duePattern.ReplaceAllString(text, "")
+		}
+	}
+	task.Text = strings.Join(strings.Fields(text), " ")
+	return task
+}
+
+// ExtractTasks 提取正文中的任务，忽略代码块中的内容
+func ExtractTasks(memoID, content string) []*Task {
+	var tasks []*Task
+	fence := ""
+	for i, line := range strings.Split(content, "\n") {
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case strings.HasPrefix(m[1], fence) && strings.TrimSpace(line) == m[1]:
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if task := parseTaskLine(line); task != nil {
+			task.MemoID = memoID
+			task.Line = i + 1
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// TaskFilter 任务筛选条件
+type TaskFilter struct {
+	Status    string // open、done，空表示全部
+	DueBefore string // 只保留截止日期早于该日期的任务，格式 2006-01-02
+}
+
+// BuildTaskIndex 汇总所有备忘录中的任务，按截止日期排序，没有截止日期的排在最后
+func BuildTaskIndex(memos []*Memo, filter TaskFilter) []*Task {
+	tasks := []*Task{}
+	for _, memo := range memos {
+		for _, task := range ExtractTasks(memo.ID, memo.Content) {
+			switch {
+			case filter.Status == TaskStatusOpen && task.Done,
+				filter.Status == TaskStatusDone && !task.Done,
+				filter.DueBefore != "" && (task.Due == "" || task.Due >= filter.DueBefore):
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Due != b.Due {
+			if a.Due == "" || b.Due == "" {
+				return b.Due == ""
+			}
+			return a.Due < b.Due
+		}
+		if a.MemoID != b.MemoID {
+			return a.MemoID < b.MemoID
+		}
+		return a.Line < b.Line
+	})
+	return tasks
+}
+
+// Tasks 列出符合条件的任务
+func (s *MemoStore) Tasks(filter TaskFilter) ([]*Task, error) {
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+	return BuildTaskIndex(memos, filter), nil
+}
+
+// ToggleTask 切换备忘录第 line 行任务的完成状态，done 为 nil 时取反。
+// expectedText 非空时需要与当前任务文字一致，防止按过期的行号修改。
+// 只改动复选框和 updated_at 所在的行，文件的其他内容保持原样
+func (s *MemoStore) ToggleTask(memoID string, line int, done *bool, expectedText string) (*Task, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	memo, err := s.readMemoFromFile(memoID)
+	if err != nil {
+		return nil, err
+	}
+	path := s.getMemoPath(memoID)
+	data, err := s.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
+	}
+
+	var task *Task
+	for _, t := range ExtractTasks(memo.ID, memo.Content) {
+		if t.Line == line {
+			task = t
+			break
+		}
+	}
+	if task == nil {
+		return nil, fmt.Errorf("%w: %s 第 %d 行", ErrTaskNotFound, memoID, line)
+	}
+	if expectedText != "" && expectedText != task.Text {
+		return nil, fmt.Errorf("%w: %s 第 %d 行", ErrTaskChanged, memoID, line)
+	}
+	if done == nil {
+		flipped := !task.Done
+		done = &flipped
+	}
+	if *done == task.Done {
+		return task, nil
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	bodyStart, updatedLine := locateMemoBody(lines)
+	if bodyStart < 0 {
+		return nil, errors.New("无效的memo文件格式: 缺少YAML前置元数据")
+	}
+	index := bodyStart + line - 1
+	if index >= len(lines) {
+		return nil, fmt.Errorf("%w: %s 第 %d 行", ErrTaskNotFound, memoID, line)
+	}
+	m := taskPattern.FindSubmatchIndex(bytes.TrimRight(lines[index], "\r"))
+	if m == nil {
+		return nil, fmt.Errorf("%w: %s 第 %d 行", ErrTaskNotFound, memoID, line)
+	}
+	mark := byte(' ')
+	if *done {
+		mark = 'x'
+	}
+	lines[index][m[4]] = mark
+
+	now := s.now().Truncate(time.Second)
+	if updatedLine >= 0 {
+		lines[updatedLine] = []byte("updated_at: " + now.Format(time.RFC3339))
+	}
+	data, err = s.encodeMemo(bytes.Join(lines, []byte("\n")), memo.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("加密备忘录失败: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return nil, err
+	}
+	s.memoChanged(EventMemoUpdated, memoID)
+
+	task.Done = *done
+	return task, nil
+}
+
+// 找出正文第一行（去掉前导空行后）和头部 updated_at 行的位置，不存在时返回 -1
+func locateMemoBody(lines [][]byte) (bodyStart, updatedLine int) {
+	updatedLine = -1
+	if len(lines) == 0 || string(bytes.TrimRight(lines[0], "\r")) != "---" {
+		return -1, -1
+	}
+	for i := 1; i < len(lines); i++ {
+		line := bytes.TrimRight(lines[i], "\r")
+		if bytes.HasPrefix(line, []byte("updated_at:")) {
+			updatedLine = i
+		}
+		if string(line) != "---" {
+			continue
+		}
+		// 正文与 parseMemoFile 一致，跳过开头的空白行
+		for j := i + 1; j < len(lines); j++ {
+			if len(bytes.TrimSpace(lines[j])) > 0 {
+				return j, updatedLine
+			}
+		}
+		return len(lines), updatedLine
+	}
+	return -1, -1
+}
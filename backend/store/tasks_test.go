@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractTasks(t *testing.T) {
+	content := "计划\n- [ ] 写周报 @due(2024-05-01)\n- [x] 买菜\n  * [X] 嵌套任务\n- [ ]\n```\n- [ ] 代码中的任务\n```\n1. [ ] 有序列表"
+	want := []*Task{
+		{MemoID: "m", Line: 2, Text: "写周报", Due: "2024-05-01"},
+		{MemoID: "m", Line: 3, Text: "买菜", Done: true},
+		{MemoID: "m", Line: 4, Text: "嵌套任务", Done: true},
+		{MemoID: "m", Line: 9, Text: "有序列表"},
+	}
+	if got := ExtractTasks("m", content); !reflect.DeepEqual(got, want) {
+		t.Errorf("提取任务不正确: 期望 %+v, 实际 %+v", want, got)
+	}
+
+	index := BuildTaskIndex([]*Memo{{ID: "m", Content: content}}, TaskFilter{Status: TaskStatusOpen, DueBefore: "2024-06-01"})
+	if len(index) != 1 || index[0].Line != 2 {
+		t.Errorf("任务筛选不正确: %+v", index)
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-task-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	memo := &Memo{Title: "清单", Content: "- [ ] 第一项\n- [ ] 第二项  \n\n  结尾有空格  "}
+	if err := store.CreateMemo(memo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	path := store.getMemoPath(memo.ID)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+
+	if _, err := store.ToggleTask(memo.ID, 2, nil, "第一项"); !errors.Is(err, ErrTaskChanged) {
+		t.Errorf("文字不一致时应当失败: %v", err)
+	}
+	if _, err := store.ToggleTask(memo.ID, 3, nil, ""); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("不是任务的行应当失败: %v", err)
+	}
+
+	task, err := store.ToggleTask(memo.ID, 2, nil, "第二项")
+	if err != nil {
+		t.Fatalf("切换任务失败: %v", err)
+	}
+	if !task.Done {
+		t.Error("任务应当被勾选")
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	// 除了复选框和 updated_at 之外的内容保持不变
+	beforeLines := strings.Split(string(before), "\n")
+	afterLines := strings.Split(string(after), "\n")
+	if len(beforeLines) != len(afterLines) {
+		t.Fatalf("文件行数发生变化: %d -> %d", len(beforeLines), len(afterLines))
+	}
+	for i := range beforeLines {
+		if beforeLines[i] == afterLines[i] || strings.HasPrefix(beforeLines[i], "updated_at:") {
+			continue
+		}
+		if afterLines[i] != "- [x] 第二项  " {
+			t.Errorf("第 %d 行被意外修改: %q -> %q", i+1, beforeLines[i], afterLines[i])
+		}
+	}
+
+	tasks, err := store.Tasks(TaskFilter{Status: TaskStatusDone})
+	if err != nil {
+		t.Fatalf("列出任务失败: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Text != "第二项" {
+		t.Errorf("已完成的任务不正确: %+v", tasks)
+	}
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidParent 表示回复的目标不存在或会形成循环
+var ErrInvalidParent = errors.New("无效的父备忘录")
+
+// ThreadNode 对话树中的一条备忘录及其回复
+type ThreadNode struct {
+	*Memo
+	ReplyCount  int           `json:"replyCount"`  // 所有后代回复的数量
+	LastReplyAt *time.Time    `json:"lastReplyAt"` // 最近一条回复的创建时间
+	Replies     []*ThreadNode `json:"replies"`     // 直接回复，按创建时间排序
+}
+
+// 校验 parent 可以作为 id 的父备忘录，调用方需持有锁。id 为空表示新建的备忘录
+func (s *MemoStore) validateParentLocked(id, parent string) error {
+	if _, err := s.readMemoFromFile(parent); err != nil {
+		return fmt.Errorf("%w: 备忘录不存在: %s", ErrInvalidParent, parent)
+	}
+
+	// 沿 parent 向上查找，遇到自己说明会形成循环
+	seen := make(map[string]bool)
+	for current := parent; current != "" && !seen[current]; {
+		if current == id {
+			return fmt.Errorf("%w: 不能回复自己或自己的回复", ErrInvalidParent)
+		}
+		seen[current] = true
+		memo, err := s.readMemoFromFile(current)
+		if err != nil {
+			// 祖先已丢失时视为对话的根
+			return nil
+		}
+		current = memo.ParentID()
+	}
+	return nil
+}
+
+// 建立备忘录ID索引和父子关系。父备忘录不存在或处于循环中时视为根，
+// 返回的 parents 只包含有效的父子关系
+func indexThreads(memos []*Memo) (map[string]*Memo, map[string]string, map[string][]*Memo) {
+	byID := make(map[string]*Memo, len(memos))
+	for _, memo := range memos {
+		byID[memo.ID] = memo
+	}
+
+	parents := make(map[string]string)
+	for _, memo := range memos {
+		if _, ok := byID[memo.ParentID()]; ok && !inParentCycle(memo, byID) {
+			parents[memo.ID] = memo.ParentID()
+		}
+	}
+
+	children := make(map[string][]*Memo)
+	for _, memo := range memos {
+		if parent, ok := parents[memo.ID]; ok {
+			children[parent] = append(children[parent], memo)
+		}
+	}
+	for _, replies := range children {
+		sort.Slice(replies, func(i, j int) bool { return replies[i].CreatedAt.Before(replies[j].CreatedAt) })
+	}
+	return byID, parents, children
+}
+
+// 判断沿 parent 向上是否会回到 memo 自己
+func inParentCycle(memo *Memo, byID map[string]*Memo) bool {
+	seen := make(map[string]bool)
+	for current := memo.ParentID(); current != ""; {
+		if current == memo.ID {
+			return true
+		}
+		parent, ok := byID[current]
+		if !ok || seen[current] {
+			return false
+		}
+		seen[current] = true
+		current = parent.ParentID()
+	}
+	return false
+}
+
+// 从 memo 开始构建对话树，visited 防止循环
+func buildThreadNode(memo *Memo, children map[string][]*Memo, visited map[string]bool) *ThreadNode {
+	visited[memo.ID] = true
+	node := &ThreadNode{Memo: memo, Replies: []*ThreadNode{}}
+	for _, reply := range children[memo.ID] {
+		if visited[reply.ID] {
+			continue
+		}
+		child := buildThreadNode(reply, children, visited)
+		node.Replies = append(node.Replies, child)
+		node.ReplyCount += 1 + child.ReplyCount
+
+		latest := reply.CreatedAt
+		if child.LastReplyAt != nil && child.LastReplyAt.After(latest) {
+			latest = *child.LastReplyAt
+		}
+		if node.LastReplyAt == nil || latest.After(*node.LastReplyAt) {
+			node.LastReplyAt = &latest
+		}
+	}
+	return node
+}
+
+// BuildThread 返回包含指定备忘录的整个对话树，从根备忘录开始
+func BuildThread(memos []*Memo, id string) (*ThreadNode, error) {
+	byID, parents, children := indexThreads(memos)
+	root, ok := byID[id]
+	if !ok {
+		return nil, fmt.Errorf("备忘录不存在: %s", id)
+	}
+	for {
+		parent, ok := parents[root.ID]
+		if !ok {
+			break
+		}
+		root = byID[parent]
+	}
+	return buildThreadNode(root, children, make(map[string]bool)), nil
+}
+
+// CollapseThreads 只保留对话的根备忘录，回复折叠到根下面，保持 memos 原有的顺序
+func CollapseThreads(memos []*Memo) []*ThreadNode {
+	_, parents, children := indexThreads(memos)
+	threads := []*ThreadNode{}
+	for _, memo := range memos {
+		if _, ok := parents[memo.ID]; ok {
+			continue
+		}
+		threads = append(threads, buildThreadNode(memo, children, make(map[string]bool)))
+	}
+	return threads
+}
+
+// 把 id 的直接回复挂到 id 的父备忘录下（id 是根时回复成为新的根），调用方需持有写锁
+func (s *MemoStore) reparentRepliesLocked(id string) error {
+	deleted, err := s.readMemoFromFile(id)
+	if err != nil {
+		return err
+	}
+	memos, err := s.listMemosLocked()
+	if err != nil {
+		return err
+	}
+
+	var replies []*Memo
+	now := s.now().Truncate(time.Second)
+	for _, memo := range memos {
+		if memo.ParentID() != id || memo.ID == id {
+			continue
+		}
+		memo.Parent = deleted.Parent
+		memo.UpdatedAt = now
+		replies = append(replies, memo)
+	}
+	return s.saveMemosAtomic(replies)
+}
+
+// Thread 返回包含指定备忘录的对话树
+func (s *MemoStore) Thread(id string) (*ThreadNode, error) {
+	memos, err := s.ListMemos()
+	if err != nil {
+		return nil, err
+	}
+	return BuildThread(memos, id)
+}
+
+// CreateReply 创建一条回复 parent 的备忘录
+func (s *MemoStore) CreateReply(parent string, memo *Memo) error {
+	memo.Parent = &parent
+	return s.CreateMemo(memo)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMemoThreads(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-thread-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+
+	root := &Memo{Content: "根"}
+	if err := store.CreateMemo(root); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	middle := &Memo{Content: "中间"}
+	if err := store.CreateReply(root.ID, middle); err != nil {
+		t.Fatalf("创建回复失败: %v", err)
+	}
+	leaf := &Memo{Content: "叶子"}
+	if err := store.CreateReply(middle.ID, leaf); err != nil {
+		t.Fatalf("创建回复失败: %v", err)
+	}
+	other := &Memo{Content: "无关"}
+	if err := store.CreateMemo(other); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+
+	if err := store.CreateReply("2000-01-01-1", &Memo{Content: "x"}); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("回复不存在的备忘录应当失败: %v", err)
+	}
+	if err := store.UpdateMemo(root.ID, &Memo{Parent: &leaf.ID}); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("形成循环的回复应当失败: %v", err)
+	}
+
+	// 从任意一条备忘录都能取到完整的对话树
+	thread, err := store.Thread(leaf.ID)
+	if err != nil {
+		t.Fatalf("获取对话失败: %v", err)
+	}
+	if thread.ID != root.ID || thread.ReplyCount != 2 || len(thread.Replies) != 1 ||
+		thread.Replies[0].ID != middle.ID || thread.Replies[0].Replies[0].ID != leaf.ID {
+		t.Errorf("对话树结构不正确: %+v", thread)
+	}
+
+	memos, err := store.ListMemos()
+	if err != nil {
+		t.Fatalf("列出备忘录失败: %v", err)
+	}
+	if collapsed := CollapseThreads(memos); len(collapsed) != 2 {
+		t.Errorf("折叠后应只剩2个根备忘录, 实际 %d", len(collapsed))
+	}
+
+	// 删除中间的备忘录后，回复挂到它的父备忘录下
+	if err := store.DeleteMemo(middle.ID); err != nil {
+		t.Fatalf("删除备忘录失败: %v", err)
+	}
+	reparented, err := store.GetMemo(leaf.ID)
+	if err != nil {
+		t.Fatalf("获取备忘录失败: %v", err)
+	}
+	if reparented.ParentID() != root.ID {
+		t.Errorf("回复未挂到上一级: 期望 %s, 实际 %s", root.ID, reparented.ParentID())
+	}
+	thread, err = store.Thread(root.ID)
+	if err != nil {
+		t.Fatalf("获取对话失败: %v", err)
+	}
+	if thread.ReplyCount != 1 || thread.Replies[0].ID != leaf.ID {
+		t.Errorf("删除后的对话树不正确: %+v", thread)
+	}
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrMemoExists 表示恢复时已有同ID的备忘录
+var ErrMemoExists = errors.New("备忘录已存在")
+
+// TrashedMemo 回收站中的备忘录
+type TrashedMemo struct {
+	*Memo
+	TrashedAt time.Time `json:"trashedAt"` // 移入回收站的时间
+}
+
+// 获取回收站目录的路径
+func (s *MemoStore) getTrashDir() string {
+	return filepath.Join(s.dataDir, "trash")
+}
+
+// 获取回收站中备忘录文件的路径
+func (s *MemoStore) getTrashPath(id string) string {
+	return filepath.Join(s.getTrashDir(), id+".md")
+}
+
+// TrashMemo 将备忘录移入回收站
+func (s *MemoStore) TrashMemo(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.trashMemosLocked([]string{id})
+}
+
+// 将备忘录文件移入回收站，调用方需持有写锁。回收站中的ID仍然占用序号，不更新序号缓存
+func (s *MemoStore) trashMemosLocked(ids []string) error {
+	if err := os.MkdirAll(s.getTrashDir(), 0755); err != nil {
+		return fmt.Errorf("无法创建trash目录: %w", err)
+	}
+	for _, id := range ids {
+		if _, err := os.Stat(s.getMemoPath(id)); err != nil {
+			return fmt.Errorf("备忘录不存在: %s", id)
+		}
+	}
+	now := s.now()
+	for _, id := range ids {
+		if err := s.revokeSharesLocked(id); err != nil {
+			return err
+		}
+		trashPath := s.getTrashPath(id)
+		if err := os.Rename(s.getMemoPath(id), trashPath); err != nil {
+			return fmt.Errorf("移入回收站失败: %w", err)
+		}
+		// 用文件修改时间记录移入回收站的时间
+		os.Chtimes(trashPath, now, now)
+		s.memoChanged(EventMemoDeleted, id)
+	}
+	return nil
+}
+
+// ListTrash 列出回收站中的备忘录，最近移入的在前
+func (s *MemoStore) ListTrash() ([]*TrashedMemo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries, err := os.ReadDir(s.getTrashDir())
+	if os.IsNotExist(err) {
+		return []*TrashedMemo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取trash目录失败: %w", err)
+	}
+
+	trashed := []*TrashedMemo{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".md")
+		data, err := s.readFile(s.getTrashPath(id))
+		if err != nil {
+			return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
+		}
+		memo, err := parseMemoFile(data, id)
+		if err != nil {
+			return nil, fmt.Errorf("读取备忘录文件失败: %w", err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		trashed = append(trashed, &TrashedMemo{Memo: memo, TrashedAt: info.ModTime()})
+	}
+	sort.Slice(trashed, func(i, j int) bool { return trashed[i].TrashedAt.After(trashed[j].TrashedAt) })
+	return trashed, nil
+}
+
+// RestoreMemo 将回收站中的备忘录恢复
+func (s *MemoStore) RestoreMemo(id string) (*Memo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	trashPath := s.getTrashPath(id)
+	data, err := s.readFile(trashPath)
+	if err != nil {
+		return nil, fmt.Errorf("回收站中没有该备忘录: %s", id)
+	}
+	if _, err := os.Stat(s.getMemoPath(id)); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMemoExists, id)
+	}
+	memo, err := parseMemoFile(data, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(trashPath, s.getMemoPath(id)); err != nil {
+		return nil, fmt.Errorf("恢复备忘录失败: %w", err)
+	}
+	s.memoChanged(EventMemoCreated, id)
+	return memo, nil
+}
+
+// PurgeMemo 从回收站中彻底删除备忘录
+func (s *MemoStore) PurgeMemo(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(s.getTrashPath(id)); err != nil {
+		return fmt.Errorf("回收站中没有该备忘录: %s", id)
+	}
+	return nil
+}
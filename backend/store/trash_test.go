@@ -0,0 +1,87 @@
+package store
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeMemosAndTrash(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "memo-trash-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewMemoStore(tempDir)
+	if err != nil {
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	first := &Memo{Tags: []string{"读书"}, Content: "读完了 #小说"}
+	second := &Memo{Tags: []string{"手机"}, Content: "读完了  #小说"}
+	third := &Memo{Content: "补充：结局很好"}
+	for _, memo := range []*Memo{first, second, third} {
+		if err := store.CreateMemo(memo); err != nil {
+			t.Fatalf("创建备忘录失败: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+	reply := &Memo{Content: "回复"}
+	if err := store.CreateReply(second.ID, reply); err != nil {
+		t.Fatalf("创建回复失败: %v", err)
+	}
+
+	merged, err := store.MergeMemos(first.ID, []string{second.ID, third.ID})
+	if err != nil {
+		t.Fatalf("合并备忘录失败: %v", err)
+	}
+	if want := "读完了 #小说" + mergeSeparator + "补充：结局很好"; merged.Content != want {
+		t.Errorf("合并后的正文不正确: %q", merged.Content)
+	}
+	if want := []string{"读书", "手机", "小说"}; !reflect.DeepEqual(merged.Tags, want) {
+		t.Errorf("合并后的标签不正确: 期望 %v, 实际 %v", want, merged.Tags)
+	}
+
+	memos, err := store.ListMemos()
+	if err != nil {
+		t.Fatalf("列出备忘录失败: %v", err)
+	}
+	if len(memos) != 2 {
+		t.Errorf("回收站中的备忘录不应出现在列表中: %d", len(memos))
+	}
+	moved, err := store.GetMemo(reply.ID)
+	if err != nil || moved.ParentID() != first.ID {
+		t.Errorf("回复未改为回复合并后的备忘录: %+v, %v", moved, err)
+	}
+
+	trashed, err := store.ListTrash()
+	if err != nil || len(trashed) != 2 {
+		t.Fatalf("回收站内容不正确: %+v, %v", trashed, err)
+	}
+
+	// 回收站中的ID仍然占用序号
+	fresh := &Memo{Content: "新的"}
+	if err := store.CreateMemo(fresh); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	if fresh.ID == second.ID || fresh.ID == third.ID {
+		t.Errorf("新备忘录复用了回收站中的ID: %s", fresh.ID)
+	}
+
+	if _, err := store.RestoreMemo(third.ID); err != nil {
+		t.Fatalf("恢复备忘录失败: %v", err)
+	}
+	if _, err := store.GetMemo(third.ID); err != nil {
+		t.Errorf("恢复后无法获取备忘录: %v", err)
+	}
+	if err := store.PurgeMemo(second.ID); err != nil {
+		t.Fatalf("彻底删除失败: %v", err)
+	}
+	if trashed, _ := store.ListTrash(); len(trashed) != 0 {
+		t.Errorf("回收站应当为空: %+v", trashed)
+	}
+}
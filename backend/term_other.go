@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package main
+
+// 其他系统上无法关闭回显，口令会显示在终端中，建议使用 --keyfile 或 RAMBLOG_PASSPHRASE
+func disableEcho(fd int) func() {
+	return func() {}
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// 关闭终端回显，返回恢复原设置的函数。fd 不是终端时什么也不做
+func disableEcho(fd int) func() {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return func() {}
+	}
+	original := *termios
+	termios.Lflag &^= unix.ECHO
+	termios.Lflag |= unix.ICANON | unix.ISIG
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return func() {}
+	}
+	return func() { unix.IoctlSetTermios(fd, ioctlSetTermios, &original) }
+}
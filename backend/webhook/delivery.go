@@ -0,0 +1,276 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// 发送记录保留的条数
+const deliveryLogSize = 500
+
+// 发送结果
+const (
+	ResultDelivered = "delivered" // 对方返回 2xx
+	ResultRetry     = "retry"     // 失败，稍后重试
+	ResultFailed    = "failed"    // 重试次数用完，放弃
+)
+
+// Delivery 队列中等待发送的请求。队列文件不加密，只保存事件本身，
+// 备忘录的内容在发送时从存储中读取，启用加密存储时不会以明文留在数据目录中
+type Delivery struct {
+	ID          string    `json:"id"`
+	HookID      string    `json:"hookId"`
+	Event       string    `json:"event"`
+	MemoID      string    `json:"memoId,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Source      string    `json:"source"`
+	EventTime   time.Time `json:"eventTime"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Attempt 一次发送的记录
+type Attempt struct {
+	DeliveryID string     `json:"deliveryId"`
+	HookID     string     `json:"hookId"`
+	Event      string     `json:"event"`
+	MemoID     string     `json:"memoId,omitempty"`
+	URL        string     `json:"url"`
+	Attempt    int        `json:"attempt"` // 第几次发送，从 1 开始
+	StatusCode int        `json:"statusCode,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Duration   int64      `json:"durationMs"`
+	Result     string     `json:"result"`
+	NextRetry  *time.Time `json:"nextRetry,omitempty"`
+	Time       time.Time  `json:"time"`
+}
+
+// Sign 计算请求体的签名，即 X-Ramblog-Signature 请求头的值。接收方用同样的密钥计算后比较
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliveries 返回最近的发送记录，从新到旧。hookID 为空时返回全部 webhook 的记录，limit 不大于 0 时不限制数量
+func (d *Dispatcher) Deliveries(hookID string, limit int) []*Attempt {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	attempts := []*Attempt{}
+	for _, attempt := range d.deliveries {
+		if hookID != "" && attempt.HookID != hookID {
+			continue
+		}
+		copied := *attempt
+		attempts = append(attempts, &copied)
+		if limit > 0 && len(attempts) == limit {
+			break
+		}
+	}
+	return attempts
+}
+
+// Pending 返回队列中等待发送的请求数量
+func (d *Dispatcher) Pending(hookID string) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	count := 0
+	for _, delivery := range d.queue {
+		if hookID == "" || delivery.HookID == hookID {
+			count++
+		}
+	}
+	return count
+}
+
+// 发送所有到期的请求，返回距下一个请求到期的时间
+func (d *Dispatcher) deliverDue(ctx context.Context) time.Duration {
+	d.mutex.Lock()
+	now := d.now()
+	var due []*Delivery
+	for _, delivery := range d.queue {
+		if !delivery.NextAttempt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	d.mutex.Unlock()
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		d.deliver(ctx, delivery)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	wait := d.maxDelay
+	now = d.now()
+	for _, delivery := range d.queue {
+		if next := delivery.NextAttempt.Sub(now); next < wait {
+			wait = next
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// 发送一个请求并根据结果把它移出队列或安排重试
+func (d *Dispatcher) deliver(ctx context.Context, delivery *Delivery) {
+	d.mutex.Lock()
+	index := d.indexLocked(delivery.HookID)
+	if index < 0 || d.hooks[index].Disabled {
+		// webhook 已删除或停用，不再发送
+		d.removeLocked(delivery.ID)
+		d.saveQueueLocked()
+		d.mutex.Unlock()
+		return
+	}
+	hook := *d.hooks[index]
+	d.mutex.Unlock()
+
+	attempt := &Attempt{
+		DeliveryID: delivery.ID,
+		HookID:     delivery.HookID,
+		Event:      delivery.Event,
+		MemoID:     delivery.MemoID,
+		URL:        hook.URL,
+		Attempt:    delivery.Attempts + 1,
+		Time:       d.now(),
+	}
+	started := time.Now()
+	status, err := d.post(ctx, &hook, delivery)
+	attempt.Duration = time.Since(started).Milliseconds()
+	attempt.StatusCode = status
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("对方返回 %d", status)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	switch {
+	case err == nil:
+		attempt.Result = ResultDelivered
+		d.removeLocked(delivery.ID)
+	case attempt.Attempt >= d.maxAttempts:
+		attempt.Error = err.Error()
+		attempt.Result = ResultFailed
+		d.removeLocked(delivery.ID)
+		log.Printf("webhook %s 发送 %s 失败，已放弃: %v", hook.ID, delivery.ID, err)
+	default:
+		attempt.Error = err.Error()
+		attempt.Result = ResultRetry
+		delivery.Attempts = attempt.Attempt
+		delivery.NextAttempt = d.now().Add(d.backoff(delivery.Attempts))
+		next := delivery.NextAttempt
+		attempt.NextRetry = &next
+	}
+	d.saveQueueLocked()
+
+	d.deliveries = append([]*Attempt{attempt}, d.deliveries...)
+	if len(d.deliveries) > deliveryLogSize {
+		d.deliveries = d.deliveries[:deliveryLogSize]
+	}
+	d.saveDeliveriesLocked()
+}
+
+// 生成请求体。备忘录在发送时读取，发送前已被删除时不带备忘录
+func (d *Dispatcher) payload(delivery *Delivery) ([]byte, error) {
+	payload := Payload{
+		ID:     delivery.ID,
+		Event:  delivery.Event,
+		Time:   delivery.EventTime,
+		MemoID: delivery.MemoID,
+		Tags:   delivery.Tags,
+		Source: delivery.Source,
+	}
+	if delivery.MemoID != "" && delivery.Event != store.EventMemoDeleted {
+		payload.Memo, _ = d.store.GetMemo(delivery.MemoID)
+	}
+	return json.Marshal(payload)
+}
+
+// 发送请求，返回状态码
+func (d *Dispatcher) post(ctx context.Context, hook *Hook, delivery *Delivery) (int, error) {
+	body, err := d.payload(delivery)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Ramblog-Webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// 第 n 次失败后的等待时间，每次翻倍，不超过 maxDelay
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryDelay
+	for i := 1; i < attempts && delay < d.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+	return delay
+}
+
+func (d *Dispatcher) removeLocked(id string) {
+	for i, delivery := range d.queue {
+		if delivery.ID == id {
+			d.queue = append(d.queue[:i:i], d.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// 保存队列。失败时只记录日志，内存中的队列仍会发送，只是重启后会丢失
+func (d *Dispatcher) saveQueueLocked() error {
+	data, err := json.Marshal(d.queue)
+	if err == nil {
+		err = store.WriteDataFile(filepath.Join(d.dataDir, queueFileName), data)
+	}
+	if err != nil {
+		log.Printf("保存 webhook 队列失败: %v", err)
+	}
+	return err
+}
+
+func (d *Dispatcher) saveDeliveriesLocked() {
+	data, err := json.Marshal(d.deliveries)
+	if err == nil {
+		err = store.WriteDataFile(filepath.Join(d.dataDir, deliveriesFileName), data)
+	}
+	if err != nil {
+		log.Printf("保存 webhook 发送记录失败: %v", err)
+	}
+}
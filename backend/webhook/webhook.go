@@ -0,0 +1,389 @@
+// Package webhook 把备忘录和标签的变更事件推送到外部地址。
+// 请求体用 HMAC-SHA256 签名，发送失败时按指数退避重试，待发送的队列保存在数据目录中，重启后继续发送
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// Events 可以订阅的事件
+var Events = []string{store.EventMemoCreated, store.EventMemoUpdated, store.EventMemoDeleted, store.EventTagsChanged}
+
+// 数据目录中的文件
+const (
+	hooksFileName      = "webhooks.yaml"
+	queueFileName      = "webhook-queue.json"
+	deliveriesFileName = "webhook-deliveries.json"
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Ramblog-Event"
+	HeaderDelivery  = "X-Ramblog-Delivery"
+	HeaderSignature = "X-Ramblog-Signature" // sha256=<请求体的 HMAC-SHA256 十六进制>
+)
+
+var (
+	// ErrHookNotFound webhook 不存在
+	ErrHookNotFound = errors.New("webhook 不存在")
+	// ErrInvalidHook webhook 配置无效
+	ErrInvalidHook = errors.New("webhook 无效")
+)
+
+// Hook 一个推送地址及其过滤条件
+type Hook struct {
+	ID        string    `json:"id" yaml:"id"`
+	URL       string    `json:"url" yaml:"url"`
+	Events    []string  `json:"events,omitempty" yaml:"events,omitempty"` // 为空时推送全部事件
+	Tags      []string  `json:"tags,omitempty" yaml:"tags,omitempty"`     // 只推送带有其中一个标签（含子标签）的备忘录
+	Secret    string    `json:"secret,omitempty" yaml:"secret"`           // 签名密钥，创建时为空则自动生成，只在创建时返回
+	Disabled  bool      `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	CreatedAt time.Time `json:"createdAt" yaml:"created_at"`
+}
+
+// 判断事件是否需要推送到该地址。设置了标签过滤时，只推送带有这些标签的备忘录的创建和修改
+func (h *Hook) matches(eventType string, memo *store.Memo) bool {
+	if h.Disabled {
+		return false
+	}
+	if len(h.Events) > 0 && !contains(h.Events, eventType) {
+		return false
+	}
+	if len(h.Tags) == 0 {
+		return true
+	}
+	if memo == nil {
+		return false
+	}
+	for _, want := range h.Tags {
+		for _, tag := range memo.Tags {
+			if tag == want || strings.HasPrefix(tag, want+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Payload 推送的请求体
+type Payload struct {
+	ID     string      `json:"id"` // 同一次推送重试时不变，接收方可用于去重
+	Event  string      `json:"event"`
+	Time   time.Time   `json:"time"`
+	MemoID string      `json:"memoId,omitempty"`
+	Memo   *store.Memo `json:"memo,omitempty"` // 发送时读取的备忘录，删除事件没有
+	Tags   []string    `json:"tags,omitempty"` // 标签事件涉及的标签
+	Source string      `json:"source"`
+}
+
+// Dispatcher 管理 webhook 配置，把事件放入队列并发送
+type Dispatcher struct {
+	store   *store.MemoStore
+	dataDir string
+	sub     *store.Subscription
+
+	mutex      sync.Mutex
+	hooks      []*Hook
+	queue      []*Delivery
+	deliveries []*Attempt
+
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration // 第一次重试的等待时间，之后每次翻倍
+	maxDelay    time.Duration
+	wake        chan struct{}
+	now         func() time.Time
+}
+
+// NewDispatcher 加载数据目录中的 webhook 配置和待发送的队列，并开始接收存储的事件。
+// 调用 Run 后才会发送
+func NewDispatcher(memoStore *store.MemoStore) (*Dispatcher, error) {
+	d := &Dispatcher{
+		store:       memoStore,
+		dataDir:     memoStore.DataDir(),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 8,
+		retryDelay:  30 * time.Second,
+		maxDelay:    time.Hour,
+		wake:        make(chan struct{}, 1),
+		now:         time.Now,
+	}
+	if err := store.ReadYAMLFile(filepath.Join(d.dataDir, hooksFileName), &d.hooks); err != nil {
+		return nil, fmt.Errorf("读取 webhook 配置失败: %w", err)
+	}
+	if err := readJSON(filepath.Join(d.dataDir, queueFileName), &d.queue); err != nil {
+		return nil, fmt.Errorf("读取 webhook 队列失败: %w", err)
+	}
+	if err := readJSON(filepath.Join(d.dataDir, deliveriesFileName), &d.deliveries); err != nil {
+		return nil, fmt.Errorf("读取 webhook 发送记录失败: %w", err)
+	}
+	d.sub, _, _ = memoStore.Events().Subscribe("")
+	return d, nil
+}
+
+// List 列出全部 webhook，按创建时间排序
+func (d *Dispatcher) List() []*Hook {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	hooks := make([]*Hook, 0, len(d.hooks))
+	for _, hook := range d.hooks {
+		copied := *hook
+		hooks = append(hooks, &copied)
+	}
+	return hooks
+}
+
+// Get 获取 webhook
+func (d *Dispatcher) Get(id string) (*Hook, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	index := d.indexLocked(id)
+	if index < 0 {
+		return nil, ErrHookNotFound
+	}
+	copied := *d.hooks[index]
+	return &copied, nil
+}
+
+// Create 添加 webhook，ID 和创建时间由服务器生成
+func (d *Dispatcher) Create(hook *Hook) (*Hook, error) {
+	created := &Hook{URL: hook.URL, Events: hook.Events, Tags: hook.Tags, Secret: hook.Secret, Disabled: hook.Disabled}
+	if err := validate(created); err != nil {
+		return nil, err
+	}
+	if created.Secret == "" {
+		secret, err := store.RandomID(16)
+		if err != nil {
+			return nil, fmt.Errorf("生成密钥失败: %w", err)
+		}
+		created.Secret = secret
+	}
+	id, err := store.RandomID(6)
+	if err != nil {
+		return nil, fmt.Errorf("生成 webhook ID失败: %w", err)
+	}
+	created.ID = id
+	created.CreatedAt = d.now().Truncate(time.Second)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.hooks = append(d.hooks, created)
+	if err := d.saveHooksLocked(); err != nil {
+		d.hooks = d.hooks[:len(d.hooks)-1]
+		return nil, err
+	}
+	copied := *created
+	return &copied, nil
+}
+
+// Update 修改 webhook 的地址、过滤条件和状态，密钥为空时保留原来的
+func (d *Dispatcher) Update(id string, hook *Hook) (*Hook, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	index := d.indexLocked(id)
+	if index < 0 {
+		return nil, ErrHookNotFound
+	}
+	previous := d.hooks[index]
+	updated := *previous
+	updated.URL = hook.URL
+	updated.Events = hook.Events
+	updated.Tags = hook.Tags
+	updated.Disabled = hook.Disabled
+	if hook.Secret != "" {
+		updated.Secret = hook.Secret
+	}
+	if err := validate(&updated); err != nil {
+		return nil, err
+	}
+
+	d.hooks[index] = &updated
+	if err := d.saveHooksLocked(); err != nil {
+		d.hooks[index] = previous
+		return nil, err
+	}
+	copied := updated
+	return &copied, nil
+}
+
+// Delete 删除 webhook，队列中尚未发送的请求一并丢弃
+func (d *Dispatcher) Delete(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	index := d.indexLocked(id)
+	if index < 0 {
+		return ErrHookNotFound
+	}
+	d.hooks = append(d.hooks[:index:index], d.hooks[index+1:]...)
+	if err := d.saveHooksLocked(); err != nil {
+		return err
+	}
+	queue := d.queue[:0]
+	for _, delivery := range d.queue {
+		if delivery.HookID != id {
+			queue = append(queue, delivery)
+		}
+	}
+	d.queue = queue
+	return d.saveQueueLocked()
+}
+
+func (d *Dispatcher) indexLocked(id string) int {
+	for i, hook := range d.hooks {
+		if hook.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// 校验地址和过滤条件，并去掉标签前的 #
+func validate(hook *Hook) error {
+	u, err := url.Parse(strings.TrimSpace(hook.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: 地址必须是 http 或 https 的完整地址", ErrInvalidHook)
+	}
+	hook.URL = u.String()
+	for _, event := range hook.Events {
+		if !contains(Events, event) {
+			return fmt.Errorf("%w: 未知的事件 %s，可选: %s", ErrInvalidHook, event, strings.Join(Events, "、"))
+		}
+	}
+	tags := make([]string, 0, len(hook.Tags))
+	for _, tag := range hook.Tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if err := store.ValidateTag(tag); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHook, err)
+		}
+		tags = append(tags, tag)
+	}
+	hook.Tags = tags
+	return nil
+}
+
+// Run 接收存储的事件放入队列，并发送到期的请求，直到 ctx 结束
+func (d *Dispatcher) Run(ctx context.Context) {
+	go d.listen(ctx)
+
+	for {
+		timer := time.NewTimer(d.deliverDue(ctx))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// 接收事件。订阅因处理过慢被断开时带上最后的事件ID重新订阅，补上错过的事件
+func (d *Dispatcher) listen(ctx context.Context) {
+	sub := d.sub
+	lastID := ""
+	for {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+			return
+		case event, ok := <-sub.C:
+			if ok {
+				lastID = event.ID
+				d.enqueue(event)
+				continue
+			}
+			var missed []store.Event
+			sub, missed, _ = d.store.Events().Subscribe(lastID)
+			for _, event := range missed {
+				lastID = event.ID
+				d.enqueue(event)
+			}
+		}
+	}
+}
+
+// 为匹配的 webhook 生成请求放入队列
+func (d *Dispatcher) enqueue(event store.Event) {
+	var memo *store.Memo
+	if event.MemoID != "" && event.Type != store.EventMemoDeleted {
+		// 读取失败（如已被删除）时按没有备忘录处理，带标签过滤的 webhook 不会触发
+		memo, _ = d.store.GetMemo(event.MemoID)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	added := false
+	for _, hook := range d.hooks {
+		if !hook.matches(event.Type, memo) {
+			continue
+		}
+		id, err := store.RandomID(8)
+		if err != nil {
+			log.Printf("webhook %s 生成请求ID失败，未放入队列: %v", hook.ID, err)
+			continue
+		}
+		d.queue = append(d.queue, &Delivery{
+			ID:          id,
+			HookID:      hook.ID,
+			Event:       event.Type,
+			MemoID:      event.MemoID,
+			Tags:        event.Tags,
+			Source:      event.Source,
+			EventTime:   event.Time,
+			CreatedAt:   d.now(),
+			NextAttempt: d.now(),
+		})
+		added = true
+	}
+	if !added {
+		return
+	}
+	d.saveQueueLocked()
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) saveHooksLocked() error {
+	return store.WriteYAMLFile(filepath.Join(d.dataDir, hooksFileName), d.hooks)
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ramblog-app/backend/store"
+)
+
+// 记录收到的请求，failures 大于 0 时先返回相应次数的 500
+type receiver struct {
+	mutex    sync.Mutex
+	failures int32
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mutex.Lock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	rc.mutex.Unlock()
+	if atomic.AddInt32(&rc.failures, -1) >= 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 等待发送记录满足条件
+func waitFor(t *testing.T, d *Dispatcher, ok func([]*Attempt) bool) []*Attempt {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if attempts := d.Deliveries("", 0); ok(attempts) {
+			return attempts
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待发送超时: %+v", d.Deliveries("", 0))
+	return nil
+}
+
+func newTestStore(t *testing.T) (*store.MemoStore, func()) {
+	tempDir, err := os.MkdirTemp("", "webhook-test")
+	if err != nil {
+		t.Fatalf("无法创建临时目录: %v", err)
+	}
+	memoStore, err := store.NewMemoStore(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("创建MemoStore失败: %v", err)
+	}
+	return memoStore, func() { os.RemoveAll(tempDir) }
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	memoStore, cleanup := newTestStore(t)
+	defer cleanup()
+
+	rc := &receiver{failures: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d, err := NewDispatcher(memoStore)
+	if err != nil {
+		t.Fatalf("创建Dispatcher失败: %v", err)
+	}
+	d.retryDelay = 10 * time.Millisecond
+
+	if _, err := d.Create(&Hook{URL: "ftp://example.com"}); !errors.Is(err, ErrInvalidHook) {
+		t.Errorf("非 http 地址应返回 ErrInvalidHook, 实际 %v", err)
+	}
+	if _, err := d.Create(&Hook{URL: server.URL, Events: []string{"memo.moved"}}); !errors.Is(err, ErrInvalidHook) {
+		t.Errorf("未知的事件应返回 ErrInvalidHook, 实际 %v", err)
+	}
+	hook, err := d.Create(&Hook{URL: server.URL, Events: []string{store.EventMemoCreated}, Tags: []string{"#todo"}})
+	if err != nil {
+		t.Fatalf("创建webhook失败: %v", err)
+	}
+	if hook.Secret == "" || hook.Tags[0] != "todo" {
+		t.Errorf("应生成密钥并去掉标签前的 #: %+v", hook)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	// 只有带 #todo 子标签的新备忘录会推送
+	memoStore.CreateMemo(&store.Memo{Content: "随便写写 #日记"})
+	todo := &store.Memo{Content: "买牛奶 #todo/家里"}
+	if err := memoStore.CreateMemo(todo); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	memoStore.UpdateMemo(todo.ID, &store.Memo{Content: "买牛奶和面包 #todo"})
+
+	attempts := waitFor(t, d, func(a []*Attempt) bool { return len(a) == 2 })
+	if attempts[1].Result != ResultRetry || attempts[1].StatusCode != 500 || attempts[1].NextRetry == nil {
+		t.Errorf("第一次发送应失败并安排重试: %+v", attempts[1])
+	}
+	if attempts[0].Result != ResultDelivered || attempts[0].Attempt != 2 || attempts[0].DeliveryID != attempts[1].DeliveryID {
+		t.Errorf("重试应发送成功: %+v", attempts[0])
+	}
+	if d.Pending("") != 0 {
+		t.Errorf("发送成功后队列应为空")
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	if len(rc.requests) != 2 {
+		t.Fatalf("应收到 2 个请求, 实际 %d", len(rc.requests))
+	}
+	for i, r := range rc.requests {
+		if got := r.Header.Get(HeaderSignature); got != Sign(hook.Secret, rc.bodies[i]) {
+			t.Errorf("签名不正确: %s", got)
+		}
+		if r.Header.Get(HeaderEvent) != store.EventMemoCreated || r.Header.Get(HeaderDelivery) != attempts[0].DeliveryID {
+			t.Errorf("请求头不正确: %v", r.Header)
+		}
+	}
+	var first, retry Payload
+	if err := json.Unmarshal(rc.bodies[0], &first); err != nil {
+		t.Fatalf("解析请求体失败: %v", err)
+	}
+	json.Unmarshal(rc.bodies[1], &retry)
+	if first.MemoID != todo.ID || first.Memo == nil || first.Memo.ID != todo.ID {
+		t.Errorf("请求体不正确: %s", rc.bodies[0])
+	}
+	if retry.ID != first.ID || !retry.Time.Equal(first.Time) {
+		t.Errorf("重试应使用相同的推送ID和事件时间: %s", rc.bodies[1])
+	}
+}
+
+func TestDispatcherQueueSurvivesRestart(t *testing.T) {
+	memoStore, cleanup := newTestStore(t)
+	defer cleanup()
+
+	rc := &receiver{failures: 1 << 20}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	first, err := NewDispatcher(memoStore)
+	if err != nil {
+		t.Fatalf("创建Dispatcher失败: %v", err)
+	}
+	hook, err := first.Create(&Hook{URL: server.URL})
+	if err != nil {
+		t.Fatalf("创建webhook失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go first.Run(ctx)
+	memoStore.CreateMemo(&store.Memo{Content: "重启前写的"})
+	waitFor(t, first, func(a []*Attempt) bool { return len(a) == 1 })
+	cancel()
+
+	// 对方恢复后，新的 Dispatcher 从文件中加载队列继续发送
+	atomic.StoreInt32(&rc.failures, 0)
+	second, err := NewDispatcher(memoStore)
+	if err != nil {
+		t.Fatalf("重新创建Dispatcher失败: %v", err)
+	}
+	if second.Pending(hook.ID) != 1 {
+		t.Fatalf("队列应在重启后保留, 实际 %d", second.Pending(hook.ID))
+	}
+	second.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go second.Run(ctx)
+
+	attempts := waitFor(t, second, func(a []*Attempt) bool { return len(a) == 2 })
+	if attempts[0].Result != ResultDelivered || attempts[0].Attempt != 2 {
+		t.Errorf("重启后应发送成功: %+v", attempts[0])
+	}
+
+	// 删除 webhook 后不再推送
+	if err := second.Delete(hook.ID); err != nil {
+		t.Fatalf("删除webhook失败: %v", err)
+	}
+	if _, err := second.Get(hook.ID); !errors.Is(err, ErrHookNotFound) {
+		t.Errorf("删除后应返回 ErrHookNotFound, 实际 %v", err)
+	}
+}
+
+func TestDispatcherQueueWithKeyring(t *testing.T) {
+	memoStore, cleanup := newTestStore(t)
+	defer cleanup()
+	keyring, err := store.CreateKeyring(memoStore.DataDir(), []byte("correct horse"), store.EncryptionAll)
+	if err != nil {
+		t.Fatalf("启用加密失败: %v", err)
+	}
+	memoStore.SetKeyring(keyring)
+
+	rc := &receiver{failures: 1 << 20}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d, err := NewDispatcher(memoStore)
+	if err != nil {
+		t.Fatalf("创建Dispatcher失败: %v", err)
+	}
+	if _, err := d.Create(&Hook{URL: server.URL}); err != nil {
+		t.Fatalf("创建webhook失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	secret := "银行卡密码在抽屉里"
+	if err := memoStore.CreateMemo(&store.Memo{Content: secret}); err != nil {
+		t.Fatalf("创建备忘录失败: %v", err)
+	}
+	waitFor(t, d, func(a []*Attempt) bool { return len(a) == 1 })
+
+	// 请求体中是解密后的内容，数据目录中的队列和发送记录不含备忘录内容
+	rc.mutex.Lock()
+	body := string(rc.bodies[0])
+	rc.mutex.Unlock()
+	if !strings.Contains(body, secret) {
+		t.Errorf("请求体应包含备忘录内容: %s", body)
+	}
+	for _, name := range []string{queueFileName, deliveriesFileName} {
+		data, err := os.ReadFile(filepath.Join(memoStore.DataDir(), name))
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", name, err)
+		}
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%s 中不应有明文的备忘录内容", name)
+		}
+	}
+	if d.Pending("") != 1 {
+		t.Errorf("失败的请求应留在队列中")
+	}
+}
@@ -162,6 +162,13 @@ export const getTags = async (): Promise<Tag[]> => {
           color: getRandomColor(),
         }));
       }
+      // 后端返回的标签对象可能没有颜色
+      if (Array.isArray(tags)) {
+        return tags.map((tag: Tag) => ({
+          ...tag,
+          color: tag.color || getRandomColor(),
+        }));
+      }
       return tags;
     } catch (error) {
       console.error('从API获取标签失败:', error);